@@ -81,10 +81,13 @@ func Migrate() error {
 		&models.MenuCategory{},
 		&models.MenuItem{},
 		&models.MenuItemImage{},
+		&models.MenuModifierGroup{},
+		&models.MenuModifierOption{},
 
 		// Orders
 		&models.Order{},
 		&models.OrderItem{},
+		&models.OrderEventLog{},
 
 		// Cart
 		&models.Cart{},
@@ -95,11 +98,24 @@ func Migrate() error {
 		&models.Delivery{},
 		&models.DeliveryPartnerDocument{},
 		&models.DeliveryZone{},
+		&models.ZoneSurgeChange{},
 		&models.DeliveryProvider{},
 		&models.DriverReferral{},
+		&models.DeliveryLocationPing{},
+		&models.LocationJumpCandidate{},
+		&models.DeliveryOffer{},
+		&models.DeliveryBatch{},
+		&models.DeliveryAttempt{},
+		&models.DriverShift{},
+		&models.PartnerOnlineSession{},
+		&models.DriverMetricEvent{},
+		&models.DriverMetricSnapshot{},
+		&models.DriverRating{},
 
 		// Promotions
 		&models.ChefPromotion{},
+		&models.PromoCode{},
+		&models.PromoRedemption{},
 
 		// Reviews
 		&models.Review{},
@@ -108,6 +124,7 @@ func Migrate() error {
 		&models.Post{},
 		&models.PostLike{},
 		&models.PostComment{},
+		&models.PostReport{},
 
 		// Catering
 		&models.CateringRequest{},
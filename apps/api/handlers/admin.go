@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"fmt"
+	"log"
 	"net/http"
 	"strconv"
 	"strings"
@@ -11,8 +12,11 @@ import (
 	"github.com/google/uuid"
 	"github.com/homechef/api/config"
 	"github.com/homechef/api/database"
+	"github.com/homechef/api/middleware"
 	"github.com/homechef/api/models"
 	"github.com/homechef/api/services"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type AdminHandler struct{}
@@ -559,6 +563,183 @@ func (h *AdminHandler) GetOrderDetails(c *gin.Context) {
 	c.JSON(http.StatusOK, order)
 }
 
+// UpdateOrderStatus lets an admin move an order through its lifecycle, e.g. to
+// cancel a stuck order or hand it back for re-dispatch.
+// PUT /admin/orders/:id/status
+func (h *AdminHandler) UpdateOrderStatus(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	var req struct {
+		Status string `json:"status" binding:"required"`
+		Reason string `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var order models.Order
+	if err := database.DB.First(&order, "id = ?", id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return
+	}
+
+	oldStatus := order.Status
+	newStatus := models.OrderStatus(req.Status)
+	by := services.ByUser(services.ActorAdmin, userID).WithNotes(req.Reason)
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		// Lock the order before its delivery, as every assignment path does
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, "id = ?", id).Error; err != nil {
+			return err
+		}
+		oldStatus = order.Status
+
+		var extra map[string]interface{}
+		releaseDelivery := false
+		switch newStatus {
+		case models.OrderStatusCancelled:
+			order.CancelReason = req.Reason
+			extra = map[string]interface{}{"cancel_reason": req.Reason}
+			releaseDelivery = true
+		case models.OrderStatusReady:
+			// Handing an order back for dispatch detaches it from its driver
+			if oldStatus == models.OrderStatusDriverAssigned || oldStatus == models.OrderStatusPickedUp || oldStatus == models.OrderStatusDelivering {
+				extra = map[string]interface{}{"delivery_id": nil}
+				releaseDelivery = true
+			}
+		}
+
+		// The old driver's delivery is closed with the change
+		if releaseDelivery && order.DeliveryID != nil {
+			if err := services.CancelActiveDelivery(tx, *order.DeliveryID, "Taken off the driver by an admin: "+req.Reason, by); err != nil {
+				return err
+			}
+		}
+
+		if err := services.UpdateOrderStatus(tx, &order, newStatus, by, extra); err != nil {
+			return err
+		}
+		if newStatus == models.OrderStatusReady && releaseDelivery {
+			order.DeliveryID = nil
+		}
+		return nil
+	})
+	if err != nil {
+		respondOrderStatusError(c, err)
+		return
+	}
+
+	database.DB.Create(&models.AuditLog{
+		UserID:     &userID,
+		Action:     "order.status_changed",
+		EntityType: "order",
+		EntityID:   order.ID.String(),
+		OldValue:   string(oldStatus),
+		NewValue:   string(newStatus) + ": " + req.Reason,
+		IPAddress:  c.ClientIP(),
+		UserAgent:  c.Request.UserAgent(),
+	})
+
+	go func() {
+		orderEvent := services.OrderEvent{
+			OrderID:     order.ID,
+			OrderNumber: order.OrderNumber,
+			CustomerID:  order.CustomerID,
+			ChefID:      order.ChefID,
+			Status:      string(order.Status),
+			Total:       order.Total,
+		}
+		subject := services.SubjectOrderUpdated
+		switch order.Status {
+		case models.OrderStatusCancelled:
+			subject = services.SubjectOrderCancelled
+		case models.OrderStatusDelivered:
+			subject = services.SubjectOrderDelivered
+		}
+		if err := services.PublishOrderEvent(subject, orderEvent); err != nil {
+			log.Printf("Failed to publish order status update event: %v", err)
+		}
+
+		// Ready orders, including ones handed back, go to the dispatcher
+		if order.Status == models.OrderStatusReady {
+			if err := services.PublishOrderEvent(services.SubjectOrderReady, orderEvent); err != nil {
+				log.Printf("Failed to publish order ready event: %v", err)
+			}
+		}
+	}()
+
+	c.JSON(http.StatusOK, order.ToResponse())
+}
+
+// GetOrderTimeline returns the full event log for an order, including actors,
+// sources and notes.
+// GET /admin/orders/:id/timeline
+func (h *AdminHandler) GetOrderTimeline(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	var order models.Order
+	if err := database.DB.First(&order, "id = ?", id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return
+	}
+
+	var events []models.OrderEventLog
+	database.DB.Where("order_id = ?", order.ID).Order("created_at").Find(&events)
+
+	// Resolve actor names for support staff
+	actorIDs := make([]uuid.UUID, 0)
+	for _, e := range events {
+		if e.ActorID != nil {
+			actorIDs = append(actorIDs, *e.ActorID)
+		}
+	}
+	actorNames := make(map[uuid.UUID]string)
+	if len(actorIDs) > 0 {
+		var users []models.User
+		database.DB.Where("id IN ?", actorIDs).Find(&users)
+		for _, u := range users {
+			actorNames[u.ID] = strings.TrimSpace(u.FirstName + " " + u.LastName)
+		}
+	}
+
+	data := make([]gin.H, len(events))
+	for i, e := range events {
+		entry := gin.H{
+			"id":        e.ID,
+			"eventType": e.EventType,
+			"oldValue":  e.OldValue,
+			"newValue":  e.NewValue,
+			"actorId":   e.ActorID,
+			"actorRole": e.ActorRole,
+			"source":    e.Source,
+			"notes":     e.Notes,
+			"createdAt": e.CreatedAt,
+		}
+		if e.ActorID != nil {
+			entry["actorName"] = actorNames[*e.ActorID]
+		}
+		data[i] = entry
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"orderId":     order.ID,
+		"orderNumber": order.OrderNumber,
+		"status":      order.Status,
+		"data":        data,
+	})
+}
+
 // GetAnalytics returns platform analytics
 func (h *AdminHandler) GetAnalytics(c *gin.Context) {
 	db := database.DB
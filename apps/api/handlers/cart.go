@@ -0,0 +1,432 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/homechef/api/database"
+	"github.com/homechef/api/middleware"
+	"github.com/homechef/api/models"
+	"gorm.io/gorm"
+)
+
+const maxCartItemQuantity = 50
+
+type CartHandler struct {
+	orders *OrderHandler
+}
+
+func NewCartHandler() *CartHandler {
+	return &CartHandler{orders: NewOrderHandler()}
+}
+
+// AddCartItemRequest represents the add-to-cart payload
+type AddCartItemRequest struct {
+	MenuItemID        uuid.UUID   `json:"menuItemId" binding:"required"`
+	Quantity          int         `json:"quantity" binding:"required,min=1"`
+	Notes             string      `json:"notes"`
+	ModifierOptionIDs []uuid.UUID `json:"modifierOptionIds"`
+	ReplaceCart       bool        `json:"replaceCart"` // Discard items from another chef instead of failing
+}
+
+// UpdateCartItemRequest represents a partial cart item update
+type UpdateCartItemRequest struct {
+	Quantity          *int         `json:"quantity"`
+	Notes             *string      `json:"notes"`
+	ModifierOptionIDs *[]uuid.UUID `json:"modifierOptionIds"`
+}
+
+// CheckoutCartRequest carries everything CreateOrderRequest needs apart from the items
+type CheckoutCartRequest struct {
+	DeliveryAddressID    *uuid.UUID            `json:"deliveryAddressId"`
+	DeliveryAddress      *CreateAddressRequest `json:"deliveryAddress"`
+	DeliveryInstructions string                `json:"deliveryInstructions"`
+	SpecialInstructions  string                `json:"specialInstructions"`
+	Tip                  float64               `json:"tip"`
+	PromoCode            string                `json:"promoCode"`
+	PaymentMethodID      *uuid.UUID            `json:"paymentMethodId"`
+	ScheduledFor         *time.Time            `json:"scheduledFor"`
+}
+
+// GetCart returns the user's cart, revalidated against the live menu. Price
+// changes stay flagged until the customer accepts them or checks out.
+func (h *CartHandler) GetCart(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	h.respondWithCart(c, userID, http.StatusOK)
+}
+
+// AcceptPrices records that the customer has seen the live prices of the items
+// in their cart, clearing any price-change flags
+// POST /cart/accept-prices
+func (h *CartHandler) AcceptPrices(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	cart, err := loadCart(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch cart"})
+		return
+	}
+	if err := refreshCartPrices(cart); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update cart prices"})
+		return
+	}
+
+	h.respondWithCart(c, userID, http.StatusOK)
+}
+
+// AddItem adds a menu item to the cart, enforcing the single-chef rule
+func (h *CartHandler) AddItem(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var req AddCartItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Quantity > maxCartItemQuantity {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Quantity exceeds the maximum allowed per item", "max": maxCartItemQuantity})
+		return
+	}
+
+	var menuItem models.MenuItem
+	if err := withModifiers(database.DB, "").Where("id = ? AND is_available = ?", req.MenuItemID, true).
+		First(&menuItem).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Menu item not found or unavailable"})
+		return
+	}
+
+	_, delta, err := menuItem.SelectModifiers(req.ModifierOptionIDs)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	unitPrice := models.RoundAmount(menuItem.Price + delta)
+	optionIDs := sortedOptionIDs(req.ModifierOptionIDs)
+
+	var chef models.ChefProfile
+	if err := database.DB.Where("id = ? AND is_active = ? AND is_verified = ?", menuItem.ChefID, true, true).
+		First(&chef).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Chef not found or not available"})
+		return
+	}
+
+	if !chef.AcceptingOrders {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Chef is not accepting orders"})
+		return
+	}
+
+	cart := models.Cart{UserID: userID}
+	if err := database.DB.Where("user_id = ?", userID).FirstOrCreate(&cart).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load cart"})
+		return
+	}
+
+	tx := database.DB.Begin()
+
+	// Single-chef rule: a cart can only hold items from one chef at a time
+	if cart.ChefID != nil && *cart.ChefID != chef.ID {
+		var itemCount int64
+		tx.Model(&models.CartItem{}).Where("cart_id = ?", cart.ID).Count(&itemCount)
+		if itemCount > 0 {
+			if !req.ReplaceCart {
+				tx.Rollback()
+				c.JSON(http.StatusConflict, gin.H{
+					"error":  "Your cart contains items from another chef. Clear it or set replaceCart to start a new one.",
+					"chefId": cart.ChefID,
+				})
+				return
+			}
+			if err := tx.Where("cart_id = ?", cart.ID).Delete(&models.CartItem{}).Error; err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clear cart"})
+				return
+			}
+		}
+	}
+
+	if err := tx.Model(&cart).Update("chef_id", chef.ID).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update cart"})
+		return
+	}
+
+	// Merge with an identical line (same item, options and notes) instead of
+	// duplicating it. The line keeps the price the customer last accepted, so a
+	// change since then is flagged rather than applied silently.
+	var existing models.CartItem
+	err = tx.Where("cart_id = ? AND menu_item_id = ? AND notes = ? AND modifier_option_ids = ?",
+		cart.ID, menuItem.ID, req.Notes, optionIDs).
+		First(&existing).Error
+	if err == nil {
+		quantity := existing.Quantity + req.Quantity
+		if quantity > maxCartItemQuantity {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Quantity exceeds the maximum allowed per item", "max": maxCartItemQuantity})
+			return
+		}
+		if err := tx.Model(&existing).Update("quantity", quantity).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update cart item"})
+			return
+		}
+	} else if err == gorm.ErrRecordNotFound {
+		item := models.CartItem{
+			CartID:            cart.ID,
+			MenuItemID:        menuItem.ID,
+			Quantity:          req.Quantity,
+			Notes:             req.Notes,
+			UnitPrice:         unitPrice,
+			ModifierOptionIDs: optionIDs,
+		}
+		if err := tx.Create(&item).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add item to cart"})
+			return
+		}
+	} else {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add item to cart"})
+		return
+	}
+
+	tx.Commit()
+
+	h.respondWithCart(c, userID, http.StatusCreated)
+}
+
+// UpdateItem changes the quantity and/or notes of a cart item
+func (h *CartHandler) UpdateItem(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+	itemID := c.Param("itemId")
+
+	var req UpdateCartItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	item, ok := findCartItem(c, userID, itemID)
+	if !ok {
+		return
+	}
+
+	updates := map[string]interface{}{}
+	if req.Quantity != nil {
+		if *req.Quantity < 1 || *req.Quantity > maxCartItemQuantity {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Quantity must be between 1 and %d", maxCartItemQuantity), "max": maxCartItemQuantity})
+			return
+		}
+		updates["quantity"] = *req.Quantity
+	}
+	if req.Notes != nil {
+		updates["notes"] = *req.Notes
+	}
+	if req.ModifierOptionIDs != nil {
+		var menuItem models.MenuItem
+		if err := withModifiers(database.DB, "").Where("id = ?", item.MenuItemID).First(&menuItem).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Menu item not found or unavailable"})
+			return
+		}
+		_, delta, err := menuItem.SelectModifiers(*req.ModifierOptionIDs)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		updates["modifier_option_ids"] = sortedOptionIDs(*req.ModifierOptionIDs)
+		updates["unit_price"] = models.RoundAmount(menuItem.Price + delta)
+	}
+
+	if len(updates) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Nothing to update"})
+		return
+	}
+
+	if err := database.DB.Model(item).Updates(updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update cart item"})
+		return
+	}
+
+	h.respondWithCart(c, userID, http.StatusOK)
+}
+
+// RemoveItem removes a single item from the cart
+func (h *CartHandler) RemoveItem(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+	itemID := c.Param("itemId")
+
+	item, ok := findCartItem(c, userID, itemID)
+	if !ok {
+		return
+	}
+
+	if err := database.DB.Delete(item).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove cart item"})
+		return
+	}
+
+	// Release the chef lock once the cart is empty
+	var remaining int64
+	database.DB.Model(&models.CartItem{}).Where("cart_id = ?", item.CartID).Count(&remaining)
+	if remaining == 0 {
+		database.DB.Model(&models.Cart{}).Where("id = ?", item.CartID).Update("chef_id", nil)
+	}
+
+	h.respondWithCart(c, userID, http.StatusOK)
+}
+
+// ClearCart removes every item from the cart
+func (h *CartHandler) ClearCart(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var cart models.Cart
+	if err := database.DB.Where("user_id = ?", userID).First(&cart).Error; err != nil {
+		c.JSON(http.StatusOK, gin.H{"message": "Cart cleared"})
+		return
+	}
+
+	tx := database.DB.Begin()
+	if err := tx.Where("cart_id = ?", cart.ID).Delete(&models.CartItem{}).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clear cart"})
+		return
+	}
+	if err := tx.Model(&cart).Update("chef_id", nil).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clear cart"})
+		return
+	}
+	tx.Commit()
+
+	c.JSON(http.StatusOK, gin.H{"message": "Cart cleared"})
+}
+
+// Checkout places an order from the current cart contents. The cart is
+// revalidated first; unavailable items or price changes must be reviewed by the
+// customer before the order can be placed.
+func (h *CartHandler) Checkout(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var req CheckoutCartRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	cart, err := loadCart(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch cart"})
+		return
+	}
+
+	if cart.ChefID == nil || len(cart.Items) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cart is empty"})
+		return
+	}
+
+	response := cart.ToResponse()
+	if response.HasUnavailableItems || response.HasPriceChanges {
+		if err := refreshCartPrices(cart); err != nil {
+			log.Printf("Failed to refresh cart prices for user %s: %v", userID, err)
+		}
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Your cart has changed. Please review it before checking out.",
+			"cart":  response,
+		})
+		return
+	}
+
+	if !response.MeetsMinimumOrder {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":           "Cart does not meet the chef's minimum order",
+			"amountToMinimum": response.AmountToMinimum,
+		})
+		return
+	}
+
+	orderReq := CreateOrderRequest{
+		ChefID:               *cart.ChefID,
+		Items:                make([]CreateOrderItem, len(cart.Items)),
+		DeliveryAddressID:    req.DeliveryAddressID,
+		DeliveryAddress:      req.DeliveryAddress,
+		DeliveryInstructions: req.DeliveryInstructions,
+		SpecialInstructions:  req.SpecialInstructions,
+		Tip:                  req.Tip,
+		PromoCode:            req.PromoCode,
+		PaymentMethodID:      req.PaymentMethodID,
+		ScheduledFor:         req.ScheduledFor,
+	}
+	for i, item := range cart.Items {
+		orderReq.Items[i] = CreateOrderItem{
+			MenuItemID: item.MenuItemID,
+			Quantity:   item.Quantity,
+			Notes:      item.Notes,
+			ModifierOptionIDs: item.OptionIDs(),
+		}
+	}
+
+	h.orders.placeOrder(c, userID, orderReq)
+}
+
+// respondWithCart reloads the cart and writes it with the given status
+func (h *CartHandler) respondWithCart(c *gin.Context, userID uuid.UUID, status int) {
+	cart, err := loadCart(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch cart"})
+		return
+	}
+	c.JSON(status, cart.ToResponse())
+}
+
+// loadCart fetches the user's cart with its chef and menu items. A user without
+// a cart gets an empty one rather than an error.
+func loadCart(userID uuid.UUID) (*models.Cart, error) {
+	var cart models.Cart
+	query := database.DB.Preload("Chef").
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("created_at ASC") }).
+		Preload("Items.MenuItem")
+	err := withModifiers(query, "Items.MenuItem.").
+		Where("user_id = ?", userID).
+		First(&cart).Error
+	if err == gorm.ErrRecordNotFound {
+		return &models.Cart{UserID: userID, Items: []models.CartItem{}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &cart, nil
+}
+
+// refreshCartPrices records the live menu price (with modifiers) on each item once the customer
+// has been shown it, so a price change is only flagged once.
+func refreshCartPrices(cart *models.Cart) error {
+	for _, item := range cart.Items {
+		if !item.IsOrderable() {
+			continue
+		}
+		if unitPrice, _ := item.LiveUnitPrice(); item.UnitPrice != unitPrice {
+			if err := database.DB.Model(&models.CartItem{}).Where("id = ?", item.ID).
+				Update("unit_price", unitPrice).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// findCartItem loads a cart item owned by the user, writing a 404 if missing
+func findCartItem(c *gin.Context, userID uuid.UUID, itemID string) (*models.CartItem, bool) {
+	var item models.CartItem
+	if err := database.DB.Joins("JOIN carts ON carts.id = cart_items.cart_id").
+		Where("cart_items.id = ? AND carts.user_id = ?", itemID, userID).
+		First(&item).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Cart item not found"})
+		return nil, false
+	}
+	return &item, true
+}
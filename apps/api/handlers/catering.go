@@ -0,0 +1,684 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/homechef/api/database"
+	"github.com/homechef/api/middleware"
+	"github.com/homechef/api/models"
+	"github.com/homechef/api/services"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	cateringMinLeadTime          = 24 * time.Hour     // Earliest an event can be booked
+	cateringDefaultQuoteWindow   = 72 * time.Hour     // Default quote deadline when none is given
+	cateringDefaultQuoteValidity = 7 * 24 * time.Hour // Default validity of a chef's quote
+)
+
+type CateringHandler struct{}
+
+func NewCateringHandler() *CateringHandler {
+	return &CateringHandler{}
+}
+
+type CreateCateringRequestRequest struct {
+	EventType     string     `json:"eventType" binding:"required"`
+	EventDate     time.Time  `json:"eventDate" binding:"required"`
+	EventTime     string     `json:"eventTime"`
+	GuestCount    int        `json:"guestCount" binding:"required,min=1"`
+	Budget        float64    `json:"budget" binding:"min=0"`
+	CuisineTypes  []string   `json:"cuisineTypes"`
+	DietaryNeeds  []string   `json:"dietaryNeeds"`
+	MenuStyle     string     `json:"menuStyle"`
+	Description   string     `json:"description"`
+	VenueName     string     `json:"venueName"`
+	AddressLine1  string     `json:"addressLine1" binding:"required"`
+	AddressLine2  string     `json:"addressLine2"`
+	City          string     `json:"city" binding:"required"`
+	State         string     `json:"state"`
+	PostalCode    string     `json:"postalCode"`
+	Latitude      float64    `json:"latitude"`
+	Longitude     float64    `json:"longitude"`
+	ContactName   string     `json:"contactName"`
+	ContactPhone  string     `json:"contactPhone"`
+	ContactEmail  string     `json:"contactEmail"`
+	QuoteDeadline *time.Time `json:"quoteDeadline"`
+}
+
+type SubmitCateringQuoteRequest struct {
+	ProposedMenu      string     `json:"proposedMenu" binding:"required"`
+	MenuItems         []string   `json:"menuItems"`
+	PricePerPerson    float64    `json:"pricePerPerson" binding:"required,gt=0"`
+	TotalPrice        float64    `json:"totalPrice" binding:"min=0"` // Defaults to pricePerPerson x guests
+	Notes             string     `json:"notes"`
+	IncludesSetup     bool       `json:"includesSetup"`
+	IncludesServing   bool       `json:"includesServing"`
+	IncludesCleanup   bool       `json:"includesCleanup"`
+	IncludesEquipment bool       `json:"includesEquipment"`
+	ValidUntil        *time.Time `json:"validUntil"`
+}
+
+// ---------- Customer ----------
+
+// CreateRequest posts a new catering request for chefs to quote on.
+// POST /catering/requests
+func (h *CateringHandler) CreateRequest(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var req CreateCateringRequestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	now := time.Now()
+	if req.EventDate.Before(now.Add(cateringMinLeadTime)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Event date must be at least 24 hours from now"})
+		return
+	}
+
+	// Quotes must be in well before the event so the chosen chef has time to prepare
+	latestDeadline := req.EventDate.Add(-cateringMinLeadTime)
+	deadline := now.Add(cateringDefaultQuoteWindow)
+	if req.QuoteDeadline != nil {
+		deadline = *req.QuoteDeadline
+		if !deadline.After(now) || deadline.After(latestDeadline) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Quote deadline must be in the future and at least 24 hours before the event"})
+			return
+		}
+	}
+	if deadline.After(latestDeadline) {
+		deadline = latestDeadline
+	}
+
+	request := models.CateringRequest{
+		CustomerID:    userID,
+		Status:        models.CateringStatusOpen,
+		EventType:     req.EventType,
+		EventDate:     req.EventDate,
+		EventTime:     req.EventTime,
+		GuestCount:    req.GuestCount,
+		Budget:        req.Budget,
+		CuisineTypes:  ensureStringArray(normalizeCuisines(req.CuisineTypes)),
+		DietaryNeeds:  ensureStringArray(req.DietaryNeeds),
+		MenuStyle:     req.MenuStyle,
+		Description:   req.Description,
+		VenueName:     req.VenueName,
+		AddressLine1:  req.AddressLine1,
+		AddressLine2:  req.AddressLine2,
+		City:          req.City,
+		State:         req.State,
+		PostalCode:    req.PostalCode,
+		Latitude:      req.Latitude,
+		Longitude:     req.Longitude,
+		ContactName:   req.ContactName,
+		ContactPhone:  req.ContactPhone,
+		ContactEmail:  req.ContactEmail,
+		QuoteDeadline: &deadline,
+	}
+
+	if err := database.DB.Create(&request).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create catering request"})
+		return
+	}
+
+	go func() {
+		if err := services.PublishEvent(services.SubjectCateringRequest, "catering.request.created", userID, map[string]interface{}{
+			"request_id":    request.ID.String(),
+			"customer_id":   userID.String(),
+			"event_type":    request.EventType,
+			"event_date":    request.EventDate,
+			"guest_count":   request.GuestCount,
+			"city":          request.City,
+			"cuisine_types": []string(request.CuisineTypes),
+		}); err != nil {
+			log.Printf("Failed to publish catering request event: %v", err)
+		}
+	}()
+
+	c.JSON(http.StatusCreated, cateringRequestDetail(&request))
+}
+
+// GetMyRequests lists the customer's catering requests.
+// GET /catering/requests
+func (h *CateringHandler) GetMyRequests(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 50 {
+		limit = 10
+	}
+	offset := (page - 1) * limit
+
+	query := database.DB.Model(&models.CateringRequest{}).Where("customer_id = ?", userID)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var requests []models.CateringRequest
+	if err := query.Preload("Quotes").
+		Order("created_at DESC").
+		Offset(offset).Limit(limit).
+		Find(&requests).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch catering requests"})
+		return
+	}
+
+	responses := make([]models.CateringRequestResponse, len(requests))
+	for i := range requests {
+		responses[i] = requests[i].ToResponse()
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": responses,
+		"pagination": gin.H{
+			"page":       page,
+			"limit":      limit,
+			"total":      total,
+			"totalPages": (total + int64(limit) - 1) / int64(limit),
+			"hasNext":    int64(offset+limit) < total,
+			"hasPrev":    page > 1,
+		},
+	})
+}
+
+// GetRequest returns one of the customer's catering requests with its quotes.
+// GET /catering/requests/:id
+func (h *CateringHandler) GetRequest(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var request models.CateringRequest
+	if err := database.DB.Preload("Quotes").Preload("AcceptedQuote").Preload("AcceptedQuote.Chef").
+		Where("id = ? AND customer_id = ?", c.Param("id"), userID).
+		First(&request).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Catering request not found"})
+		return
+	}
+
+	response := cateringRequestDetail(&request)
+	if request.AcceptedQuote != nil {
+		response["acceptedQuote"] = request.AcceptedQuote.ToResponse()
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetQuotes lists the quotes received on a request, cheapest first.
+// GET /catering/requests/:id/quotes
+func (h *CateringHandler) GetQuotes(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var request models.CateringRequest
+	if err := database.DB.Where("id = ? AND customer_id = ?", c.Param("id"), userID).
+		First(&request).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Catering request not found"})
+		return
+	}
+
+	var quotes []models.CateringQuote
+	if err := database.DB.Preload("Chef").
+		Where("request_id = ?", request.ID).
+		Order("CASE WHEN status = 'pending' THEN 0 ELSE 1 END, total_price ASC").
+		Find(&quotes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch quotes"})
+		return
+	}
+
+	responses := make([]models.CateringQuoteResponse, len(quotes))
+	for i := range quotes {
+		responses[i] = quotes[i].ToResponse()
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": responses})
+}
+
+// AcceptQuote accepts a chef's quote. Every other quote on the request is
+// rejected in the same transaction; the request row is locked so two quotes
+// can never both be accepted.
+// POST /catering/quotes/:id/accept
+func (h *CateringHandler) AcceptQuote(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var quote models.CateringQuote
+	if err := database.DB.Where("id = ?", c.Param("id")).First(&quote).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Quote not found"})
+		return
+	}
+
+	tx := database.DB.Begin()
+
+	var request models.CateringRequest
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND customer_id = ?", quote.RequestID, userID).
+		First(&request).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusNotFound, gin.H{"error": "Quote not found"})
+		return
+	}
+
+	if request.Status != models.CateringStatusOpen && request.Status != models.CateringStatusQuoted {
+		tx.Rollback()
+		c.JSON(http.StatusConflict, gin.H{"error": "This request is no longer accepting quotes", "status": request.Status})
+		return
+	}
+
+	// Re-read the quote under the lock in case the sweep expired it meanwhile
+	now := time.Now()
+	if err := tx.Where("id = ?", quote.ID).First(&quote).Error; err != nil ||
+		quote.Status != models.QuoteStatusPending ||
+		(quote.ValidUntil != nil && now.After(*quote.ValidUntil)) {
+		tx.Rollback()
+		c.JSON(http.StatusConflict, gin.H{"error": "This quote is no longer valid"})
+		return
+	}
+
+	if err := tx.Model(&quote).Updates(map[string]interface{}{
+		"status":      models.QuoteStatusAccepted,
+		"accepted_at": now,
+	}).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to accept quote"})
+		return
+	}
+
+	var rejectedChefIDs []uuid.UUID
+	tx.Model(&models.CateringQuote{}).
+		Where("request_id = ? AND id <> ? AND status = ?", request.ID, quote.ID, models.QuoteStatusPending).
+		Pluck("chef_id", &rejectedChefIDs)
+
+	if err := tx.Model(&models.CateringQuote{}).
+		Where("request_id = ? AND id <> ? AND status = ?", request.ID, quote.ID, models.QuoteStatusPending).
+		Updates(map[string]interface{}{
+			"status":      models.QuoteStatusRejected,
+			"rejected_at": now,
+		}).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to accept quote"})
+		return
+	}
+
+	if err := tx.Model(&request).Updates(map[string]interface{}{
+		"status":            models.CateringStatusAccepted,
+		"accepted_quote_id": quote.ID,
+	}).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to accept quote"})
+		return
+	}
+
+	tx.Commit()
+
+	go func() {
+		if err := services.PublishEvent(services.SubjectCateringQuote, "catering.quote.accepted", userID, map[string]interface{}{
+			"request_id":        request.ID.String(),
+			"quote_id":          quote.ID.String(),
+			"chef_id":           quote.ChefID.String(),
+			"customer_id":       userID.String(),
+			"total_price":       quote.TotalPrice,
+			"rejected_chef_ids": rejectedChefIDs,
+		}); err != nil {
+			log.Printf("Failed to publish catering quote accepted event: %v", err)
+		}
+	}()
+
+	database.DB.Preload("Chef").First(&quote, quote.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Quote accepted",
+		"quote":   quote.ToResponse(),
+	})
+}
+
+// CancelRequest cancels an open request and rejects its pending quotes.
+// POST /catering/requests/:id/cancel
+func (h *CateringHandler) CancelRequest(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	tx := database.DB.Begin()
+
+	var request models.CateringRequest
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND customer_id = ?", c.Param("id"), userID).
+		First(&request).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusNotFound, gin.H{"error": "Catering request not found"})
+		return
+	}
+
+	if request.Status != models.CateringStatusOpen && request.Status != models.CateringStatusQuoted {
+		tx.Rollback()
+		c.JSON(http.StatusConflict, gin.H{"error": "This request can no longer be cancelled", "status": request.Status})
+		return
+	}
+
+	now := time.Now()
+	tx.Model(&models.CateringQuote{}).
+		Where("request_id = ? AND status = ?", request.ID, models.QuoteStatusPending).
+		Updates(map[string]interface{}{
+			"status":      models.QuoteStatusRejected,
+			"rejected_at": now,
+		})
+	if err := tx.Model(&request).Update("status", models.CateringStatusCancelled).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel request"})
+		return
+	}
+
+	tx.Commit()
+
+	c.JSON(http.StatusOK, gin.H{"message": "Catering request cancelled"})
+}
+
+// ---------- Chef ----------
+
+// GetAvailableRequests lists open requests that match the chef's cuisines and
+// service radius and are still accepting quotes.
+// GET /chef/catering/requests
+func (h *CateringHandler) GetAvailableRequests(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var chef models.ChefProfile
+	if err := database.DB.Where("user_id = ?", userID).First(&chef).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Chef profile not found"})
+		return
+	}
+
+	if !chef.IsVerified || !chef.IsActive {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only verified chefs can view catering requests"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 50 {
+		limit = 20
+	}
+
+	now := time.Now()
+	query := services.ScopeCateringRequestsForChef(database.DB.Model(&models.CateringRequest{}), &chef).
+		Where("status IN ?", []models.CateringRequestStatus{models.CateringStatusOpen, models.CateringStatusQuoted}).
+		Where("(quote_deadline IS NULL OR quote_deadline > ?) AND event_date > ?", now, now).
+		Where("customer_id <> ?", userID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch catering requests"})
+		return
+	}
+
+	offset := (page - 1) * limit
+	var requests []models.CateringRequest
+	if err := query.Order("quote_deadline ASC, created_at DESC").
+		Offset(offset).Limit(limit).Find(&requests).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch catering requests"})
+		return
+	}
+
+	var quotedIDs []uuid.UUID
+	database.DB.Model(&models.CateringQuote{}).Where("chef_id = ?", chef.ID).Pluck("request_id", &quotedIDs)
+	quoted := make(map[uuid.UUID]bool, len(quotedIDs))
+	for _, id := range quotedIDs {
+		quoted[id] = true
+	}
+
+	matches := make([]gin.H, 0, len(requests))
+	for i := range requests {
+		req := &requests[i]
+		item := gin.H{
+			"request":       req.ToResponse(),
+			"quoteDeadline": req.QuoteDeadline,
+			"hasQuoted":     quoted[req.ID],
+		}
+		if req.Latitude != 0 && chef.Latitude != 0 {
+			item["distanceKm"] = models.RoundAmount(haversine(chef.Latitude, chef.Longitude, req.Latitude, req.Longitude))
+		}
+		matches = append(matches, item)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": matches,
+		"pagination": gin.H{
+			"page":       page,
+			"limit":      limit,
+			"total":      total,
+			"totalPages": (total + int64(limit) - 1) / int64(limit),
+			"hasNext":    int64(offset+limit) < total,
+			"hasPrev":    page > 1,
+		},
+	})
+}
+
+// SubmitQuote submits the chef's quote on a request. A chef can quote once per request.
+// POST /chef/catering/requests/:id/quote
+func (h *CateringHandler) SubmitQuote(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var chef models.ChefProfile
+	if err := database.DB.Where("user_id = ?", userID).First(&chef).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Chef profile not found"})
+		return
+	}
+
+	if !chef.IsVerified || !chef.IsActive {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only verified chefs can submit catering quotes"})
+		return
+	}
+
+	var req SubmitCateringQuoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Lock the request so a quote cannot slip in while it is being accepted
+	tx := database.DB.Begin()
+
+	var request models.CateringRequest
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", c.Param("id")).First(&request).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusNotFound, gin.H{"error": "Catering request not found"})
+		return
+	}
+
+	now := time.Now()
+	if request.CustomerID == userID || !services.CateringChefMatches(&request, &chef) {
+		tx.Rollback()
+		c.JSON(http.StatusNotFound, gin.H{"error": "Catering request not found"})
+		return
+	}
+	if !services.CateringRequestAcceptsQuotes(&request, now) {
+		tx.Rollback()
+		c.JSON(http.StatusConflict, gin.H{"error": "This request is no longer accepting quotes"})
+		return
+	}
+
+	validUntil := now.Add(cateringDefaultQuoteValidity)
+	if req.ValidUntil != nil {
+		validUntil = *req.ValidUntil
+		if !validUntil.After(now) {
+			tx.Rollback()
+			c.JSON(http.StatusBadRequest, gin.H{"error": "validUntil must be in the future"})
+			return
+		}
+	}
+	if validUntil.After(request.EventDate) {
+		validUntil = request.EventDate
+	}
+
+	totalPrice := req.TotalPrice
+	if totalPrice == 0 {
+		totalPrice = models.RoundAmount(req.PricePerPerson * float64(request.GuestCount))
+	}
+
+	quote := models.CateringQuote{
+		RequestID:         request.ID,
+		ChefID:            chef.ID,
+		Status:            models.QuoteStatusPending,
+		ProposedMenu:      req.ProposedMenu,
+		MenuItems:         ensureStringArray(req.MenuItems),
+		PricePerPerson:    req.PricePerPerson,
+		TotalPrice:        totalPrice,
+		Notes:             req.Notes,
+		IncludesSetup:     req.IncludesSetup,
+		IncludesServing:   req.IncludesServing,
+		IncludesCleanup:   req.IncludesCleanup,
+		IncludesEquipment: req.IncludesEquipment,
+		ValidUntil:        &validUntil,
+	}
+
+	// The unique (request_id, chef_id) index turns a concurrent double submit into a no-op
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&quote)
+	if result.Error != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to submit quote"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		c.JSON(http.StatusConflict, gin.H{"error": "You have already quoted on this request"})
+		return
+	}
+
+	if err := tx.Model(&models.CateringRequest{}).
+		Where("id = ? AND status = ?", request.ID, models.CateringStatusOpen).
+		Update("status", models.CateringStatusQuoted).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to submit quote"})
+		return
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to submit quote"})
+		return
+	}
+
+	go func() {
+		if err := services.PublishEvent(services.SubjectCateringQuote, "catering.quote.submitted", userID, map[string]interface{}{
+			"request_id":  request.ID.String(),
+			"quote_id":    quote.ID.String(),
+			"chef_id":     chef.ID.String(),
+			"chef_name":   chef.BusinessName,
+			"customer_id": request.CustomerID.String(),
+			"total_price": quote.TotalPrice,
+			"valid_until": quote.ValidUntil,
+		}); err != nil {
+			log.Printf("Failed to publish catering quote event: %v", err)
+		}
+	}()
+
+	quote.Chef = chef
+	c.JSON(http.StatusCreated, quote.ToResponse())
+}
+
+// GetChefQuotes lists the chef's quotes. Venue and contact details are only
+// revealed once the customer has accepted the quote.
+// GET /chef/catering/quotes
+func (h *CateringHandler) GetChefQuotes(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var chef models.ChefProfile
+	if err := database.DB.Where("user_id = ?", userID).First(&chef).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Chef profile not found"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 50 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	query := database.DB.Model(&models.CateringQuote{}).Where("chef_id = ?", chef.ID)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var quotes []models.CateringQuote
+	if err := query.Preload("Request").
+		Order("created_at DESC").
+		Offset(offset).Limit(limit).
+		Find(&quotes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch quotes"})
+		return
+	}
+
+	responses := make([]gin.H, len(quotes))
+	for i := range quotes {
+		quote := &quotes[i]
+		quote.Chef = chef
+		item := gin.H{
+			"quote":   quote.ToResponse(),
+			"request": quote.Request.ToResponse(),
+		}
+		if quote.Status == models.QuoteStatusAccepted {
+			item["request"] = cateringRequestDetail(&quote.Request)
+		}
+		responses[i] = item
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": responses,
+		"pagination": gin.H{
+			"page":       page,
+			"limit":      limit,
+			"total":      total,
+			"totalPages": (total + int64(limit) - 1) / int64(limit),
+			"hasNext":    int64(offset+limit) < total,
+			"hasPrev":    page > 1,
+		},
+	})
+}
+
+// cateringRequestDetail is the full request view (venue and contact included)
+// shown to the customer and, after acceptance, to the chosen chef
+func cateringRequestDetail(r *models.CateringRequest) gin.H {
+	return gin.H{
+		"request":         r.ToResponse(),
+		"addressLine1":    r.AddressLine1,
+		"addressLine2":    r.AddressLine2,
+		"postalCode":      r.PostalCode,
+		"latitude":        r.Latitude,
+		"longitude":       r.Longitude,
+		"contactName":     r.ContactName,
+		"contactPhone":    r.ContactPhone,
+		"contactEmail":    r.ContactEmail,
+		"quoteDeadline":   r.QuoteDeadline,
+		"acceptedQuoteId": r.AcceptedQuoteID,
+	}
+}
+
+// normalizeCuisines trims cuisine names and drops blanks
+func normalizeCuisines(cuisines []string) []string {
+	result := make([]string, 0, len(cuisines))
+	for _, cuisine := range cuisines {
+		if cuisine = strings.TrimSpace(cuisine); cuisine != "" {
+			result = append(result, cuisine)
+		}
+	}
+	return result
+}
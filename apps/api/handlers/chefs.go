@@ -163,7 +163,7 @@ func (h *ChefHandler) GetChefMenu(c *gin.Context) {
 
 	category := c.Query("category")
 
-	query := database.DB.Where("chef_id = ? AND is_available = ? AND is_approved = ?", chefID, true, true).Preload("Images")
+	query := withModifiers(database.DB, "").Where("chef_id = ? AND is_available = ? AND is_approved = ?", chefID, true, true).Preload("Images")
 
 	if category != "" {
 		query = query.Where("category_id = ?", category)
@@ -499,6 +499,58 @@ func (h *ChefHandler) GetChefOrders(c *gin.Context) {
 	})
 }
 
+// GetPendingPickups lists the chef's orders a driver is on the way to collect,
+// with the pickup code to give the driver once they have been recognised
+// GET /chef/pickups
+func (h *ChefHandler) GetPendingPickups(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var chef models.ChefProfile
+	if err := database.DB.Where("user_id = ?", userID).First(&chef).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Chef profile not found"})
+		return
+	}
+
+	var orders []models.Order
+	if err := database.DB.Preload("Delivery").Preload("Delivery.DeliveryPartner").Preload("Delivery.DeliveryPartner.User").
+		Where("chef_id = ? AND status = ?", chef.ID, models.OrderStatusDriverAssigned).
+		Order("driver_assigned_at ASC").
+		Find(&orders).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch pickups"})
+		return
+	}
+
+	pickups := make([]gin.H, 0, len(orders))
+	for _, order := range orders {
+		if order.Delivery == nil {
+			continue
+		}
+		d := order.Delivery
+		partner := d.DeliveryPartner
+		pickup := gin.H{
+			"orderId":          order.ID,
+			"orderNumber":      order.OrderNumber,
+			"deliveryId":       d.ID,
+			"deliveryStatus":   d.Status,
+			"pickupCode":       d.PickupCode,
+			"driverAssignedAt": order.DriverAssignedAt,
+			"driver": gin.H{
+				"name":          partner.User.FirstName + " " + partner.User.LastName,
+				"avatar":        partner.User.Avatar,
+				"vehicleType":   partner.VehicleType,
+				"vehicleNumber": partner.VehicleNumber,
+				"vehicleColor":  partner.VehicleColor,
+			},
+		}
+		if partner.CurrentLatitude != 0 || partner.CurrentLongitude != 0 {
+			pickup["driverDistance"] = haversine(partner.CurrentLatitude, partner.CurrentLongitude, chef.Latitude, chef.Longitude)
+		}
+		pickups = append(pickups, pickup)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": pickups})
+}
+
 // UpdateOrderStatus updates an order's status
 func (h *ChefHandler) UpdateOrderStatus(c *gin.Context) {
 	userID, _ := middleware.GetUserID(c)
@@ -518,15 +570,21 @@ func (h *ChefHandler) UpdateOrderStatus(c *gin.Context) {
 
 	var req struct {
 		Status string `json:"status" binding:"required"`
+		Reason string `json:"reason"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	order.Status = models.OrderStatus(req.Status)
-	if err := database.DB.Save(&order).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update order"})
+	var extra map[string]interface{}
+	if models.OrderStatus(req.Status) == models.OrderStatusCancelled {
+		order.CancelReason = req.Reason
+		extra = map[string]interface{}{"cancel_reason": req.Reason}
+	}
+
+	if err := services.UpdateOrderStatus(database.DB, &order, models.OrderStatus(req.Status), services.ByUser(services.ActorChef, userID).WithNotes(req.Reason), extra); err != nil {
+		respondOrderStatusError(c, err)
 		return
 	}
 
@@ -543,13 +601,20 @@ func (h *ChefHandler) UpdateOrderStatus(c *gin.Context) {
 
 		// Determine which subject to publish to based on status
 		subject := services.SubjectOrderUpdated
-		if order.Status == models.OrderStatusDelivered {
-			subject = services.SubjectOrderDelivered
+		if order.Status == models.OrderStatusCancelled {
+			subject = services.SubjectOrderCancelled
 		}
 
 		if err := services.PublishOrderEvent(subject, orderEvent); err != nil {
 			log.Printf("Failed to publish order status update event: %v", err)
 		}
+
+		// Ready orders go to the dispatcher
+		if order.Status == models.OrderStatusReady {
+			if err := services.PublishOrderEvent(services.SubjectOrderReady, orderEvent); err != nil {
+				log.Printf("Failed to publish order ready event: %v", err)
+			}
+		}
 	}()
 
 	c.JSON(http.StatusOK, order.ToResponse())
@@ -656,6 +721,10 @@ func (h *ChefHandler) GetChefSettings(c *gin.Context) {
 			ChefID:              chef.ID,
 			AutoAcceptOrders:    false,
 			AutoAcceptThreshold: 0,
+			AutoAcceptMaxActive: 5,
+			SlotMinutes:         services.DefaultSlotMinutes,
+			SlotCapacity:        services.DefaultSlotCapacity,
+			ScheduleLeadMinutes: services.DefaultScheduleLeadMinutes,
 			PushNewOrder:        true,
 			PushOrderUpdate:     true,
 			EmailDailySummary:   true,
@@ -680,6 +749,11 @@ func (h *ChefHandler) GetChefSettings(c *gin.Context) {
 		},
 		"autoAcceptOrders":    settings.AutoAcceptOrders,
 		"autoAcceptThreshold": settings.AutoAcceptThreshold,
+		"autoAcceptMaxActive": settings.AutoAcceptMaxActive,
+		"slotMinutes":         settings.SlotMinutes,
+		"slotCapacity":        settings.SlotCapacity,
+		"scheduleLeadMinutes": settings.ScheduleLeadMinutes,
+		"timezone":            settings.Timezone,
 		"acceptingOrders":     chef.AcceptingOrders,
 		"authProvider":        string(user.AuthProvider),
 	})
@@ -704,7 +778,12 @@ func (h *ChefHandler) UpdateChefSettings(c *gin.Context) {
 			SmsNewOrder      bool `json:"smsNewOrder"`
 		} `json:"notifications"`
 		AutoAcceptOrders    bool    `json:"autoAcceptOrders"`
-		AutoAcceptThreshold float64 `json:"autoAcceptThreshold"`
+		AutoAcceptThreshold float64 `json:"autoAcceptThreshold" binding:"min=0"`
+		AutoAcceptMaxActive *int    `json:"autoAcceptMaxActive" binding:"omitempty,min=0"`
+		SlotMinutes         *int    `json:"slotMinutes" binding:"omitempty,min=15,max=240"`
+		SlotCapacity        *int    `json:"slotCapacity" binding:"omitempty,min=0"`
+		ScheduleLeadMinutes *int    `json:"scheduleLeadMinutes" binding:"omitempty,min=0"`
+		Timezone            *string `json:"timezone"`
 		AcceptingOrders     bool    `json:"acceptingOrders"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -712,16 +791,50 @@ func (h *ChefHandler) UpdateChefSettings(c *gin.Context) {
 		return
 	}
 
+	if req.Timezone != nil {
+		if _, err := time.LoadLocation(*req.Timezone); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown timezone"})
+			return
+		}
+	}
+
 	// Update acceptingOrders on chef profile
 	database.DB.Model(&chef).Update("accepting_orders", req.AcceptingOrders)
 
 	// Upsert chef settings
 	var settings models.ChefSettings
 	if err := database.DB.Where("chef_id = ?", chef.ID).First(&settings).Error; err != nil {
-		settings = models.ChefSettings{ChefID: chef.ID}
+		settings = models.ChefSettings{
+			ChefID:              chef.ID,
+			AutoAcceptMaxActive: 5,
+			SlotMinutes:         services.DefaultSlotMinutes,
+			SlotCapacity:        services.DefaultSlotCapacity,
+			ScheduleLeadMinutes: services.DefaultScheduleLeadMinutes,
+		}
+		// Create swaps zero values for column defaults, so insert the defaults
+		// first and let the Save below write what the chef asked for
+		if err := database.DB.Create(&settings).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save settings"})
+			return
+		}
 	}
 	settings.AutoAcceptOrders = req.AutoAcceptOrders
 	settings.AutoAcceptThreshold = req.AutoAcceptThreshold
+	if req.AutoAcceptMaxActive != nil {
+		settings.AutoAcceptMaxActive = *req.AutoAcceptMaxActive
+	}
+	if req.SlotMinutes != nil {
+		settings.SlotMinutes = *req.SlotMinutes
+	}
+	if req.SlotCapacity != nil {
+		settings.SlotCapacity = *req.SlotCapacity
+	}
+	if req.ScheduleLeadMinutes != nil {
+		settings.ScheduleLeadMinutes = *req.ScheduleLeadMinutes
+	}
+	if req.Timezone != nil {
+		settings.Timezone = *req.Timezone
+	}
 	settings.PushNewOrder = req.Notifications.PushNewOrder
 	settings.PushOrderUpdate = req.Notifications.PushOrderUpdate
 	settings.EmailDailySummary = req.Notifications.EmailDailySummary
@@ -739,6 +852,11 @@ func (h *ChefHandler) UpdateChefSettings(c *gin.Context) {
 		},
 		"autoAcceptOrders":    settings.AutoAcceptOrders,
 		"autoAcceptThreshold": settings.AutoAcceptThreshold,
+		"autoAcceptMaxActive": settings.AutoAcceptMaxActive,
+		"slotMinutes":         settings.SlotMinutes,
+		"slotCapacity":        settings.SlotCapacity,
+		"scheduleLeadMinutes": settings.ScheduleLeadMinutes,
+		"timezone":            settings.Timezone,
 		"acceptingOrders":     req.AcceptingOrders,
 	})
 }
@@ -1,10 +1,12 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"math"
 	"net/http"
+	"sort"
 	"strconv"
 	"time"
 
@@ -15,6 +17,8 @@ import (
 	"github.com/homechef/api/middleware"
 	"github.com/homechef/api/models"
 	"github.com/homechef/api/services"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type DeliveryHandler struct{}
@@ -175,7 +179,8 @@ func (h *DeliveryHandler) GetStats(c *gin.Context) {
 	})
 }
 
-// ToggleOnline toggles the delivery partner's online/offline status
+// ToggleOnline toggles the delivery partner's online/offline status. Internal
+// agents going online outside a rostered shift are flagged.
 func (h *DeliveryHandler) ToggleOnline(c *gin.Context) {
 	userID, _ := middleware.GetUserID(c)
 
@@ -193,15 +198,35 @@ func (h *DeliveryHandler) ToggleOnline(c *gin.Context) {
 		return
 	}
 
+	// Each online stretch is kept as a session for shift attendance
+	now := time.Now()
+	response := gin.H{}
+	if req.IsOnline {
+		session, shift, err := services.StartOnlineSession(database.DB, &partner, now)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update status"})
+			return
+		}
+		if shift != nil {
+			response["shift"] = shift.ToResponse()
+		}
+		if session.OutsideShift {
+			response["outsideShift"] = true
+			response["warning"] = "You are not rostered on a shift right now. This session has been flagged to your fleet manager"
+		}
+	} else if err := services.EndOnlineSession(database.DB, partner.ID, now); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update status"})
+		return
+	}
+
 	partner.IsOnline = req.IsOnline
 	if err := database.DB.Save(&partner).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update status"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"isOnline": partner.IsOnline,
-	})
+	response["isOnline"] = partner.IsOnline
+	c.JSON(http.StatusOK, response)
 }
 
 // UpdateLocation updates the delivery partner's current location
@@ -215,23 +240,122 @@ func (h *DeliveryHandler) UpdateLocation(c *gin.Context) {
 	}
 
 	var req struct {
-		Latitude  float64 `json:"latitude" binding:"required"`
-		Longitude float64 `json:"longitude" binding:"required"`
+		Latitude  float64 `json:"latitude" binding:"required,min=-90,max=90"`
+		Longitude float64 `json:"longitude" binding:"required,min=-180,max=180"`
+		Accuracy  float64 `json:"accuracy" binding:"min=0"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	partner.CurrentLatitude = req.Latitude
-	partner.CurrentLongitude = req.Longitude
+	var active []models.Delivery
+	database.DB.Select("id", "order_id").
+		Where("delivery_partner_id = ? AND status IN ?", partner.ID, services.ActiveDeliveryStatuses).
+		Find(&active)
+	deliveryIDs := make([]uuid.UUID, len(active))
+	for i, d := range active {
+		deliveryIDs[i] = d.ID
+	}
 
-	if err := database.DB.Save(&partner).Error; err != nil {
+	// Keep the breadcrumb trail; a rejected jump must not move the live position either
+	result, err := services.RecordLocationPing(database.DB, partner.ID, deliveryIDs, req.Latitude, req.Longitude, req.Accuracy, time.Now())
+	if err != nil {
+		var locErr *services.LocationError
+		if errors.As(err, &locErr) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": locErr.Message})
+			return
+		}
+		log.Printf("Failed to record location ping for partner %s: %v", partner.ID, err)
+	}
+
+	// Only the position: counters and metrics on the row have other writers
+	if err := database.DB.Model(&partner).Updates(map[string]interface{}{
+		"current_latitude":  req.Latitude,
+		"current_longitude": req.Longitude,
+	}).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update location"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	// Share the position with customers tracking an active delivery
+	if len(active) > 0 {
+		go func() {
+			for _, d := range active {
+				if err := services.PublishEvent(services.SubjectDeliveryLocation, "delivery.location", userID, map[string]interface{}{
+					"order_id":  d.OrderID.String(),
+					"latitude":  req.Latitude,
+					"longitude": req.Longitude,
+				}); err != nil {
+					log.Printf("Failed to publish delivery location: %v", err)
+					return
+				}
+			}
+		}()
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "recorded": result == services.PingStored})
+}
+
+// AdminGetDeliveryRoute returns the recorded route of a delivery as a GeoJSON
+// Feature with a LineString geometry, for replaying disputed deliveries. Pass
+// tolerance (metres) to downsample long routes.
+// GET /admin/delivery/routes/:id
+func (h *DeliveryHandler) AdminGetDeliveryRoute(c *gin.Context) {
+	var delivery models.Delivery
+	if err := database.DB.Where("id = ?", c.Param("id")).First(&delivery).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Delivery not found"})
+		return
+	}
+
+	tolerance, _ := strconv.ParseFloat(c.DefaultQuery("tolerance", "0"), 64)
+
+	var pings []models.DeliveryLocationPing
+	if err := database.DB.Where("delivery_id = ?", delivery.ID).
+		Order("recorded_at ASC").Find(&pings).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load route"})
+		return
+	}
+	route := services.SimplifyRoute(pings, tolerance)
+
+	coordinates := make([][]float64, len(route))
+	timestamps := make([]time.Time, len(route))
+	for i, p := range route {
+		// GeoJSON positions are [longitude, latitude]
+		coordinates[i] = []float64{p.Longitude, p.Latitude}
+		timestamps[i] = p.RecordedAt
+	}
+
+	properties := gin.H{
+		"deliveryId":    delivery.ID,
+		"orderId":       delivery.OrderID,
+		"partnerId":     delivery.DeliveryPartnerID,
+		"status":        delivery.Status,
+		"pickup":        []float64{delivery.PickupLongitude, delivery.PickupLatitude},
+		"dropoff":       []float64{delivery.DropoffLongitude, delivery.DropoffLatitude},
+		"pointCount":    len(route),
+		"rawPointCount": len(pings),
+		"timestamps":    timestamps,
+	}
+	if len(route) > 0 {
+		properties["startedAt"] = route[0].RecordedAt
+		properties["endedAt"] = route[len(route)-1].RecordedAt
+	}
+
+	// A LineString needs two positions; report shorter routes without geometry
+	var geometry interface{}
+	if len(coordinates) >= 2 {
+		geometry = gin.H{
+			"type":        "LineString",
+			"coordinates": coordinates,
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"type":       "Feature",
+		"geometry":   geometry,
+		"properties": properties,
+	})
 }
 
 // GetCurrentDelivery returns the current active delivery
@@ -265,6 +389,61 @@ func (h *DeliveryHandler) GetCurrentDelivery(c *gin.Context) {
 	})
 }
 
+// GetCurrentRun returns every delivery the partner is carrying with their
+// remaining stops in the suggested order
+// GET /delivery/run
+func (h *DeliveryHandler) GetCurrentRun(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var partner models.DeliveryPartner
+	if err := database.DB.Where("user_id = ?", userID).First(&partner).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Delivery partner profile not found"})
+		return
+	}
+
+	var deliveries []models.Delivery
+	if err := database.DB.Preload("Order").Preload("Order.Items").Preload("Order.Chef").
+		Where("delivery_partner_id = ? AND status IN ?", partner.ID, services.ActiveDeliveryStatuses).
+		Order("assigned_at ASC").
+		Find(&deliveries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch current deliveries"})
+		return
+	}
+
+	// Stops as last planned; sequence 0 means done
+	stops := make([]services.BatchStop, 0, len(deliveries)*2)
+	responses := make([]gin.H, len(deliveries))
+	var batchID *uuid.UUID
+	for i, d := range deliveries {
+		responses[i] = deliveryDetailResponse(&deliveries[i])
+		if d.BatchID != nil {
+			batchID = d.BatchID
+		}
+		if d.PickupSequence > 0 {
+			stops = append(stops, services.BatchStop{
+				Sequence: d.PickupSequence, Kind: services.StopKindPickup, DeliveryID: d.ID, OrderID: d.OrderID,
+				Status: d.PickupStopStatus, Address: d.PickupAddressLine1, City: d.PickupAddressCity,
+				Latitude: d.PickupLatitude, Longitude: d.PickupLongitude,
+			})
+		}
+		if d.DropoffSequence > 0 {
+			stops = append(stops, services.BatchStop{
+				Sequence: d.DropoffSequence, Kind: services.StopKindDropoff, DeliveryID: d.ID, OrderID: d.OrderID,
+				Status: d.DropoffStopStatus, Address: d.DropoffAddressLine1, City: d.DropoffAddressCity,
+				Latitude: d.DropoffLatitude, Longitude: d.DropoffLongitude,
+			})
+		}
+	}
+	sort.Slice(stops, func(i, j int) bool { return stops[i].Sequence < stops[j].Sequence })
+
+	c.JSON(http.StatusOK, gin.H{
+		"batchId":       batchID,
+		"deliveries":    responses,
+		"stops":         stops,
+		"maxConcurrent": partner.MaxConcurrent,
+	})
+}
+
 // GetAvailableDeliveries returns orders ready for pickup that haven't been assigned
 func (h *DeliveryHandler) GetAvailableDeliveries(c *gin.Context) {
 	userID, _ := middleware.GetUserID(c)
@@ -288,20 +467,20 @@ func (h *DeliveryHandler) GetAvailableDeliveries(c *gin.Context) {
 		return
 	}
 
-	// Find orders that are ready for pickup and don't have a delivery assigned
+	// Find orders that are ready for pickup and don't have a delivery assigned.
+	// Orders out on offer to a driver hold a delivery and are not listed.
 	var orders []models.Order
 	query := database.DB.Preload("Items").Preload("Chef").
-		Where("status = ? AND delivery_id IS NULL", models.OrderStatusReady)
+		Where("status = ? AND delivery_id IS NULL", models.OrderStatusReady).
+		Order("created_at ASC")
 
-	// If partner has location, sort by distance
+	// With a location, take a wider window and keep the nearest 20 below
+	limit := 20
 	if partner.CurrentLatitude != 0 && partner.CurrentLongitude != 0 {
-		// Simple distance ordering using Haversine approximation
-		query = query.Order("created_at ASC")
-	} else {
-		query = query.Order("created_at ASC")
+		limit = 100
 	}
 
-	if err := query.Limit(20).Find(&orders).Error; err != nil {
+	if err := query.Limit(limit).Find(&orders).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch available deliveries"})
 		return
 	}
@@ -341,6 +520,19 @@ func (h *DeliveryHandler) GetAvailableDeliveries(c *gin.Context) {
 		})
 	}
 
+	// Nearest first; orders without a kitchen location go last, oldest first
+	if partner.CurrentLatitude != 0 && partner.CurrentLongitude != 0 {
+		sort.SliceStable(available, func(i, j int) bool {
+			if (available[i].Distance == 0) != (available[j].Distance == 0) {
+				return available[j].Distance == 0
+			}
+			return available[i].Distance < available[j].Distance
+		})
+		if len(available) > 20 {
+			available = available[:20]
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{"data": available})
 }
 
@@ -371,66 +563,60 @@ func (h *DeliveryHandler) AcceptDelivery(c *gin.Context) {
 		return
 	}
 
-	// Check for existing active delivery
-	var activeCount int64
-	database.DB.Model(&models.Delivery{}).
-		Where("delivery_partner_id = ? AND status IN ?",
-			partner.ID, []models.DeliveryStatus{
-				models.DeliveryAssigned,
-				models.DeliveryPickedUp,
-				models.DeliveryInTransit,
-			}).
-		Count(&activeCount)
-
-	if activeCount > 0 {
-		c.JSON(http.StatusConflict, gin.H{"error": "You already have an active delivery"})
-		return
-	}
-
 	// Start transaction
 	tx := database.DB.Begin()
 
-	// Get the order and lock it
+	// Lock the order so two drivers cannot both take it
 	var order models.Order
-	if err := tx.Preload("Chef").
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
 		Where("id = ? AND status = ? AND delivery_id IS NULL", orderUUID, models.OrderStatusReady).
 		First(&order).Error; err != nil {
 		tx.Rollback()
 		c.JSON(http.StatusNotFound, gin.H{"error": "Order not available for delivery"})
 		return
 	}
-
-	// Calculate distance and estimated duration
-	distance := haversine(
-		order.Chef.Latitude, order.Chef.Longitude,
-		order.DeliveryLatitude, order.DeliveryLongitude,
-	)
-	estimatedDuration := int(math.Ceil(distance / 0.5)) // ~30km/h average speed, in minutes
-	if estimatedDuration < 10 {
-		estimatedDuration = 10
+	if err := tx.Where("id = ?", order.ChefID).First(&order.Chef).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create delivery"})
+		return
 	}
 
 	// Create delivery
-	delivery := models.Delivery{
-		OrderID:            order.ID,
-		DeliveryPartnerID:  partner.ID,
-		Status:             models.DeliveryAssigned,
-		PickupAddressLine1: order.Chef.AddressLine1,
-		PickupAddressCity:  order.Chef.City,
-		PickupLatitude:     order.Chef.Latitude,
-		PickupLongitude:    order.Chef.Longitude,
-		DropoffAddressLine1: order.DeliveryAddressLine1,
-		DropoffAddressCity:  order.DeliveryAddressCity,
-		DropoffLatitude:     order.DeliveryLatitude,
-		DropoffLongitude:    order.DeliveryLongitude,
-		Distance:            distance,
-		EstimatedDuration:   estimatedDuration,
-		DeliveryFee:         order.DeliveryFee,
-		Tip:                 order.Tip,
-		TotalPayout:         order.DeliveryFee + order.Tip, // 100% to driver — subscription model
-	}
-
-	if err := tx.Create(&delivery).Error; err != nil {
+	delivery := services.NewOrderDelivery(&order, partner.ID)
+	estimatedDuration := delivery.EstimatedDuration
+	if err := services.IssuePickupCode(&delivery); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create delivery"})
+		return
+	}
+
+	// Drivers may carry up to MaxConcurrent orders that are on the same run
+	active, err := services.LockDriverLoad(tx, partner.ID)
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create delivery"})
+		return
+	}
+	if err := services.CheckBatchFit(&partner, active, &delivery); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := services.SaveOrderDelivery(tx, &delivery); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create delivery"})
+		return
+	}
+
+	if err := services.AddToBatch(tx, &partner, active, &delivery); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create delivery"})
+		return
+	}
+
+	if err := services.RecordOrderEvent(tx, order.ID, models.OrderEventDelivery, "", string(delivery.Status),
+		services.ByUser(services.ActorDriver, userID)); err != nil {
 		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create delivery"})
 		return
@@ -438,14 +624,13 @@ func (h *DeliveryHandler) AcceptDelivery(c *gin.Context) {
 
 	// Update order with delivery reference
 	order.DeliveryID = &delivery.ID
-	order.Status = models.OrderStatusPickedUp
-	now := time.Now()
-	order.PickedUpAt = &now
 	order.EstimatedDeliveryTime = estimatedDuration
-
-	if err := tx.Save(&order).Error; err != nil {
+	if err := services.UpdateOrderStatus(tx, &order, models.OrderStatusDriverAssigned, services.ByUser(services.ActorDriver, userID), map[string]interface{}{
+		"delivery_id":             delivery.ID,
+		"estimated_delivery_time": estimatedDuration,
+	}); err != nil {
 		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update order"})
+		respondOrderStatusError(c, err)
 		return
 	}
 
@@ -469,6 +654,112 @@ func (h *DeliveryHandler) AcceptDelivery(c *gin.Context) {
 	})
 }
 
+// GetDeliveryOffers returns the offers waiting on the partner's answer
+// GET /delivery/offers
+func (h *DeliveryHandler) GetDeliveryOffers(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var partner models.DeliveryPartner
+	if err := database.DB.Where("user_id = ?", userID).First(&partner).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Delivery partner profile not found"})
+		return
+	}
+
+	var deliveries []models.Delivery
+	if err := database.DB.Preload("Order").Preload("Order.Items").Preload("Order.Chef").
+		Where("delivery_partner_id = ? AND status = ? AND offer_expires_at > ?", partner.ID, models.DeliveryPending, time.Now()).
+		Order("offer_expires_at ASC").
+		Find(&deliveries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch offers"})
+		return
+	}
+
+	offers := make([]gin.H, len(deliveries))
+	for i := range deliveries {
+		offer := deliveryDetailResponse(&deliveries[i])
+		offer["offerExpiresAt"] = deliveries[i].OfferExpiresAt
+		offers[i] = offer
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": offers})
+}
+
+// AcceptDeliveryOffer takes a delivery the dispatcher offered to the partner.
+// POST /delivery/offers/:id/accept
+func (h *DeliveryHandler) AcceptDeliveryOffer(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	deliveryID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid delivery ID"})
+		return
+	}
+
+	var partner models.DeliveryPartner
+	if err := database.DB.Where("user_id = ?", userID).First(&partner).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Delivery partner profile not found"})
+		return
+	}
+
+	delivery, err := services.AcceptDeliveryOffer(&partner, deliveryID)
+	if err != nil {
+		var dispatchErr *services.DispatchError
+		if errors.As(err, &dispatchErr) {
+			c.JSON(http.StatusConflict, gin.H{"error": dispatchErr.Message})
+			return
+		}
+		respondOrderStatusError(c, err)
+		return
+	}
+
+	go func() {
+		if err := services.PublishEvent(services.SubjectDeliveryAssigned, "delivery.assigned", partner.UserID, map[string]interface{}{
+			"delivery_id":  delivery.ID.String(),
+			"order_id":     delivery.OrderID.String(),
+			"order_number": delivery.Order.OrderNumber,
+			"partner_id":   partner.ID.String(),
+		}); err != nil {
+			log.Printf("Failed to publish delivery assigned event: %v", err)
+		}
+	}()
+
+	c.JSON(http.StatusOK, gin.H{
+		"delivery": delivery.ToResponse(),
+		"message":  "Delivery accepted successfully",
+	})
+}
+
+// DeclineDeliveryOffer turns down an offer so it goes to the next driver.
+// POST /delivery/offers/:id/decline
+func (h *DeliveryHandler) DeclineDeliveryOffer(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	deliveryID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid delivery ID"})
+		return
+	}
+
+	var partner models.DeliveryPartner
+	if err := database.DB.Where("user_id = ?", userID).First(&partner).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Delivery partner profile not found"})
+		return
+	}
+
+	if err := services.DeclineDeliveryOffer(&partner, deliveryID); err != nil {
+		var dispatchErr *services.DispatchError
+		if errors.As(err, &dispatchErr) {
+			c.JSON(http.StatusConflict, gin.H{"error": dispatchErr.Message})
+			return
+		}
+		log.Printf("Failed to decline delivery offer %s: %v", deliveryID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decline offer"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Offer declined"})
+}
+
 // UpdateDeliveryStatus updates the status of a delivery
 func (h *DeliveryHandler) UpdateDeliveryStatus(c *gin.Context) {
 	userID, _ := middleware.GetUserID(c)
@@ -489,17 +780,26 @@ func (h *DeliveryHandler) UpdateDeliveryStatus(c *gin.Context) {
 	}
 
 	var req struct {
-		Status       models.DeliveryStatus `json:"status" binding:"required"`
-		CancelReason string                `json:"cancelReason"`
+		Status        models.DeliveryStatus `json:"status" binding:"required"`
+		CancelReason  string                `json:"cancelReason"`
+		FailureReason models.FailureReason  `json:"failureReason"`
+		Notes         string                `json:"notes"`
+		HandoverCode  string                `json:"handoverCode"`
+		PickupCode    string                `json:"pickupCode"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	// Offers are answered through the offer endpoints so the dispatcher can move on
+	if delivery.Status == models.DeliveryPending {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Accept or decline this offer first"})
+		return
+	}
+
 	// Validate status transitions
 	validTransitions := map[models.DeliveryStatus][]models.DeliveryStatus{
-		models.DeliveryPending:   {models.DeliveryAssigned, models.DeliveryCancelled},
 		models.DeliveryAssigned:  {models.DeliveryAtPickup, models.DeliveryPickedUp, models.DeliveryCancelled},
 		models.DeliveryAtPickup:  {models.DeliveryPickedUp, models.DeliveryCancelled},
 		models.DeliveryPickedUp:  {models.DeliveryInTransit, models.DeliveryCancelled},
@@ -514,111 +814,566 @@ func (h *DeliveryHandler) UpdateDeliveryStatus(c *gin.Context) {
 		return
 	}
 
-	valid := false
-	for _, s := range allowed {
-		if s == req.Status {
-			valid = true
-			break
-		}
+	valid := false
+	for _, s := range allowed {
+		if s == req.Status {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status transition"})
+		return
+	}
+
+	notes := req.CancelReason
+	switch req.Status {
+	case models.DeliveryPickedUp:
+		// Only the assigned driver gets the code from the chef
+		if err := services.VerifyPickupCode(database.DB, &delivery, &partner, req.PickupCode); err != nil {
+			var codeErr *services.HandoverError
+			if errors.As(err, &codeErr) {
+				c.JSON(http.StatusUnprocessableEntity, gin.H{"error": codeErr.Message})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify pickup code"})
+			return
+		}
+	case models.DeliveryFailed:
+		if _, ok := services.ClassifyFailure(req.FailureReason); !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "A valid failureReason is required"})
+			return
+		}
+		notes = string(req.FailureReason)
+		if req.Notes != "" {
+			notes += ": " + req.Notes
+		}
+	case models.DeliveryReturned:
+		// The customer may still ask for another attempt
+		if delivery.ReturnStartedAt == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Wait for the customer to answer before returning the order"})
+			return
+		}
+	case models.DeliveryDelivered:
+		if err := services.VerifyHandoverCode(database.DB, &delivery, req.HandoverCode); err != nil {
+			var handoverErr *services.HandoverError
+			if errors.As(err, &handoverErr) {
+				c.JSON(http.StatusUnprocessableEntity, gin.H{"error": handoverErr.Message})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify handover code"})
+			return
+		}
+	}
+
+	now := time.Now()
+	oldDeliveryStatus := delivery.Status
+	delivery.Status = req.Status
+
+	switch req.Status {
+	case models.DeliveryPickedUp:
+		delivery.PickedUpAt = &now
+		delivery.PickupVerifiedAt = &now
+		// The customer reads this back to the driver at the door
+		if delivery.HandoverCode == "" {
+			code, err := services.GenerateHandoverCode()
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate handover code"})
+				return
+			}
+			delivery.HandoverCode = code
+		}
+		// Orders taken before pickup codes went straight to picked up
+		if delivery.Order.Status == models.OrderStatusDriverAssigned {
+			if err := services.UpdateOrderStatus(database.DB, &delivery.Order, models.OrderStatusPickedUp, services.ByUser(services.ActorDriver, userID), nil); err != nil {
+				respondOrderStatusError(c, err)
+				return
+			}
+		}
+	case models.DeliveryInTransit:
+		if delivery.Order.Status == models.OrderStatusPickedUp {
+			if err := services.UpdateOrderStatus(database.DB, &delivery.Order, models.OrderStatusDelivering, services.ByUser(services.ActorDriver, userID), nil); err != nil {
+				respondOrderStatusError(c, err)
+				return
+			}
+		}
+	case models.DeliveryDelivered:
+		delivery.DeliveredAt = &now
+		delivery.ActualDuration = int(now.Sub(delivery.AssignedAt).Minutes())
+		delivery.HandoverVerifiedAt = &now
+		delivery.HandoverLatitude = partner.CurrentLatitude
+		delivery.HandoverLongitude = partner.CurrentLongitude
+	case models.DeliveryCancelled:
+		delivery.CancelledAt = &now
+		delivery.CancelReason = req.CancelReason
+	case models.DeliveryFailed:
+		delivery.FailedAt = &now
+		delivery.FailureReason = req.FailureReason
+	case models.DeliveryReturned:
+		delivery.ReturnedAt = &now
+	}
+
+	// Keep the driver's stops in step with the delivery
+	switch req.Status {
+	case models.DeliveryAtPickup:
+		delivery.PickupStopStatus = models.StopArrived
+	case models.DeliveryPickedUp:
+		delivery.PickupStopStatus = models.StopCompleted
+	case models.DeliveryAtDropoff:
+		delivery.DropoffStopStatus = models.StopArrived
+	case models.DeliveryDelivered:
+		delivery.DropoffStopStatus = models.StopCompleted
+	case models.DeliveryFailed:
+		delivery.DropoffStopStatus = models.StopFailed
+	case models.DeliveryCancelled:
+		if !delivery.PickupStopStatus.Done() {
+			delivery.PickupStopStatus = models.StopSkipped
+		}
+		delivery.DropoffStopStatus = models.StopSkipped
+	}
+
+	if req.Status == models.DeliveryCancelled {
+		// Hand the order back and close the delivery together, with both rows
+		// locked, so the dispatcher cannot reuse the delivery in between
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			var order models.Order
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, "id = ?", delivery.OrderID).Error; err != nil {
+				return err
+			}
+			var locked models.Delivery
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&locked, "id = ?", delivery.ID).Error; err != nil {
+				return err
+			}
+			if order.DeliveryID == nil || *order.DeliveryID != delivery.ID ||
+				locked.DeliveryPartnerID != partner.ID || locked.Status != oldDeliveryStatus {
+				return &services.OrderTransitionError{Code: services.ErrCodeOrderStatusConflict,
+					From: order.Status, To: models.OrderStatusReady, Actor: services.ActorDriver}
+			}
+
+			if err := services.UpdateOrderStatus(tx, &order, models.OrderStatusReady, services.ByUser(services.ActorDriver, userID).WithNotes(req.CancelReason),
+				map[string]interface{}{"delivery_id": nil}); err != nil {
+				return err
+			}
+			delivery.Order = order
+
+			return tx.Model(&models.Delivery{}).Where("id = ?", delivery.ID).Updates(map[string]interface{}{
+				"status":              delivery.Status,
+				"cancelled_at":        delivery.CancelledAt,
+				"cancel_reason":       delivery.CancelReason,
+				"pickup_stop_status":  delivery.PickupStopStatus,
+				"dropoff_stop_status": delivery.DropoffStopStatus,
+			}).Error
+		})
+		if err != nil {
+			var transitionErr *services.OrderTransitionError
+			if errors.As(err, &transitionErr) {
+				respondOrderStatusError(c, err)
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update delivery status"})
+			return
+		}
+	} else if req.Status == models.DeliveryDelivered {
+		// The order and the delivery complete together, and only then is
+		// anyone paid
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			if err := services.UpdateOrderStatus(tx, &delivery.Order, models.OrderStatusDelivered, services.ByUser(services.ActorDriver, userID), nil); err != nil {
+				return err
+			}
+			return tx.Save(&delivery).Error
+		})
+		if err != nil {
+			var transitionErr *services.OrderTransitionError
+			if errors.As(err, &transitionErr) {
+				respondOrderStatusError(c, err)
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update delivery status"})
+			return
+		}
+		settleDelivery(&delivery, &partner)
+	} else if err := database.DB.Save(&delivery).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update delivery status"})
+		return
+	}
+
+	// Re-plan the rest of the run from here
+	var planErr error
+	if delivery.BatchID != nil {
+		planErr = services.RefreshBatch(database.DB, &partner, *delivery.BatchID)
+	} else {
+		planErr = services.ResequenceStops(database.DB, &partner, []models.Delivery{delivery})
+	}
+	if planErr != nil {
+		log.Printf("Failed to re-plan stops for delivery %s: %v", delivery.ID, planErr)
+	}
+
+	services.LogOrderEvent(database.DB, delivery.OrderID, models.OrderEventDelivery, string(oldDeliveryStatus),
+		string(delivery.Status), services.ByUser(services.ActorDriver, userID).WithNotes(notes))
+
+	// A failed attempt is paid for, then re-attempted or sent back to the kitchen
+	switch delivery.Status {
+	case models.DeliveryFailed:
+		services.RecordFailedMetric(database.DB, &delivery)
+		if err := services.HandleDeliveryFailure(database.DB, &delivery, req.Notes, now); err != nil {
+			log.Printf("Failed to handle failed delivery %s: %v", delivery.ID, err)
+		}
+		database.DB.First(&delivery, "id = ?", delivery.ID)
+	case models.DeliveryReturned:
+		if err := services.CompleteDeliveryReturn(database.DB, &delivery, now); err != nil {
+			log.Printf("Failed to settle return of delivery %s: %v", delivery.ID, err)
+		}
+	}
+
+	// A handed-back order goes round the dispatcher again
+	if delivery.Status == models.DeliveryCancelled {
+		services.LogDriverMetric(database.DB, models.DriverMetricEvent{
+			PartnerID:  partner.ID,
+			DeliveryID: &delivery.ID,
+			Kind:       models.MetricDropped,
+		})
+		go func() {
+			if err := services.PublishOrderEvent(services.SubjectOrderReady, services.OrderEvent{
+				OrderID:     delivery.Order.ID,
+				OrderNumber: delivery.Order.OrderNumber,
+				CustomerID:  delivery.Order.CustomerID,
+				ChefID:      delivery.Order.ChefID,
+				Status:      string(delivery.Order.Status),
+				Total:       delivery.Order.Total,
+			}); err != nil {
+				log.Printf("Failed to publish order ready event: %v", err)
+			}
+		}()
+	}
+
+	go func() {
+		if err := services.PublishEvent(services.SubjectDeliveryUpdated, "delivery.updated", partner.UserID, map[string]interface{}{
+			"delivery_id": delivery.ID.String(),
+			"order_id":    delivery.OrderID.String(),
+			"old_status":  string(oldDeliveryStatus),
+			"status":      string(delivery.Status),
+		}); err != nil {
+			log.Printf("Failed to publish delivery updated event: %v", err)
+		}
+	}()
+
+	c.JSON(http.StatusOK, delivery.ToResponse())
+}
+
+// UploadProofPhoto stores an optional doorstep photo as evidence of a delivery,
+// or of the attempt when it failed
+// POST /delivery/:id/proof-photo
+func (h *DeliveryHandler) UploadProofPhoto(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var partner models.DeliveryPartner
+	if err := database.DB.Where("user_id = ?", userID).First(&partner).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Delivery partner profile not found"})
+		return
+	}
+
+	var delivery models.Delivery
+	if err := database.DB.Where("id = ? AND delivery_partner_id = ?", c.Param("id"), partner.ID).
+		First(&delivery).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Delivery not found"})
+		return
+	}
+	switch delivery.Status {
+	case models.DeliveryAtDropoff, models.DeliveryDelivered, models.DeliveryFailed:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Photos can only be added at the drop-off"})
+		return
+	}
+
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File is required"})
+		return
+	}
+	defer file.Close()
+
+	if header.Size > services.ProofPhotoMaxSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Photo too large (max 5MB)"})
+		return
+	}
+	contentType := header.Header.Get("Content-Type")
+	photoTypes := map[string]bool{"image/jpeg": true, "image/png": true, "image/webp": true}
+	if !photoTypes[contentType] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Photo must be JPEG, PNG or WebP"})
+		return
+	}
+
+	folder := fmt.Sprintf("deliveries/%s/proof", delivery.ID)
+	uploadedPath, err := services.UploadPrivateFile(c.Request.Context(), folder, header.Filename, file, contentType)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload file"})
+		return
+	}
+
+	now := time.Now()
+	if err := database.DB.Model(&delivery).Updates(map[string]interface{}{
+		"proof_photo_path": uploadedPath,
+		"proof_photo_at":   now,
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save photo"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Photo saved", "proofPhotoAt": now})
+}
+
+// AdminGetDeliveryProof returns the evidence kept for a delivery, for
+// resolving disputes. The photo link is short-lived.
+// GET /admin/delivery/proof/:id
+func (h *DeliveryHandler) AdminGetDeliveryProof(c *gin.Context) {
+	var delivery models.Delivery
+	if err := database.DB.Preload("Order").Where("id = ?", c.Param("id")).First(&delivery).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Delivery not found"})
+		return
+	}
+
+	response := gin.H{
+		"deliveryId":             delivery.ID,
+		"orderId":                delivery.OrderID,
+		"orderNumber":            delivery.Order.OrderNumber,
+		"partnerId":              delivery.DeliveryPartnerID,
+		"status":                 delivery.Status,
+		"deliveredAt":            delivery.DeliveredAt,
+		"codeRequired":           delivery.HandoverCode != "",
+		"handoverVerifiedAt":     delivery.HandoverVerifiedAt,
+		"handoverAttempts":       delivery.HandoverAttempts,
+		"dropoff":                gin.H{"latitude": delivery.DropoffLatitude, "longitude": delivery.DropoffLongitude},
+		"proofPhotoAt":           delivery.ProofPhotoAt,
+		"handoverOverrideById":   delivery.HandoverOverrideByID,
+		"handoverOverrideReason": delivery.HandoverOverrideReason,
+		"failureReason":          delivery.FailureReason,
+	}
+	if delivery.HandoverLatitude != 0 || delivery.HandoverLongitude != 0 {
+		response["handoverLocation"] = gin.H{"latitude": delivery.HandoverLatitude, "longitude": delivery.HandoverLongitude}
+		response["distanceFromDropoff"] = haversine(delivery.HandoverLatitude, delivery.HandoverLongitude,
+			delivery.DropoffLatitude, delivery.DropoffLongitude)
+	}
+	if delivery.ProofPhotoPath != "" {
+		if url, err := services.GenerateSignedURL(c.Request.Context(), delivery.ProofPhotoPath, 15*time.Minute); err == nil {
+			response["proofPhotoUrl"] = url
+		} else {
+			log.Printf("Failed to sign proof photo for delivery %s: %v", delivery.ID, err)
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ResetPickupCode unlocks a delivery whose driver entered too many wrong
+// pickup codes, issuing the kitchen a new one.
+// POST /admin/delivery/:id/pickup-code/reset
+func (h *DeliveryHandler) ResetPickupCode(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var req struct {
+		Reason string `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var delivery models.Delivery
+	if err := database.DB.Where("id = ?", c.Param("id")).First(&delivery).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Delivery not found"})
+		return
+	}
+
+	oldAttempts := delivery.PickupCodeAttempts
+	if err := services.ResetPickupCode(database.DB, &delivery, services.ByUser(services.ActorAdmin, userID).WithNotes(req.Reason)); err != nil {
+		var handoverErr *services.HandoverError
+		if errors.As(err, &handoverErr) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": handoverErr.Message})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset pickup code"})
+		return
+	}
+
+	database.DB.Create(&models.AuditLog{
+		UserID:     &userID,
+		Action:     "delivery.pickup_code_reset",
+		EntityType: "delivery",
+		EntityID:   delivery.ID.String(),
+		OldValue:   fmt.Sprintf("%d attempts", oldAttempts),
+		NewValue:   req.Reason,
+		IPAddress:  c.ClientIP(),
+		UserAgent:  c.Request.UserAgent(),
+	})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Pickup code reset", "deliveryId": delivery.ID})
+}
+
+// AdminOverrideHandover completes a delivery without the handover code, e.g.
+// when the customer confirms receipt to support. The admin and their reason
+// are kept on the delivery and in the audit log.
+// POST /admin/delivery/proof/:id/override
+func (h *DeliveryHandler) AdminOverrideHandover(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var req struct {
+		Reason string `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var delivery models.Delivery
+	if err := database.DB.Preload("Order").Where("id = ?", c.Param("id")).First(&delivery).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Delivery not found"})
+		return
+	}
+	switch delivery.Status {
+	case models.DeliveryInTransit, models.DeliveryAtDropoff:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only a delivery on its way to the customer can be completed"})
+		return
+	}
+
+	var partner models.DeliveryPartner
+	if err := database.DB.First(&partner, "id = ?", delivery.DeliveryPartnerID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Delivery partner not found"})
+		return
+	}
+
+	now := time.Now()
+	oldDeliveryStatus := delivery.Status
+	delivery.Status = models.DeliveryDelivered
+	delivery.DeliveredAt = &now
+	delivery.ActualDuration = int(now.Sub(delivery.AssignedAt).Minutes())
+	delivery.DropoffStopStatus = models.StopCompleted
+	delivery.HandoverOverrideByID = &userID
+	delivery.HandoverOverrideReason = req.Reason
+
+	by := services.ByUser(services.ActorAdmin, userID).WithNotes(req.Reason)
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// An order whose driver never marked it on the way is still picked up
+		if delivery.Order.Status == models.OrderStatusPickedUp {
+			if err := services.UpdateOrderStatus(tx, &delivery.Order, models.OrderStatusDelivering, by, nil); err != nil {
+				return err
+			}
+		}
+		if err := services.UpdateOrderStatus(tx, &delivery.Order, models.OrderStatusDelivered, by, nil); err != nil {
+			return err
+		}
+		return tx.Model(&models.Delivery{}).Where("id = ?", delivery.ID).Updates(map[string]interface{}{
+			"status":                   delivery.Status,
+			"delivered_at":             delivery.DeliveredAt,
+			"actual_duration":          delivery.ActualDuration,
+			"dropoff_stop_status":      delivery.DropoffStopStatus,
+			"handover_override_by_id":  delivery.HandoverOverrideByID,
+			"handover_override_reason": delivery.HandoverOverrideReason,
+		}).Error
+	})
+	if err != nil {
+		respondOrderStatusError(c, err)
+		return
+	}
+	settleDelivery(&delivery, &partner)
+
+	var planErr error
+	if delivery.BatchID != nil {
+		planErr = services.RefreshBatch(database.DB, &partner, *delivery.BatchID)
+	} else {
+		planErr = services.ResequenceStops(database.DB, &partner, []models.Delivery{delivery})
 	}
-	if !valid {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status transition"})
-		return
+	if planErr != nil {
+		log.Printf("Failed to re-plan stops for delivery %s: %v", delivery.ID, planErr)
 	}
 
-	now := time.Now()
-	delivery.Status = req.Status
+	services.LogOrderEvent(database.DB, delivery.OrderID, models.OrderEventDelivery, string(oldDeliveryStatus),
+		string(delivery.Status), services.ByUser(services.ActorAdmin, userID).WithNotes("Handover override: "+req.Reason))
 
-	switch req.Status {
-	case models.DeliveryPickedUp:
-		delivery.PickedUpAt = &now
-		// Update order status
-		database.DB.Model(&delivery.Order).Updates(map[string]interface{}{
-			"status":     models.OrderStatusDelivering,
-			"picked_up_at": now,
-		})
-	case models.DeliveryInTransit:
-		// Order stays in delivering status
-	case models.DeliveryDelivered:
-		delivery.DeliveredAt = &now
-		delivery.ActualDuration = int(now.Sub(delivery.AssignedAt).Minutes())
-		// Update order status
-		database.DB.Model(&delivery.Order).Updates(map[string]interface{}{
-			"status":       models.OrderStatusDelivered,
-			"delivered_at": now,
-		})
-		// Update partner stats
-		database.DB.Model(&partner).Updates(map[string]interface{}{
-			"total_deliveries": partner.TotalDeliveries + 1,
+	database.DB.Create(&models.AuditLog{
+		UserID:     &userID,
+		Action:     "delivery.handover_override",
+		EntityType: "delivery",
+		EntityID:   delivery.ID.String(),
+		OldValue:   string(oldDeliveryStatus),
+		NewValue:   string(delivery.Status) + ": " + req.Reason,
+		IPAddress:  c.ClientIP(),
+		UserAgent:  c.Request.UserAgent(),
+	})
+
+	go func() {
+		if err := services.PublishEvent(services.SubjectDeliveryUpdated, "delivery.updated", userID, map[string]interface{}{
+			"delivery_id": delivery.ID.String(),
+			"order_id":    delivery.OrderID.String(),
+			"old_status":  string(oldDeliveryStatus),
+			"status":      string(delivery.Status),
+		}); err != nil {
+			log.Printf("Failed to publish delivery updated event: %v", err)
+		}
+	}()
+
+	c.JSON(http.StatusOK, deliveryDetailResponse(&delivery))
+}
+
+// settleDelivery books everything that follows a completed delivery: driver
+// stats, earnings for the driver and chef, the customer's invoice and events
+func settleDelivery(delivery *models.Delivery, partner *models.DeliveryPartner) {
+	// Update partner stats
+	database.DB.Model(partner).Updates(map[string]interface{}{
+		"total_deliveries": partner.TotalDeliveries + 1,
+	})
+	services.RecordDeliveredMetric(database.DB, delivery)
+
+	// Publish delivery completed event
+	go func() {
+		services.PublishEvent(services.SubjectDeliveryPickedUp, "delivery.delivered", partner.UserID, map[string]interface{}{
+			"delivery_id":  delivery.ID.String(),
+			"order_id":     delivery.OrderID.String(),
+			"partner_id":   partner.ID.String(),
+			"total_payout": delivery.TotalPayout,
 		})
+	}()
 
-		// Publish delivery completed event
-		go func() {
-			services.PublishEvent(services.SubjectDeliveryPickedUp, "delivery.delivered", partner.UserID, map[string]interface{}{
-				"delivery_id":  delivery.ID.String(),
-				"order_id":     delivery.OrderID.String(),
-				"partner_id":   partner.ID.String(),
-				"total_payout": delivery.TotalPayout,
-			})
-		}()
+	// Record earnings for subscription billing (driver)
+	go func() {
+		var sub models.Subscription
+		if err := database.DB.Where("user_id = ? AND subscriber_type = ? AND status IN ?",
+			partner.UserID, models.SubscriberDriver,
+			[]models.SubscriptionStatus{models.SubStatusTrial, models.SubStatusActive}).
+			First(&sub).Error; err == nil {
+			services.RecordEarning(partner.UserID, sub.ID, models.EarningDeliveryFee, delivery.DeliveryFee, "INR", nil, &delivery.ID)
+			if delivery.Tip > 0 {
+				services.RecordEarning(partner.UserID, sub.ID, models.EarningTip, delivery.Tip, "INR", nil, &delivery.ID)
+			}
+			services.CheckEarningsThreshold(sub.ID)
+		}
+	}()
 
-		// Record earnings for subscription billing (driver)
-		go func() {
-			var sub models.Subscription
+	// Record chef earnings for subscription billing
+	go func() {
+		var order models.Order
+		if err := database.DB.Preload("Chef").First(&order, delivery.OrderID).Error; err == nil {
+			var chefSub models.Subscription
 			if err := database.DB.Where("user_id = ? AND subscriber_type = ? AND status IN ?",
-				partner.UserID, models.SubscriberDriver,
+				order.Chef.UserID, models.SubscriberChef,
 				[]models.SubscriptionStatus{models.SubStatusTrial, models.SubStatusActive}).
-				First(&sub).Error; err == nil {
-				services.RecordEarning(partner.UserID, sub.ID, models.EarningDeliveryFee, delivery.DeliveryFee, "INR", nil, &delivery.ID)
-				if delivery.Tip > 0 {
-					services.RecordEarning(partner.UserID, sub.ID, models.EarningTip, delivery.Tip, "INR", nil, &delivery.ID)
-				}
-				services.CheckEarningsThreshold(sub.ID)
+				First(&chefSub).Error; err == nil {
+				services.RecordEarning(order.Chef.UserID, chefSub.ID, models.EarningOrderRevenue, order.Subtotal-order.ChefFundedDiscount(), "INR", &order.ID, nil)
+				services.CheckEarningsThreshold(chefSub.ID)
 			}
-		}()
-
-		// Record chef earnings for subscription billing
-		go func() {
-			var order models.Order
-			if err := database.DB.Preload("Chef").First(&order, delivery.OrderID).Error; err == nil {
-				var chefSub models.Subscription
-				if err := database.DB.Where("user_id = ? AND subscriber_type = ? AND status IN ?",
-					order.Chef.UserID, models.SubscriberChef,
-					[]models.SubscriptionStatus{models.SubStatusTrial, models.SubStatusActive}).
-					First(&chefSub).Error; err == nil {
-					services.RecordEarning(order.Chef.UserID, chefSub.ID, models.EarningOrderRevenue, order.Subtotal, "INR", &order.ID, nil)
-					services.CheckEarningsThreshold(chefSub.ID)
-				}
-			}
-		}()
+		}
+	}()
 
-		// Generate order invoice for the customer
-		go func() {
-			var fullOrder models.Order
-			if err := database.DB.Preload("Items").Preload("Chef").Preload("Customer").
-				First(&fullOrder, delivery.OrderID).Error; err == nil {
-				if _, err := services.GenerateOrderInvoice(&fullOrder); err != nil {
-					log.Printf("Failed to generate order invoice for order %s: %v", delivery.OrderID, err)
-				}
+	// Generate order invoice for the customer
+	go func() {
+		var fullOrder models.Order
+		if err := database.DB.Preload("Items").Preload("Chef").Preload("Customer").
+			First(&fullOrder, delivery.OrderID).Error; err == nil {
+			if _, err := services.GenerateOrderInvoice(&fullOrder); err != nil {
+				log.Printf("Failed to generate order invoice for order %s: %v", delivery.OrderID, err)
 			}
-		}()
-
-	case models.DeliveryCancelled:
-		delivery.CancelledAt = &now
-		delivery.CancelReason = req.CancelReason
-		// Reset order - remove delivery assignment so another driver can pick up
-		database.DB.Model(&delivery.Order).Updates(map[string]interface{}{
-			"status":      models.OrderStatusReady,
-			"delivery_id": nil,
-		})
-	}
-
-	if err := database.DB.Save(&delivery).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update delivery status"})
-		return
-	}
-
-	c.JSON(http.StatusOK, delivery.ToResponse())
+		}
+	}()
 }
 
 // GetDeliveryHistory returns past deliveries for the partner
@@ -940,6 +1695,9 @@ func (h *DeliveryHandler) AdminSuspendPartner(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to suspend partner"})
 		return
 	}
+	if err := services.EndOnlineSession(database.DB, partner.ID, time.Now()); err != nil {
+		log.Printf("Failed to close online session for suspended partner %s: %v", partner.ID, err)
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Delivery partner suspended", "id": partner.ID})
 }
@@ -1094,6 +1852,11 @@ func deliveryDetailResponse(d *models.Delivery) gin.H {
 		"assignedAt":        d.AssignedAt,
 		"pickedUpAt":        d.PickedUpAt,
 		"deliveredAt":       d.DeliveredAt,
+		"batchId":           d.BatchID,
+		"pickupSequence":    d.PickupSequence,
+		"dropoffSequence":   d.DropoffSequence,
+		"pickupStopStatus":  d.PickupStopStatus,
+		"dropoffStopStatus": d.DropoffStopStatus,
 	}
 
 	if d.Order.ID != uuid.Nil {
@@ -1175,6 +1938,30 @@ func (h *DeliveryHandler) FleetOverview(c *gin.Context) {
 		Where("status = ? AND delivered_at >= ?", models.DeliveryDelivered, today).
 		Select("COALESCE(SUM(total_payout), 0)").Scan(&todayEarnings)
 
+	// Current surge per active zone, highest first
+	var zones []models.DeliveryZone
+	database.DB.Where("is_active = ?", true).Order("surge_multiplier DESC, name").Find(&zones)
+	surge := make([]gin.H, len(zones))
+	surgingZones := 0
+	for i, z := range zones {
+		if z.SurgeMultiplier > 1 {
+			surgingZones++
+		}
+		surge[i] = gin.H{
+			"zoneId":          z.ID,
+			"name":            z.Name,
+			"city":            z.City,
+			"surgeMultiplier": z.SurgeMultiplier,
+			"autoSurge":       z.AutoSurge,
+			"surgeFloor":      z.SurgeFloor,
+			"surgeCeiling":    z.SurgeCeiling,
+			"demand":          z.SurgeDemand,
+			"supply":          z.SurgeSupply,
+			"computedAt":      z.SurgeComputedAt,
+			"changedAt":       z.SurgeChangedAt,
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"totalPartners":       totalPartners,
 		"onlinePartners":      onlinePartners,
@@ -1185,6 +1972,8 @@ func (h *DeliveryHandler) FleetOverview(c *gin.Context) {
 		"unassignedOrders":    unassignedOrders,
 		"todayCompleted":      todayCompleted,
 		"todayEarnings":       todayEarnings,
+		"surgingZones":        surgingZones,
+		"surge":               surge,
 	})
 }
 
@@ -1213,6 +2002,144 @@ func (h *DeliveryHandler) GetPartnerDetail(c *gin.Context) {
 	})
 }
 
+// GetPartnerMetrics returns a partner's current performance metrics and their
+// daily history over the 7- and 30-day windows, for trends
+// GET /delivery/staff/fleet/partners/:id/metrics
+func (h *DeliveryHandler) GetPartnerMetrics(c *gin.Context) {
+	var partner models.DeliveryPartner
+	if err := database.DB.Where("id = ?", c.Param("id")).First(&partner).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Delivery partner not found"})
+		return
+	}
+
+	days, _ := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if days < 1 || days > 365 {
+		days = 30
+	}
+	since := time.Now().AddDate(0, 0, -days)
+
+	var snapshots []models.DriverMetricSnapshot
+	if err := database.DB.Where("partner_id = ? AND date >= ?", partner.ID, since).
+		Order("date").Find(&snapshots).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch metrics history"})
+		return
+	}
+
+	weekly := []models.DriverMetricSnapshot{}
+	monthly := []models.DriverMetricSnapshot{}
+	for _, s := range snapshots {
+		switch s.WindowDays {
+		case services.WeeklyMetricsWindow:
+			weekly = append(weekly, s)
+		case services.MonthlyMetricsWindow:
+			monthly = append(monthly, s)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"partnerId": partner.ID,
+		"current": gin.H{
+			"acceptanceRate":       partner.AcceptanceRate,
+			"onTimeRate":           partner.OnTimeRate,
+			"completionRate":       partner.CompletionRate,
+			"csatScore":            partner.CSATScore,
+			"weeklyAcceptanceRate": partner.WeeklyAcceptanceRate,
+			"weeklyOnTimeRate":     partner.WeeklyOnTimeRate,
+			"weeklyCompletionRate": partner.WeeklyCompletionRate,
+			"weeklyCsatScore":      partner.WeeklyCSATScore,
+			"updatedAt":            partner.MetricsUpdatedAt,
+		},
+		"weekly":  weekly,
+		"monthly": monthly,
+	})
+}
+
+// GetMyFeedback returns the ratings customers have left the delivery partner,
+// newest first, without who left them
+// GET /delivery/feedback
+func (h *DeliveryHandler) GetMyFeedback(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var partner models.DeliveryPartner
+	if err := database.DB.Where("user_id = ?", userID).First(&partner).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Delivery partner profile not found"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	ratings, total, err := services.PartnerFeedback(database.DB, partner.ID, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch feedback"})
+		return
+	}
+
+	feedback := make([]models.DriverFeedbackResponse, len(ratings))
+	for i, r := range ratings {
+		feedback[i] = r.ToFeedbackResponse()
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"rating":       partner.Rating,
+		"totalRatings": partner.TotalReviews,
+		"data":         feedback,
+		"pagination": gin.H{
+			"page":       page,
+			"limit":      limit,
+			"total":      total,
+			"totalPages": (total + int64(limit) - 1) / int64(limit),
+			"hasNext":    int64(page*limit) < total,
+			"hasPrev":    page > 1,
+		},
+	})
+}
+
+// GetPartnerFeedback returns the ratings customers have left a partner, with
+// the orders they were for
+// GET /delivery/staff/fleet/partners/:id/feedback
+func (h *DeliveryHandler) GetPartnerFeedback(c *gin.Context) {
+	var partner models.DeliveryPartner
+	if err := database.DB.Where("id = ?", c.Param("id")).First(&partner).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Delivery partner not found"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	ratings, total, err := services.PartnerFeedback(database.DB, partner.ID, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch feedback"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"partnerId":    partner.ID,
+		"rating":       partner.Rating,
+		"totalRatings": partner.TotalReviews,
+		"data":         ratings,
+		"pagination": gin.H{
+			"page":       page,
+			"limit":      limit,
+			"total":      total,
+			"totalPages": (total + int64(limit) - 1) / int64(limit),
+			"hasNext":    int64(page*limit) < total,
+			"hasPrev":    page > 1,
+		},
+	})
+}
+
 // ManualAssignDelivery allows a fleet manager to manually assign an order to a partner
 func (h *DeliveryHandler) ManualAssignDelivery(c *gin.Context) {
 	userID, _ := middleware.GetUserID(c)
@@ -1258,59 +2185,68 @@ func (h *DeliveryHandler) ManualAssignDelivery(c *gin.Context) {
 	tx := database.DB.Begin()
 
 	var order models.Order
-	if err := tx.Preload("Chef").
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
 		Where("id = ? AND status = ? AND delivery_id IS NULL", orderUUID, models.OrderStatusReady).
 		First(&order).Error; err != nil {
 		tx.Rollback()
 		c.JSON(http.StatusNotFound, gin.H{"error": "Order not available for delivery"})
 		return
 	}
+	if err := tx.Where("id = ?", order.ChefID).First(&order.Chef).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create delivery"})
+		return
+	}
+
+	delivery := services.NewOrderDelivery(&order, partner.ID)
+	delivery.AssignedByID = &userID
+	estimatedDuration := delivery.EstimatedDuration
+	if err := services.IssuePickupCode(&delivery); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create delivery"})
+		return
+	}
+
+	// Managers may batch orders the dispatcher would not, but not past capacity
+	active, err := services.LockDriverLoad(tx, partner.ID)
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create delivery"})
+		return
+	}
+	if len(active) >= partner.MaxConcurrent {
+		tx.Rollback()
+		c.JSON(http.StatusConflict, gin.H{"error": "Partner has reached maximum concurrent deliveries"})
+		return
+	}
 
-	distance := haversine(
-		order.Chef.Latitude, order.Chef.Longitude,
-		order.DeliveryLatitude, order.DeliveryLongitude,
-	)
-	estimatedDuration := int(math.Ceil(distance / 0.5))
-	if estimatedDuration < 10 {
-		estimatedDuration = 10
-	}
-
-	delivery := models.Delivery{
-		OrderID:            order.ID,
-		DeliveryPartnerID:  partner.ID,
-		Status:             models.DeliveryAssigned,
-		AssignmentType:     models.AssignmentManual,
-		AssignedByID:       &userID,
-		PickupAddressLine1: order.Chef.AddressLine1,
-		PickupAddressCity:  order.Chef.City,
-		PickupLatitude:     order.Chef.Latitude,
-		PickupLongitude:    order.Chef.Longitude,
-		DropoffAddressLine1: order.DeliveryAddressLine1,
-		DropoffAddressCity:  order.DeliveryAddressCity,
-		DropoffLatitude:     order.DeliveryLatitude,
-		DropoffLongitude:    order.DeliveryLongitude,
-		Distance:            distance,
-		EstimatedDuration:   estimatedDuration,
-		DeliveryFee:         order.DeliveryFee,
-		Tip:                 order.Tip,
-		TotalPayout:         order.DeliveryFee + order.Tip, // 100% to driver — subscription model
-	}
-
-	if err := tx.Create(&delivery).Error; err != nil {
+	if err := services.SaveOrderDelivery(tx, &delivery); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create delivery"})
+		return
+	}
+
+	if err := services.AddToBatch(tx, &partner, active, &delivery); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create delivery"})
+		return
+	}
+
+	if err := services.RecordOrderEvent(tx, order.ID, models.OrderEventDelivery, "", string(delivery.Status),
+		services.ByUser(services.ActorAdmin, userID).WithNotes("Manually assigned to partner "+partner.ID.String())); err != nil {
 		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create delivery"})
 		return
 	}
 
 	order.DeliveryID = &delivery.ID
-	order.Status = models.OrderStatusPickedUp
-	now := time.Now()
-	order.PickedUpAt = &now
 	order.EstimatedDeliveryTime = estimatedDuration
-
-	if err := tx.Save(&order).Error; err != nil {
+	if err := services.UpdateOrderStatus(tx, &order, models.OrderStatusDriverAssigned, services.ByUser(services.ActorAdmin, userID), map[string]interface{}{
+		"delivery_id":             delivery.ID,
+		"estimated_delivery_time": estimatedDuration,
+	}); err != nil {
 		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update order"})
+		respondOrderStatusError(c, err)
 		return
 	}
 
@@ -1520,7 +2456,8 @@ func (h *DeliveryHandler) ListZones(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"data": responses})
 }
 
-// CreateZone creates a new delivery zone
+// CreateZone creates a new delivery zone. When a GeoJSON boundary is given its
+// bounding box is derived from it.
 func (h *DeliveryHandler) CreateZone(c *gin.Context) {
 	var req struct {
 		Name              string  `json:"name" binding:"required"`
@@ -1533,10 +2470,15 @@ func (h *DeliveryHandler) CreateZone(c *gin.Context) {
 		MinLongitude      float64 `json:"minLongitude"`
 		MaxLongitude      float64 `json:"maxLongitude"`
 		Boundary          string  `json:"boundary"`
+		Priority          int     `json:"priority"`
 		Currency          string  `json:"currency"`
 		BaseFare          float64 `json:"baseFare"`
 		PerKmRate         float64 `json:"perKmRate"`
 		MinimumFare       float64 `json:"minimumFare"`
+		AutoSurge         *bool   `json:"autoSurge"`
+		SurgeFloor        float64 `json:"surgeFloor"`
+		SurgeCeiling      float64 `json:"surgeCeiling"`
+		SurgeStep         float64 `json:"surgeStep"`
 		TipEnabled        *bool   `json:"tipEnabled"`
 		DefaultTipPercent float64 `json:"defaultTipPercent"`
 		MaxTipAmount      float64 `json:"maxTipAmount"`
@@ -1555,6 +2497,14 @@ func (h *DeliveryHandler) CreateZone(c *gin.Context) {
 	if tier == "" { tier = "standard" }
 	tipEnabled := true
 	if req.TipEnabled != nil { tipEnabled = *req.TipEnabled }
+	autoSurge := true
+	if req.AutoSurge != nil { autoSurge = *req.AutoSurge }
+	surgeFloor := req.SurgeFloor
+	if surgeFloor == 0 { surgeFloor = 1 }
+	surgeCeiling := req.SurgeCeiling
+	if surgeCeiling == 0 { surgeCeiling = 2 }
+	surgeStep := req.SurgeStep
+	if surgeStep == 0 { surgeStep = 0.25 }
 	defaultTip := req.DefaultTipPercent
 	if defaultTip == 0 { defaultTip = 10 }
 	driverPayout := req.DriverPayoutPercent
@@ -1571,11 +2521,16 @@ func (h *DeliveryHandler) CreateZone(c *gin.Context) {
 		MinLongitude:        req.MinLongitude,
 		MaxLongitude:        req.MaxLongitude,
 		Boundary:            req.Boundary,
+		Priority:            req.Priority,
 		Currency:            currency,
 		BaseFare:            req.BaseFare,
 		PerKmRate:           req.PerKmRate,
 		MinimumFare:         req.MinimumFare,
-		SurgeMultiplier:     1.0,
+		SurgeMultiplier:     surgeFloor,
+		AutoSurge:           autoSurge,
+		SurgeFloor:          surgeFloor,
+		SurgeCeiling:        surgeCeiling,
+		SurgeStep:           surgeStep,
 		TipEnabled:          tipEnabled,
 		DefaultTipPercent:   defaultTip,
 		MaxTipAmount:        req.MaxTipAmount,
@@ -1583,6 +2538,20 @@ func (h *DeliveryHandler) CreateZone(c *gin.Context) {
 		IsActive:            true,
 	}
 
+	if err := services.ValidateSurgeLimits(&zone); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if _, err := services.PrepareZone(database.DB, &zone); err != nil {
+		var zoneErr *services.ZoneError
+		if errors.As(err, &zoneErr) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": zoneErr.Message})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check zone boundary"})
+		return
+	}
+
 	if err := database.DB.Create(&zone).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create zone"})
 		return
@@ -1591,8 +2560,11 @@ func (h *DeliveryHandler) CreateZone(c *gin.Context) {
 	c.JSON(http.StatusCreated, zone.ToResponse())
 }
 
-// UpdateZone updates a delivery zone
+// UpdateZone updates a delivery zone. A zone with a boundary keeps the bounding
+// box derived from it. Setting the surge multiplier by hand is recorded in the
+// zone's surge history and holds it for a while before automatic surge resumes.
 func (h *DeliveryHandler) UpdateZone(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
 	zoneID := c.Param("id")
 
 	var zone models.DeliveryZone
@@ -1612,11 +2584,16 @@ func (h *DeliveryHandler) UpdateZone(c *gin.Context) {
 		MinLongitude      *float64 `json:"minLongitude"`
 		MaxLongitude      *float64 `json:"maxLongitude"`
 		Boundary          *string  `json:"boundary"`
+		Priority          *int     `json:"priority"`
 		Currency          *string  `json:"currency"`
 		BaseFare          *float64 `json:"baseFare"`
 		PerKmRate         *float64 `json:"perKmRate"`
 		MinimumFare       *float64 `json:"minimumFare"`
 		SurgeMultiplier   *float64 `json:"surgeMultiplier"`
+		AutoSurge         *bool    `json:"autoSurge"`
+		SurgeFloor        *float64 `json:"surgeFloor"`
+		SurgeCeiling      *float64 `json:"surgeCeiling"`
+		SurgeStep         *float64 `json:"surgeStep"`
 		TipEnabled        *bool    `json:"tipEnabled"`
 		DefaultTipPercent *float64 `json:"defaultTipPercent"`
 		MaxTipAmount      *float64 `json:"maxTipAmount"`
@@ -1628,6 +2605,7 @@ func (h *DeliveryHandler) UpdateZone(c *gin.Context) {
 		return
 	}
 
+	previousSurge := zone.SurgeMultiplier
 	if req.Name != nil { zone.Name = *req.Name }
 	if req.City != nil { zone.City = *req.City }
 	if req.State != nil { zone.State = *req.State }
@@ -1638,18 +2616,50 @@ func (h *DeliveryHandler) UpdateZone(c *gin.Context) {
 	if req.MinLongitude != nil { zone.MinLongitude = *req.MinLongitude }
 	if req.MaxLongitude != nil { zone.MaxLongitude = *req.MaxLongitude }
 	if req.Boundary != nil { zone.Boundary = *req.Boundary }
+	if req.Priority != nil { zone.Priority = *req.Priority }
 	if req.Currency != nil { zone.Currency = *req.Currency }
 	if req.BaseFare != nil { zone.BaseFare = *req.BaseFare }
 	if req.PerKmRate != nil { zone.PerKmRate = *req.PerKmRate }
 	if req.MinimumFare != nil { zone.MinimumFare = *req.MinimumFare }
 	if req.SurgeMultiplier != nil { zone.SurgeMultiplier = *req.SurgeMultiplier }
+	if req.AutoSurge != nil { zone.AutoSurge = *req.AutoSurge }
+	if req.SurgeFloor != nil { zone.SurgeFloor = *req.SurgeFloor }
+	if req.SurgeCeiling != nil { zone.SurgeCeiling = *req.SurgeCeiling }
+	if req.SurgeStep != nil { zone.SurgeStep = *req.SurgeStep }
 	if req.TipEnabled != nil { zone.TipEnabled = *req.TipEnabled }
 	if req.DefaultTipPercent != nil { zone.DefaultTipPercent = *req.DefaultTipPercent }
 	if req.MaxTipAmount != nil { zone.MaxTipAmount = *req.MaxTipAmount }
 	if req.DriverPayoutPercent != nil { zone.DriverPayoutPercent = *req.DriverPayoutPercent }
 	if req.IsActive != nil { zone.IsActive = *req.IsActive }
 
-	if err := database.DB.Save(&zone).Error; err != nil {
+	if err := services.ValidateSurgeLimits(&zone); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if _, err := services.PrepareZone(database.DB, &zone); err != nil {
+		var zoneErr *services.ZoneError
+		if errors.As(err, &zoneErr) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": zoneErr.Message})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check zone boundary"})
+		return
+	}
+
+	tx := database.DB.Begin()
+	if err := tx.Save(&zone).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update zone"})
+		return
+	}
+	if zone.SurgeMultiplier != previousSurge {
+		if err := services.RecordSurgeChange(tx, &zone, previousSurge, models.SurgeSourceAdmin, &userID); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update zone"})
+			return
+		}
+	}
+	if err := tx.Commit().Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update zone"})
 		return
 	}
@@ -1657,6 +2667,80 @@ func (h *DeliveryHandler) UpdateZone(c *gin.Context) {
 	c.JSON(http.StatusOK, zone.ToResponse())
 }
 
+// ImportZones saves zone boundaries from an uploaded GeoJSON FeatureCollection.
+// Each feature updates the zone with the same name and city or creates a new
+// one. Nothing is saved unless every feature is accepted.
+// POST /admin/delivery/zones/import
+func (h *DeliveryHandler) ImportZones(c *gin.Context) {
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File is required"})
+		return
+	}
+	defer file.Close()
+
+	if header.Size > services.ZoneImportMaxSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File too large (max 10MB)"})
+		return
+	}
+
+	result, err := services.ImportZoneBoundaries(database.DB, file)
+	if err != nil {
+		var zoneErr *services.ZoneError
+		var importErr *services.ZoneImportError
+		switch {
+		case errors.As(err, &zoneErr):
+			c.JSON(http.StatusBadRequest, gin.H{"error": zoneErr.Message})
+		case errors.As(err, &importErr):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Some features were rejected, nothing was imported", "problems": importErr.Problems})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import zones"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetZoneSurgeHistory returns a zone's surge changes, newest first
+// GET /admin/delivery/zones/:id/surge-history
+func (h *DeliveryHandler) GetZoneSurgeHistory(c *gin.Context) {
+	var zone models.DeliveryZone
+	if err := database.DB.Where("id = ?", c.Param("id")).First(&zone).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Zone not found"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 200 {
+		limit = 50
+	}
+
+	var total int64
+	database.DB.Model(&models.ZoneSurgeChange{}).Where("zone_id = ?", zone.ID).Count(&total)
+
+	var changes []models.ZoneSurgeChange
+	if err := database.DB.Where("zone_id = ?", zone.ID).Order("created_at DESC").
+		Offset((page - 1) * limit).Limit(limit).Find(&changes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch surge history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": changes,
+		"pagination": gin.H{
+			"page":       page,
+			"limit":      limit,
+			"total":      total,
+			"totalPages": (total + int64(limit) - 1) / int64(limit),
+		},
+	})
+}
+
 // DeleteZone soft-deletes a delivery zone
 func (h *DeliveryHandler) DeleteZone(c *gin.Context) {
 	zoneID := c.Param("id")
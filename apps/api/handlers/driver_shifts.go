@@ -0,0 +1,383 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/homechef/api/database"
+	"github.com/homechef/api/middleware"
+	"github.com/homechef/api/models"
+	"github.com/homechef/api/services"
+)
+
+type ShiftHandler struct{}
+
+func NewShiftHandler() *ShiftHandler {
+	return &ShiftHandler{}
+}
+
+// respondShiftError reports a refused roster change, or a server error
+func respondShiftError(c *gin.Context, err error) {
+	var shiftErr *services.ShiftError
+	if errors.As(err, &shiftErr) {
+		c.JSON(http.StatusConflict, gin.H{"error": shiftErr.Message})
+		return
+	}
+	log.Printf("Failed to update shift roster: %v", err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update shift"})
+}
+
+// parseRosterDate parses a YYYY-MM-DD date as local midnight
+func parseRosterDate(value string) (time.Time, error) {
+	return time.ParseInLocation("2006-01-02", value, time.Local)
+}
+
+// PublishShifts adds a week of shifts to a zone's roster
+// POST /admin/fleet/shifts
+func (h *ShiftHandler) PublishShifts(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var req struct {
+		ZoneID    string               `json:"zoneId" binding:"required"`
+		WeekStart string               `json:"weekStart" binding:"required"`
+		Shifts    []services.ShiftSpec `json:"shifts" binding:"required,min=1,dive"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	zoneID, err := uuid.Parse(req.ZoneID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid zone ID"})
+		return
+	}
+	weekStart, err := parseRosterDate(req.WeekStart)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "weekStart must be a date (YYYY-MM-DD)"})
+		return
+	}
+
+	shifts, err := services.PublishShifts(database.DB, zoneID, weekStart, req.Shifts, userID)
+	if err != nil {
+		respondShiftError(c, err)
+		return
+	}
+
+	responses := make([]models.DriverShiftResponse, len(shifts))
+	for i, s := range shifts {
+		responses[i] = s.ToResponse()
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": responses})
+}
+
+// ListShifts returns the roster, defaulting to the current week
+// GET /admin/fleet/shifts
+func (h *ShiftHandler) ListShifts(c *gin.Context) {
+	weekStart := services.WeekStartOf(time.Now())
+	if v := c.Query("weekStart"); v != "" {
+		parsed, err := parseRosterDate(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "weekStart must be a date (YYYY-MM-DD)"})
+			return
+		}
+		weekStart = services.WeekStartOf(parsed)
+	}
+
+	query := database.DB.Preload("Zone").Preload("Partner.User").
+		Where("starts_at >= ? AND starts_at < ?", weekStart, weekStart.AddDate(0, 0, 7))
+	if zoneID := c.Query("zoneId"); zoneID != "" {
+		query = query.Where("zone_id = ?", zoneID)
+	}
+	if partnerID := c.Query("partnerId"); partnerID != "" {
+		query = query.Where("partner_id = ?", partnerID)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var shifts []models.DriverShift
+	if err := query.Order("starts_at, zone_id").Find(&shifts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch shifts"})
+		return
+	}
+
+	responses := make([]models.DriverShiftResponse, len(shifts))
+	open := 0
+	for i, s := range shifts {
+		responses[i] = s.ToResponse()
+		if s.Status == models.ShiftOpen {
+			open++
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":       responses,
+		"weekStart":  weekStart.Format("2006-01-02"),
+		"openShifts": open,
+	})
+}
+
+// AssignShift puts an internal agent on a shift
+// PUT /admin/fleet/shifts/:id/assign
+func (h *ShiftHandler) AssignShift(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	shiftID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shift ID"})
+		return
+	}
+	var req struct {
+		PartnerID string `json:"partnerId" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	partnerID, err := uuid.Parse(req.PartnerID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid partner ID"})
+		return
+	}
+
+	shift, err := services.AssignShift(database.DB, shiftID, partnerID, userID)
+	if err != nil {
+		respondShiftError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, shift.ToResponse())
+}
+
+// CancelShift takes a shift off the roster
+// PUT /admin/fleet/shifts/:id/cancel
+func (h *ShiftHandler) CancelShift(c *gin.Context) {
+	shiftID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shift ID"})
+		return
+	}
+
+	shift, err := services.CancelShift(database.DB, shiftID)
+	if err != nil {
+		respondShiftError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, shift.ToResponse())
+}
+
+// GetAttendanceReport returns attendance per shift and per agent, defaulting
+// to the current week
+// GET /admin/fleet/attendance
+func (h *ShiftHandler) GetAttendanceReport(c *gin.Context) {
+	filter := services.AttendanceFilter{From: services.WeekStartOf(time.Now())}
+	if v := c.Query("from"); v != "" {
+		from, err := parseRosterDate(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from must be a date (YYYY-MM-DD)"})
+			return
+		}
+		filter.From = from
+	}
+	filter.To = filter.From.AddDate(0, 0, 7)
+	if v := c.Query("to"); v != "" {
+		to, err := parseRosterDate(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to must be a date (YYYY-MM-DD)"})
+			return
+		}
+		filter.To = to.AddDate(0, 0, 1) // inclusive
+	}
+	if !filter.To.After(filter.From) || filter.To.Sub(filter.From) > 92*24*time.Hour {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Report range must be between 1 and 92 days"})
+		return
+	}
+	if v := c.Query("zoneId"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid zone ID"})
+			return
+		}
+		filter.ZoneID = &id
+	}
+	if v := c.Query("partnerId"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid partner ID"})
+			return
+		}
+		filter.PartnerID = &id
+	}
+
+	shifts, partners, err := services.AttendanceReport(database.DB, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build attendance report"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from":     filter.From.Format("2006-01-02"),
+		"to":       filter.To.AddDate(0, 0, -1).Format("2006-01-02"),
+		"shifts":   shifts,
+		"partners": partners,
+	})
+}
+
+// ListOffShiftSessions returns times internal agents went online without a
+// rostered shift, newest first
+// GET /admin/fleet/off-shift
+func (h *ShiftHandler) ListOffShiftSessions(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 200 {
+		limit = 50
+	}
+
+	query := database.DB.Model(&models.PartnerOnlineSession{}).Where("outside_shift = ?", true)
+	if partnerID := c.Query("partnerId"); partnerID != "" {
+		query = query.Where("partner_id = ?", partnerID)
+	}
+	if v := c.Query("from"); v != "" {
+		from, err := parseRosterDate(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from must be a date (YYYY-MM-DD)"})
+			return
+		}
+		query = query.Where("started_at >= ?", from)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var sessions []models.PartnerOnlineSession
+	if err := query.Order("started_at DESC").Offset((page - 1) * limit).Limit(limit).Find(&sessions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch sessions"})
+		return
+	}
+
+	partnerIDs := make([]uuid.UUID, len(sessions))
+	for i, s := range sessions {
+		partnerIDs[i] = s.PartnerID
+	}
+	var partners []models.DeliveryPartner
+	database.DB.Preload("User").Where("id IN ?", partnerIDs).Find(&partners)
+	names := map[uuid.UUID]string{}
+	for _, p := range partners {
+		names[p.ID] = p.User.FirstName + " " + p.User.LastName
+	}
+
+	data := make([]gin.H, len(sessions))
+	for i, s := range sessions {
+		var minutes int
+		if s.EndedAt != nil {
+			minutes = int(s.EndedAt.Sub(s.StartedAt).Minutes())
+		} else {
+			minutes = int(time.Since(s.StartedAt).Minutes())
+		}
+		data[i] = gin.H{
+			"id":          s.ID,
+			"partnerId":   s.PartnerID,
+			"partnerName": names[s.PartnerID],
+			"startedAt":   s.StartedAt,
+			"endedAt":     s.EndedAt,
+			"minutes":     minutes,
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": data,
+		"pagination": gin.H{
+			"page":       page,
+			"limit":      limit,
+			"total":      total,
+			"totalPages": (total + int64(limit) - 1) / int64(limit),
+		},
+	})
+}
+
+// GetMyShifts returns an internal agent's upcoming shifts and the open shifts
+// in their city they can claim
+// GET /delivery/shifts
+func (h *ShiftHandler) GetMyShifts(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var partner models.DeliveryPartner
+	if err := database.DB.Where("user_id = ?", userID).First(&partner).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Delivery partner profile not found"})
+		return
+	}
+	if partner.AgentType != models.AgentInternal {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only internal agents work rostered shifts"})
+		return
+	}
+
+	now := time.Now()
+	var mine []models.DriverShift
+	if err := database.DB.Preload("Zone").
+		Where("partner_id = ? AND status = ? AND ends_at > ?", partner.ID, models.ShiftAssigned, now).
+		Order("starts_at").Find(&mine).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch shifts"})
+		return
+	}
+
+	var open []models.DriverShift
+	if partner.City != "" {
+		if err := database.DB.Preload("Zone").
+			Joins("JOIN delivery_zones ON delivery_zones.id = driver_shifts.zone_id").
+			Where("driver_shifts.status = ? AND driver_shifts.starts_at > ? AND LOWER(delivery_zones.city) = ?",
+				models.ShiftOpen, now, strings.ToLower(partner.City)).
+			Order("driver_shifts.starts_at").Limit(100).Find(&open).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch shifts"})
+			return
+		}
+	}
+
+	mineResp := make([]models.DriverShiftResponse, len(mine))
+	for i, s := range mine {
+		mineResp[i] = s.ToResponse()
+	}
+	openResp := make([]models.DriverShiftResponse, len(open))
+	for i, s := range open {
+		openResp[i] = s.ToResponse()
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"shifts":     mineResp,
+		"openShifts": openResp,
+	})
+}
+
+// ClaimShift puts the calling internal agent on an open shift
+// POST /delivery/shifts/:id/claim
+func (h *ShiftHandler) ClaimShift(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var partner models.DeliveryPartner
+	if err := database.DB.Where("user_id = ?", userID).First(&partner).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Delivery partner profile not found"})
+		return
+	}
+	shiftID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shift ID"})
+		return
+	}
+
+	shift, err := services.ClaimShift(database.DB, shiftID, &partner)
+	if err != nil {
+		respondShiftError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, shift.ToResponse())
+}
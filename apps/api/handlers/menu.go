@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -38,7 +39,7 @@ func (h *MenuHandler) GetChefMenuItems(c *gin.Context) {
 	}
 
 	var items []models.MenuItem
-	database.DB.Where("chef_id = ?", chef.ID).Preload("Images", func(db *gorm.DB) *gorm.DB {
+	withModifiers(database.DB, "").Where("chef_id = ?", chef.ID).Preload("Images", func(db *gorm.DB) *gorm.DB {
 		return db.Order("sort_order ASC")
 	}).Order("sort_order ASC, created_at DESC").Find(&items)
 
@@ -74,7 +75,7 @@ func (h *MenuHandler) GetMenuItem(c *gin.Context) {
 	}
 
 	var item models.MenuItem
-	if err := database.DB.Preload("Images", func(db *gorm.DB) *gorm.DB {
+	if err := withModifiers(database.DB, "").Preload("Images", func(db *gorm.DB) *gorm.DB {
 		return db.Order("sort_order ASC")
 	}).Where("id = ? AND chef_id = ?", itemID, chef.ID).First(&item).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Menu item not found"})
@@ -131,6 +132,11 @@ func (h *MenuHandler) CreateMenuItem(c *gin.Context) {
 		SpiceLevel:   req.SpiceLevel,
 		IsAvailable:  true,
 		IsFeatured:   req.IsFeatured,
+		DailyLimit:   req.DailyLimit,
+	}
+	if req.DailyLimit > 0 {
+		item.RemainingQuantity = req.DailyLimit
+		item.StockDate = services.StockDate(time.Now(), services.ChefLocation(database.DB, chef.ID))
 	}
 
 	if req.CategoryID != "" {
@@ -240,6 +246,15 @@ func (h *MenuHandler) UpdateMenuItem(c *gin.Context) {
 	}
 	if req.IsAvailable != nil {
 		updates["is_available"] = *req.IsAvailable
+		updates["sold_out_at"] = nil
+	}
+	if req.DailyLimit != nil || req.RemainingQuantity != nil {
+		// An explicit isAvailable in the same request wins
+		for k, v := range stockUpdates(&item, req.DailyLimit, req.RemainingQuantity) {
+			if _, set := updates[k]; !set {
+				updates[k] = v
+			}
+		}
 	}
 	if req.IsFeatured != nil {
 		updates["is_featured"] = *req.IsFeatured
@@ -620,24 +635,27 @@ type CreateMenuItemRequest struct {
 	Serves       int      `json:"serves"`
 	SpiceLevel   int      `json:"spiceLevel"`
 	IsFeatured   bool     `json:"isFeatured"`
+	DailyLimit   int      `json:"dailyLimit" binding:"min=0"`
 }
 
 type UpdateMenuItemRequest struct {
-	Name         *string   `json:"name"`
-	Description  *string   `json:"description"`
-	Price        *float64  `json:"price"`
-	ComparePrice *float64  `json:"comparePrice"`
-	CategoryID   *string   `json:"categoryId"`
-	ImageURL     *string   `json:"imageUrl"`
-	DietaryTags  *[]string `json:"dietaryTags"`
-	Allergens    *[]string `json:"allergens"`
-	Ingredients  *[]string `json:"ingredients"`
-	PrepTime     *int      `json:"prepTime"`
-	PortionSize  *string   `json:"portionSize"`
-	Serves       *int      `json:"serves"`
-	SpiceLevel   *int      `json:"spiceLevel"`
-	IsAvailable  *bool     `json:"isAvailable"`
-	IsFeatured   *bool     `json:"isFeatured"`
+	Name              *string   `json:"name"`
+	Description       *string   `json:"description"`
+	Price             *float64  `json:"price"`
+	ComparePrice      *float64  `json:"comparePrice"`
+	CategoryID        *string   `json:"categoryId"`
+	ImageURL          *string   `json:"imageUrl"`
+	DietaryTags       *[]string `json:"dietaryTags"`
+	Allergens         *[]string `json:"allergens"`
+	Ingredients       *[]string `json:"ingredients"`
+	PrepTime          *int      `json:"prepTime"`
+	PortionSize       *string   `json:"portionSize"`
+	Serves            *int      `json:"serves"`
+	SpiceLevel        *int      `json:"spiceLevel"`
+	IsAvailable       *bool     `json:"isAvailable"`
+	IsFeatured        *bool     `json:"isFeatured"`
+	DailyLimit        *int      `json:"dailyLimit" binding:"omitempty,min=0"`
+	RemainingQuantity *int      `json:"remainingQuantity" binding:"omitempty,min=0"` // manual restock for today
 }
 
 type CreateCategoryRequest struct {
@@ -655,6 +673,41 @@ type UpdateCategoryRequest struct {
 
 // ---------- Helpers ----------
 
+// stockUpdates works out the column changes when a chef edits an item's daily
+// limit or restocks it by hand. Portions already sold today still count
+// against a new limit, and restocking a sold-out item puts it back on sale.
+func stockUpdates(item *models.MenuItem, dailyLimit, remaining *int) map[string]interface{} {
+	today := services.StockDate(time.Now(), services.ChefLocation(database.DB, item.ChefID))
+
+	limit := item.DailyLimit
+	if dailyLimit != nil {
+		limit = *dailyLimit
+	}
+
+	left := limit
+	if item.StockDate == today && item.DailyLimit > 0 {
+		sold := item.DailyLimit - item.RemainingQuantity
+		left = max(limit-sold, 0)
+	}
+	if remaining != nil {
+		left = *remaining
+	}
+
+	updates := map[string]interface{}{
+		"daily_limit":        limit,
+		"remaining_quantity": left,
+		"stock_date":         today,
+	}
+	if limit > 0 && left == 0 {
+		updates["is_available"] = false
+		updates["sold_out_at"] = time.Now()
+	} else if item.SoldOutAt != nil {
+		updates["is_available"] = true
+		updates["sold_out_at"] = nil
+	}
+	return updates
+}
+
 func ensureStringArray(arr []string) pq.StringArray {
 	if arr == nil {
 		return pq.StringArray{}
@@ -0,0 +1,376 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/homechef/api/database"
+	"github.com/homechef/api/middleware"
+	"github.com/homechef/api/models"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+// ---------- Modifier Groups ----------
+
+// CreateModifierGroup adds a modifier group, with its options, to a menu item.
+// POST /chef/menu/items/:itemId/modifier-groups
+func (h *MenuHandler) CreateModifierGroup(c *gin.Context) {
+	item, ok := findChefMenuItem(c)
+	if !ok {
+		return
+	}
+
+	var req CreateModifierGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	group := models.MenuModifierGroup{
+		MenuItemID:  item.ID,
+		Name:        req.Name,
+		MinSelect:   req.MinSelect,
+		MaxSelect:   1,
+		IsRequired:  req.IsRequired,
+		IsAvailable: true,
+		SortOrder:   req.SortOrder,
+	}
+	if req.MaxSelect != nil {
+		group.MaxSelect = *req.MaxSelect
+	}
+	if errMsg := validateModifierGroup(&group, len(req.Options)); errMsg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg})
+		return
+	}
+
+	for _, opt := range req.Options {
+		if errMsg := validatePriceDelta(item.Price, opt.PriceDelta); errMsg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": errMsg})
+			return
+		}
+	}
+
+	group.Options = make([]models.MenuModifierOption, len(req.Options))
+	for i, opt := range req.Options {
+		group.Options[i] = models.MenuModifierOption{
+			Name:        opt.Name,
+			PriceDelta:  opt.PriceDelta,
+			IsAvailable: true,
+			SortOrder:   opt.SortOrder,
+		}
+	}
+
+	if err := database.DB.Create(&group).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create modifier group"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, group)
+}
+
+// UpdateModifierGroup updates a modifier group's rules. Options are managed
+// through their own endpoints so carts referencing them stay valid.
+// PUT /chef/menu/items/:itemId/modifier-groups/:groupId
+func (h *MenuHandler) UpdateModifierGroup(c *gin.Context) {
+	group, ok := findChefModifierGroup(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateModifierGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Name != nil {
+		group.Name = *req.Name
+	}
+	if req.MinSelect != nil {
+		group.MinSelect = *req.MinSelect
+	}
+	if req.MaxSelect != nil {
+		group.MaxSelect = *req.MaxSelect
+	}
+	if req.IsRequired != nil {
+		group.IsRequired = *req.IsRequired
+	}
+	if req.IsAvailable != nil {
+		group.IsAvailable = *req.IsAvailable
+	}
+	if req.SortOrder != nil {
+		group.SortOrder = *req.SortOrder
+	}
+
+	var optionCount int64
+	database.DB.Model(&models.MenuModifierOption{}).Where("group_id = ?", group.ID).Count(&optionCount)
+	if errMsg := validateModifierGroup(group, int(optionCount)); errMsg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg})
+		return
+	}
+
+	if err := database.DB.Model(group).Updates(map[string]interface{}{
+		"name":         group.Name,
+		"min_select":   group.MinSelect,
+		"max_select":   group.MaxSelect,
+		"is_required":  group.IsRequired,
+		"is_available": group.IsAvailable,
+		"sort_order":   group.SortOrder,
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update modifier group"})
+		return
+	}
+
+	database.DB.Preload("Options", func(db *gorm.DB) *gorm.DB {
+		return db.Order("sort_order ASC, created_at ASC")
+	}).First(group, "id = ?", group.ID)
+	c.JSON(http.StatusOK, group)
+}
+
+// DeleteModifierGroup soft-deletes a modifier group and its options.
+// DELETE /chef/menu/items/:itemId/modifier-groups/:groupId
+func (h *MenuHandler) DeleteModifierGroup(c *gin.Context) {
+	group, ok := findChefModifierGroup(c)
+	if !ok {
+		return
+	}
+
+	tx := database.DB.Begin()
+	if err := tx.Where("group_id = ?", group.ID).Delete(&models.MenuModifierOption{}).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete modifier group"})
+		return
+	}
+	if err := tx.Delete(group).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete modifier group"})
+		return
+	}
+	tx.Commit()
+
+	c.JSON(http.StatusOK, gin.H{"message": "Modifier group deleted"})
+}
+
+// ---------- Modifier Options ----------
+
+// CreateModifierOption adds an option to a modifier group.
+// POST /chef/menu/items/:itemId/modifier-groups/:groupId/options
+func (h *MenuHandler) CreateModifierOption(c *gin.Context) {
+	group, ok := findChefModifierGroup(c)
+	if !ok {
+		return
+	}
+
+	var req ModifierOptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errMsg := validatePriceDelta(modifierItemPrice(group), req.PriceDelta); errMsg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg})
+		return
+	}
+
+	option := models.MenuModifierOption{
+		GroupID:     group.ID,
+		Name:        req.Name,
+		PriceDelta:  req.PriceDelta,
+		IsAvailable: true,
+		SortOrder:   req.SortOrder,
+	}
+	if err := database.DB.Create(&option).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create modifier option"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, option)
+}
+
+// UpdateModifierOption updates an option's name, price delta or availability.
+// PUT /chef/menu/items/:itemId/modifier-groups/:groupId/options/:optionId
+func (h *MenuHandler) UpdateModifierOption(c *gin.Context) {
+	group, ok := findChefModifierGroup(c)
+	if !ok {
+		return
+	}
+
+	var option models.MenuModifierOption
+	if err := database.DB.Where("id = ? AND group_id = ?", c.Param("optionId"), group.ID).First(&option).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Modifier option not found"})
+		return
+	}
+
+	var req UpdateModifierOptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	updates := map[string]interface{}{}
+	if req.Name != nil {
+		updates["name"] = *req.Name
+	}
+	if req.PriceDelta != nil {
+		if errMsg := validatePriceDelta(modifierItemPrice(group), *req.PriceDelta); errMsg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": errMsg})
+			return
+		}
+		updates["price_delta"] = *req.PriceDelta
+	}
+	if req.IsAvailable != nil {
+		updates["is_available"] = *req.IsAvailable
+	}
+	if req.SortOrder != nil {
+		updates["sort_order"] = *req.SortOrder
+	}
+
+	if len(updates) > 0 {
+		if err := database.DB.Model(&option).Updates(updates).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update modifier option"})
+			return
+		}
+	}
+
+	database.DB.First(&option, "id = ?", option.ID)
+	c.JSON(http.StatusOK, option)
+}
+
+// DeleteModifierOption soft-deletes an option. Carts holding it are flagged as
+// unavailable on their next read.
+// DELETE /chef/menu/items/:itemId/modifier-groups/:groupId/options/:optionId
+func (h *MenuHandler) DeleteModifierOption(c *gin.Context) {
+	group, ok := findChefModifierGroup(c)
+	if !ok {
+		return
+	}
+
+	result := database.DB.Where("id = ? AND group_id = ?", c.Param("optionId"), group.ID).Delete(&models.MenuModifierOption{})
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Modifier option not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Modifier option deleted"})
+}
+
+// ---------- Request types ----------
+
+type ModifierOptionRequest struct {
+	Name       string  `json:"name" binding:"required"`
+	PriceDelta float64 `json:"priceDelta"`
+	SortOrder  int     `json:"sortOrder"`
+}
+
+type CreateModifierGroupRequest struct {
+	Name       string                  `json:"name" binding:"required"`
+	MinSelect  int                     `json:"minSelect" binding:"min=0"`
+	MaxSelect  *int                    `json:"maxSelect" binding:"omitempty,min=0"`
+	IsRequired bool                    `json:"isRequired"`
+	SortOrder  int                     `json:"sortOrder"`
+	Options    []ModifierOptionRequest `json:"options" binding:"required,min=1,dive"`
+}
+
+type UpdateModifierGroupRequest struct {
+	Name        *string `json:"name"`
+	MinSelect   *int    `json:"minSelect" binding:"omitempty,min=0"`
+	MaxSelect   *int    `json:"maxSelect" binding:"omitempty,min=0"`
+	IsRequired  *bool   `json:"isRequired"`
+	IsAvailable *bool   `json:"isAvailable"`
+	SortOrder   *int    `json:"sortOrder"`
+}
+
+type UpdateModifierOptionRequest struct {
+	Name        *string  `json:"name"`
+	PriceDelta  *float64 `json:"priceDelta"`
+	IsAvailable *bool    `json:"isAvailable"`
+	SortOrder   *int     `json:"sortOrder"`
+}
+
+// ---------- Helpers ----------
+
+// validateModifierGroup checks a group's selection rules can be satisfied.
+// Returns an error message for the client, or "" on success.
+func validateModifierGroup(group *models.MenuModifierGroup, optionCount int) string {
+	if group.MaxSelect > 0 && group.MinSelections() > group.MaxSelect {
+		return "minSelect cannot be greater than maxSelect"
+	}
+	if group.MinSelections() > optionCount {
+		return "Group requires more selections than it has options"
+	}
+	return ""
+}
+
+// validatePriceDelta checks an option cannot discount an item below free.
+// Returns an error message for the client, or "" on success.
+func validatePriceDelta(itemPrice, delta float64) string {
+	if itemPrice+delta < 0 {
+		return "priceDelta cannot take the item below a price of zero"
+	}
+	return ""
+}
+
+// modifierItemPrice is the base price of the menu item a group belongs to
+func modifierItemPrice(group *models.MenuModifierGroup) float64 {
+	var price float64
+	database.DB.Model(&models.MenuItem{}).Where("id = ?", group.MenuItemID).Select("price").Scan(&price)
+	return price
+}
+
+// withModifiers preloads modifier groups and options in menu order. path is
+// the association path to the menu item, e.g. "Items.MenuItem.", or "" when
+// querying menu items directly.
+func withModifiers(db *gorm.DB, path string) *gorm.DB {
+	return db.Preload(path+"ModifierGroups", func(db *gorm.DB) *gorm.DB {
+		return db.Order("sort_order ASC, created_at ASC")
+	}).Preload(path+"ModifierGroups.Options", func(db *gorm.DB) *gorm.DB {
+		return db.Order("sort_order ASC, created_at ASC")
+	})
+}
+
+// sortedOptionIDs normalises chosen option IDs so identical selections compare equal
+func sortedOptionIDs(ids []uuid.UUID) pq.StringArray {
+	out := make(pq.StringArray, len(ids))
+	for i, id := range ids {
+		out[i] = id.String()
+	}
+	sort.Strings(out)
+	return out
+}
+
+// findChefMenuItem loads the :itemId menu item owned by the signed-in chef,
+// writing a 404 if missing
+func findChefMenuItem(c *gin.Context) (*models.MenuItem, bool) {
+	userID, _ := middleware.GetUserID(c)
+
+	var chef models.ChefProfile
+	if err := database.DB.Where("user_id = ?", userID).First(&chef).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Chef profile not found"})
+		return nil, false
+	}
+
+	var item models.MenuItem
+	if err := database.DB.Where("id = ? AND chef_id = ?", c.Param("itemId"), chef.ID).First(&item).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Menu item not found"})
+		return nil, false
+	}
+	return &item, true
+}
+
+// findChefModifierGroup loads the :groupId modifier group on the chef's
+// :itemId menu item, writing a 404 if missing
+func findChefModifierGroup(c *gin.Context) (*models.MenuModifierGroup, bool) {
+	item, ok := findChefMenuItem(c)
+	if !ok {
+		return nil, false
+	}
+
+	var group models.MenuModifierGroup
+	if err := database.DB.Where("id = ? AND menu_item_id = ?", c.Param("groupId"), item.ID).First(&group).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Modifier group not found"})
+		return nil, false
+	}
+	return &group, true
+}
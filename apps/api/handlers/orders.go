@@ -1,7 +1,10 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"math/rand"
 	"net/http"
@@ -14,6 +17,7 @@ import (
 	"github.com/homechef/api/middleware"
 	"github.com/homechef/api/models"
 	"github.com/homechef/api/services"
+	"gorm.io/gorm/clause"
 )
 
 type OrderHandler struct{}
@@ -37,9 +41,10 @@ type CreateOrderRequest struct {
 }
 
 type CreateOrderItem struct {
-	MenuItemID uuid.UUID `json:"menuItemId" binding:"required"`
-	Quantity   int       `json:"quantity" binding:"required,min=1"`
-	Notes      string    `json:"notes"`
+	MenuItemID        uuid.UUID   `json:"menuItemId" binding:"required"`
+	Quantity          int         `json:"quantity" binding:"required,min=1"`
+	Notes             string      `json:"notes"`
+	ModifierOptionIDs []uuid.UUID `json:"modifierOptionIds"`
 }
 
 type CreateAddressRequest struct {
@@ -62,6 +67,12 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 		return
 	}
 
+	h.placeOrder(c, userID, req)
+}
+
+// placeOrder validates and persists an order for the given user. It is shared by
+// CreateOrder and the cart checkout path so both price and validate identically.
+func (h *OrderHandler) placeOrder(c *gin.Context, userID uuid.UUID, req CreateOrderRequest) {
 	// Verify chef exists and is active
 	var chef models.ChefProfile
 	if err := database.DB.Where("id = ? AND is_active = ? AND is_verified = ?", req.ChefID, true, true).
@@ -81,24 +92,36 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 
 	for i, item := range req.Items {
 		var menuItem models.MenuItem
-		if err := database.DB.Where("id = ? AND chef_id = ? AND is_available = ?",
+		if err := withModifiers(database.DB, "").Where("id = ? AND chef_id = ? AND is_available = ?",
 			item.MenuItemID, req.ChefID, true).First(&menuItem).Error; err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Menu item %s not found or unavailable", item.MenuItemID)})
 			return
 		}
 
-		itemSubtotal := menuItem.Price * float64(item.Quantity)
+		// Price each unit from the live menu price plus the chosen options
+		modifiers, delta, err := menuItem.SelectModifiers(item.ModifierOptionIDs)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		modifiersJSON, _ := json.Marshal(modifiers)
+		unitPrice := models.RoundAmount(menuItem.Price + delta)
+
+		itemSubtotal := models.RoundAmount(unitPrice * float64(item.Quantity))
 		subtotal += itemSubtotal
 
 		orderItems[i] = models.OrderItem{
 			MenuItemID: item.MenuItemID,
 			Name:       menuItem.Name,
-			Price:      menuItem.Price,
+			Price:      unitPrice,
+			BasePrice:  menuItem.Price,
+			Modifiers:  string(modifiersJSON),
 			Quantity:   item.Quantity,
 			Subtotal:   itemSubtotal,
 			Notes:      item.Notes,
 		}
 	}
+	subtotal = models.RoundAmount(subtotal)
 
 	// Check minimum order
 	if subtotal < chef.MinimumOrder {
@@ -110,16 +133,10 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 	}
 
 	// Calculate fees
-	deliveryFee := 2.99 // Base fee, could be calculated based on distance
-	serviceFee := subtotal * 0.10 // 10% service fee
-	tax := subtotal * 0.08 // 8% tax
+	serviceFee := models.RoundAmount(subtotal * 0.10) // 10% service fee
 	tip := req.Tip
 	discount := 0.0
 
-	// TODO: Apply promo code discount
-
-	total := subtotal + deliveryFee + serviceFee + tax + tip - discount
-
 	// Get or create delivery address
 	var deliveryAddr CreateAddressRequest
 	if req.DeliveryAddressID != nil {
@@ -145,6 +162,111 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 		return
 	}
 
+	// Price delivery from the chef's zone and the dropoff distance
+	feeQuote, err := services.QuoteDeliveryFee(&chef, deliveryAddr.Latitude, deliveryAddr.Longitude)
+	if err != nil {
+		var zoneErr *services.ZoneError
+		if errors.As(err, &zoneErr) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": zoneErr.Message})
+			return
+		}
+		log.Printf("Failed to quote delivery fee: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate delivery fee"})
+		return
+	}
+	deliveryFee := feeQuote.Fee
+
+	// Tax with the chef's country config; rates are snapshotted on the order
+	countryCode := services.ResolveChefCountry(&chef)
+	taxCfg, err := services.GetTaxConfig(countryCode)
+	if err != nil {
+		log.Printf("Failed to load tax config for %s: %v", countryCode, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate tax"})
+		return
+	}
+	orderTax := services.CalculateOrderTax(taxCfg, subtotal, serviceFee, deliveryFee)
+
+	// Validate the saved payment method chosen for checkout, if any
+	if req.PaymentMethodID != nil {
+		if method, errMsg := loadCheckoutPaymentMethod(userID, *req.PaymentMethodID); method == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": errMsg})
+			return
+		}
+	}
+
+	// Start transaction
+	tx := database.DB.Begin()
+
+	// Scheduled orders must land in an open delivery slot. The chef row is locked
+	// so two checkouts cannot both take the last place in a slot.
+	if req.ScheduledFor != nil {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", chef.ID).First(&models.ChefProfile{}).Error; err != nil {
+			tx.Rollback()
+			log.Printf("Failed to lock chef %s for scheduling: %v", chef.ID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate scheduled time"})
+			return
+		}
+		slotCfg := services.LoadSlotSettings(tx, &chef)
+		if _, err := services.ValidateScheduledFor(tx, &chef, slotCfg, *req.ScheduledFor, time.Now()); err != nil {
+			tx.Rollback()
+			var slotErr *services.SlotError
+			if errors.As(err, &slotErr) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": slotErr.Message})
+				return
+			}
+			log.Printf("Failed to validate scheduled time: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate scheduled time"})
+			return
+		}
+	}
+
+	// Take portions out of daily stock under row locks so the last portion
+	// cannot be sold twice
+	quantities := make(map[uuid.UUID]int, len(orderItems))
+	for _, item := range orderItems {
+		quantities[item.MenuItemID] += item.Quantity
+	}
+	stockFor := time.Now()
+	if req.ScheduledFor != nil {
+		stockFor = *req.ScheduledFor
+	}
+	if err := services.ReserveStock(tx, chef.ID, quantities, stockFor, time.Now()); err != nil {
+		tx.Rollback()
+		var stockErr *services.StockError
+		if errors.As(err, &stockErr) {
+			c.JSON(http.StatusConflict, gin.H{"error": stockErr.Message})
+			return
+		}
+		log.Printf("Failed to reserve stock: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reserve menu stock"})
+		return
+	}
+
+	// Apply promo code under a row lock so usage limits hold under concurrency
+	var promo *models.PromoCode
+	if req.PromoCode != "" {
+		promo, discount, err = services.ApplyPromoCode(tx, req.PromoCode, services.PromoCheckout{
+			UserID:   userID,
+			ChefID:   chef.ID,
+			City:     deliveryAddr.City,
+			Country:  countryCode,
+			Subtotal: subtotal,
+		})
+		if err != nil {
+			tx.Rollback()
+			var promoErr *services.PromoError
+			if errors.As(err, &promoErr) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": promoErr.Message})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to apply promo code"})
+			return
+		}
+	}
+
+	// Food tax inside tax-inclusive prices is already part of the subtotal
+	total := models.RoundAmount(subtotal + deliveryFee + serviceFee + orderTax.Payable() + tip - discount)
+
 	// Generate order number
 	orderNumber := generateOrderNumber()
 
@@ -158,11 +280,21 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 		Subtotal:                  subtotal,
 		DeliveryFee:               deliveryFee,
 		ServiceFee:                serviceFee,
-		Tax:                       tax,
+		Tax:                       orderTax.Total(),
+		FoodTax:                   orderTax.FoodTax,
+		ServiceTax:                orderTax.ServiceTax,
+		DeliveryTax:               orderTax.DeliveryTax,
+		TaxCountryCode:            orderTax.CountryCode,
+		TaxName:                   orderTax.TaxName,
+		FoodTaxPercent:            orderTax.FoodPercent,
+		ServiceTaxPercent:         orderTax.ServicePercent,
+		DeliveryTaxPercent:        orderTax.DeliveryPercent,
+		PricesIncludeTax:          orderTax.PricesIncludeTax,
 		Tip:                       tip,
 		Discount:                  discount,
 		Total:                     total,
-		PromoCode:                 req.PromoCode,
+		PromoCode:                 services.NormalizePromoCode(req.PromoCode),
+		PaymentMethodID:           req.PaymentMethodID,
 		DeliveryAddressLine1:      deliveryAddr.Line1,
 		DeliveryAddressLine2:      deliveryAddr.Line2,
 		DeliveryAddressCity:       deliveryAddr.City,
@@ -173,11 +305,13 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 		DeliveryInstructions:      req.DeliveryInstructions,
 		SpecialInstructions:       req.SpecialInstructions,
 		ScheduledFor:              req.ScheduledFor,
-		EstimatedPrepTime:         30, // Default, could be calculated
+		EstimatedPrepTime:         services.ChefPrepMinutes(&chef),
 	}
 
-	// Start transaction
-	tx := database.DB.Begin()
+	if promo != nil {
+		order.PromoCodeID = &promo.ID
+		order.DiscountFundedBy = string(promo.FundedBy)
+	}
 
 	if err := tx.Create(&order).Error; err != nil {
 		tx.Rollback()
@@ -196,7 +330,25 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 		return
 	}
 
+	if err := services.RecordOrderEvent(tx, order.ID, models.OrderEventCreated, "", string(order.Status),
+		services.ByUser(services.ActorCustomer, userID)); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create order"})
+		return
+	}
+
+	// Record the promo redemption while the code row is still locked
+	if promo != nil {
+		if err := services.RecordPromoRedemption(tx, promo, userID, order.ID, discount); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to apply promo code"})
+			return
+		}
+	}
+
 	// Clear user's cart for this chef
+	tx.Where("cart_id IN (?)", tx.Model(&models.Cart{}).Select("id").
+		Where("user_id = ? AND chef_id = ?", userID, chef.ID)).Delete(&models.CartItem{})
 	tx.Where("user_id = ? AND chef_id = ?", userID, chef.ID).Delete(&models.Cart{})
 
 	tx.Commit()
@@ -228,6 +380,89 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 	c.JSON(http.StatusCreated, order.ToResponse())
 }
 
+// QuoteDeliveryFee prices delivery from a chef to a dropoff before the order is
+// placed. The dropoff is either lat/lng or, for signed-in users, a saved addressId.
+// GET /chefs/:id/delivery-quote
+func (h *OrderHandler) QuoteDeliveryFee(c *gin.Context) {
+	var chef models.ChefProfile
+	if err := database.DB.Where("id = ? AND is_active = ?", c.Param("id"), true).First(&chef).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Chef not found"})
+		return
+	}
+
+	var lat, lng float64
+	if addressID := c.Query("addressId"); addressID != "" {
+		userID, ok := middleware.GetUserID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Sign in to use a saved address"})
+			return
+		}
+		var addr models.Address
+		if err := database.DB.Where("id = ? AND user_id = ?", addressID, userID).First(&addr).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Address not found"})
+			return
+		}
+		lat, lng = addr.Latitude, addr.Longitude
+	} else {
+		var err error
+		if lat, err = strconv.ParseFloat(c.Query("lat"), 64); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "lat and lng or addressId are required"})
+			return
+		}
+		if lng, err = strconv.ParseFloat(c.Query("lng"), 64); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "lat and lng or addressId are required"})
+			return
+		}
+	}
+
+	quote, err := services.QuoteDeliveryFee(&chef, lat, lng)
+	if err != nil {
+		var zoneErr *services.ZoneError
+		if errors.As(err, &zoneErr) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": zoneErr.Message})
+			return
+		}
+		log.Printf("Failed to quote delivery fee: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate delivery fee"})
+		return
+	}
+
+	c.JSON(http.StatusOK, quote)
+}
+
+// GetDeliverySlots lists a chef's bookable delivery windows for scheduled
+// orders over the next few days (default 3, at most 14).
+// GET /chefs/:id/slots
+func (h *OrderHandler) GetDeliverySlots(c *gin.Context) {
+	var chef models.ChefProfile
+	if err := database.DB.Where("id = ? AND is_active = ?", c.Param("id"), true).First(&chef).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Chef not found"})
+		return
+	}
+
+	days, _ := strconv.Atoi(c.DefaultQuery("days", "3"))
+	if days < 1 || days > services.MaxSlotDays {
+		days = 3
+	}
+
+	now := time.Now()
+	slotCfg := services.LoadSlotSettings(database.DB, &chef)
+	slots, err := services.GenerateDeliverySlots(database.DB, &chef, slotCfg, now, now, days)
+	if err != nil {
+		log.Printf("Failed to generate delivery slots: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load delivery slots"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"slots":           slots,
+		"slotMinutes":     slotCfg.SlotMinutes,
+		"leadMinutes":     slotCfg.LeadMinutes,
+		"prepMinutes":     slotCfg.PrepMinutes,
+		"acceptingOrders": chef.AcceptingOrders,
+	})
+}
+
 // GetOrders returns the user's orders
 func (h *OrderHandler) GetOrders(c *gin.Context) {
 	userID, _ := middleware.GetUserID(c)
@@ -288,6 +523,65 @@ func (h *OrderHandler) GetOrder(c *gin.Context) {
 	c.JSON(http.StatusOK, order.ToResponse())
 }
 
+// RespondToFailedDelivery lets a customer choose what happens after a failed
+// delivery attempt: another attempt, optionally with new instructions for the
+// driver, or sending the order back.
+// POST /orders/:id/delivery-failure
+func (h *OrderHandler) RespondToFailedDelivery(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+	orderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	var req struct {
+		Action               string `json:"action" binding:"required,oneof=retry return"`
+		DeliveryInstructions string `json:"deliveryInstructions"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var order models.Order
+	if err := database.DB.Where("id = ? AND customer_id = ?", orderID, userID).First(&order).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return
+	}
+
+	if req.Action == services.FailureOptionRetry {
+		delivery, err := services.RetryFailedDelivery(userID, orderID, req.DeliveryInstructions)
+		if err != nil {
+			respondDeliveryFailureError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, delivery.ToResponse())
+		return
+	}
+
+	if err := services.ReturnFailedDelivery(userID, orderID); err != nil {
+		respondDeliveryFailureError(c, err)
+		return
+	}
+	if err := database.DB.First(&order, "id = ?", orderID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch order"})
+		return
+	}
+	c.JSON(http.StatusOK, order.ToResponse())
+}
+
+// respondDeliveryFailureError writes the response for a refused failed-delivery choice
+func respondDeliveryFailureError(c *gin.Context, err error) {
+	var failureErr *services.DeliveryFailureError
+	if errors.As(err, &failureErr) {
+		c.JSON(http.StatusConflict, gin.H{"error": failureErr.Message})
+		return
+	}
+	log.Printf("Failed to handle failed delivery choice: %v", err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update delivery"})
+}
+
 // CancelOrder cancels an order
 func (h *OrderHandler) CancelOrder(c *gin.Context) {
 	userID, _ := middleware.GetUserID(c)
@@ -300,24 +594,15 @@ func (h *OrderHandler) CancelOrder(c *gin.Context) {
 		return
 	}
 
-	// Check if order can be cancelled
-	if order.Status != models.OrderStatusPending && order.Status != models.OrderStatusAccepted {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Order cannot be cancelled at this stage"})
-		return
-	}
-
 	var req struct {
 		Reason string `json:"reason"`
 	}
 	c.ShouldBindJSON(&req)
 
-	now := time.Now()
-	order.Status = models.OrderStatusCancelled
-	order.CancelledAt = &now
 	order.CancelReason = req.Reason
-
-	if err := database.DB.Save(&order).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel order"})
+	if err := services.UpdateOrderStatus(database.DB, &order, models.OrderStatusCancelled, services.ByUser(services.ActorCustomer, userID).WithNotes(req.Reason),
+		map[string]interface{}{"cancel_reason": req.Reason}); err != nil {
+		respondOrderStatusError(c, err)
 		return
 	}
 
@@ -352,6 +637,107 @@ func (h *OrderHandler) TrackOrder(c *gin.Context) {
 		return
 	}
 
+	c.JSON(http.StatusOK, trackingSnapshot(&order))
+}
+
+// StreamOrderTracking pushes live tracking to the customer as server-sent
+// events: a "snapshot" event on connect and whenever the order or its delivery
+// changes, and "location" events with the driver's position at most every few
+// seconds. The stream ends once the order reaches a final status.
+// GET /orders/:id/stream
+func (h *OrderHandler) StreamOrderTracking(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var order models.Order
+	if err := database.DB.Where("id = ? AND customer_id = ?", c.Param("id"), userID).
+		First(&order).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return
+	}
+
+	tracker := services.GetOrderTracker()
+	if !tracker.IsRunning() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Live tracking is unavailable, use /track instead"})
+		return
+	}
+
+	// The server's WriteTimeout would cut the stream off; lift it for this
+	// response only
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		log.Printf("Failed to clear write deadline for tracking stream: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Live tracking is unavailable, use /track instead"})
+		return
+	}
+
+	updates, stop := tracker.Watch(order.ID)
+	defer stop()
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	loadSnapshot := func() (gin.H, bool) {
+		var current models.Order
+		if err := database.DB.Preload("Delivery").Preload("Chef").First(&current, "id = ?", order.ID).Error; err != nil {
+			return nil, true
+		}
+		return trackingSnapshot(&current), isFinalOrderStatus(current.Status)
+	}
+
+	snapshot, done := loadSnapshot()
+	c.SSEvent("snapshot", snapshot)
+	c.Writer.Flush()
+	if done {
+		return
+	}
+
+	heartbeat := time.NewTicker(services.TrackingHeartbeatInterval)
+	defer heartbeat.Stop()
+	locationTick := time.NewTicker(services.TrackingLocationInterval)
+	defer locationTick.Stop()
+
+	var lastLocation time.Time
+	var pendingLocation *services.TrackingUpdate
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case update, ok := <-updates:
+			if !ok {
+				return false
+			}
+			if update.Kind == services.TrackingUpdateLocation {
+				// Throttle coordinates; keep only the newest between sends
+				if time.Since(lastLocation) < services.TrackingLocationInterval {
+					pendingLocation = &update
+					return true
+				}
+				lastLocation = time.Now()
+				pendingLocation = nil
+				c.SSEvent("location", update)
+				return true
+			}
+			snapshot, done := loadSnapshot()
+			c.SSEvent("snapshot", snapshot)
+			return !done
+		case <-locationTick.C:
+			if pendingLocation != nil {
+				lastLocation = time.Now()
+				c.SSEvent("location", pendingLocation)
+				pendingLocation = nil
+			}
+			return true
+		case <-heartbeat.C:
+			c.SSEvent("ping", gin.H{"timestamp": time.Now().UTC()})
+			return true
+		}
+	})
+}
+
+// trackingSnapshot is the customer's view of an order in progress. The order
+// must be loaded with Delivery and Chef.
+func trackingSnapshot(order *models.Order) gin.H {
 	response := gin.H{
 		"orderId":     order.ID,
 		"orderNumber": order.OrderNumber,
@@ -364,15 +750,159 @@ func (h *OrderHandler) TrackOrder(c *gin.Context) {
 		"createdAt":             order.CreatedAt,
 		"acceptedAt":            order.AcceptedAt,
 		"preparedAt":            order.PreparedAt,
+		"driverAssignedAt":      order.DriverAssignedAt,
 		"pickedUpAt":            order.PickedUpAt,
 		"deliveredAt":           order.DeliveredAt,
 	}
 
+	var events []models.OrderEventLog
+	database.DB.Where("order_id = ? AND event_type IN ?", order.ID,
+		[]models.OrderEventType{models.OrderEventCreated, models.OrderEventStatus}).
+		Order("created_at").Find(&events)
+	response["steps"] = buildTrackingSteps(order, events)
+
 	if order.Delivery != nil {
 		response["delivery"] = order.Delivery.ToResponse()
+
+		// Driver position and the handover code are only shared while the
+		// delivery is in progress
+		for _, s := range services.ActiveDeliveryStatuses {
+			if order.Delivery.Status != s {
+				continue
+			}
+			if order.Delivery.HandoverCode != "" {
+				response["handoverCode"] = order.Delivery.HandoverCode
+			}
+			var partner models.DeliveryPartner
+			if err := database.DB.Select("current_latitude", "current_longitude").
+				First(&partner, "id = ?", order.Delivery.DeliveryPartnerID).Error; err == nil &&
+				(partner.CurrentLatitude != 0 || partner.CurrentLongitude != 0) {
+				response["driverLocation"] = gin.H{
+					"latitude":  partner.CurrentLatitude,
+					"longitude": partner.CurrentLongitude,
+				}
+			}
+			break
+		}
+	}
+
+	return response
+}
+
+// isFinalOrderStatus reports whether an order can no longer change in a way
+// a tracking customer cares about
+func isFinalOrderStatus(status models.OrderStatus) bool {
+	switch status {
+	case models.OrderStatusDelivered, models.OrderStatusCancelled, models.OrderStatusRefunded:
+		return true
 	}
+	return false
+}
 
-	c.JSON(http.StatusOK, response)
+// GetOrderTimeline returns the order's event log with internal details removed.
+// GET /orders/:id/timeline
+func (h *OrderHandler) GetOrderTimeline(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var order models.Order
+	if err := database.DB.Where("id = ? AND customer_id = ?", c.Param("id"), userID).
+		First(&order).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return
+	}
+
+	var events []models.OrderEventLog
+	database.DB.Where("order_id = ?", order.ID).Order("created_at").Find(&events)
+
+	timeline := make([]models.OrderTimelineEntry, len(events))
+	for i := range events {
+		timeline[i] = events[i].ToTimelineEntry()
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"orderId":     order.ID,
+		"orderNumber": order.OrderNumber,
+		"status":      order.Status,
+		"data":        timeline,
+	})
+}
+
+// trackingSteps is the happy path shown to customers while tracking an order
+var trackingSteps = []struct {
+	status models.OrderStatus
+	label  string
+}{
+	{models.OrderStatusPending, "Order placed"},
+	{models.OrderStatusAccepted, "Accepted by chef"},
+	{models.OrderStatusPreparing, "Preparing"},
+	{models.OrderStatusReady, "Ready for pickup"},
+	{models.OrderStatusDriverAssigned, "Driver on the way to the kitchen"},
+	{models.OrderStatusPickedUp, "Picked up"},
+	{models.OrderStatusDelivering, "On the way"},
+	{models.OrderStatusDelivered, "Delivered"},
+}
+
+// buildTrackingSteps turns the order's status events into tracking steps. Orders
+// placed before the event log existed fall back to the order timestamps.
+func buildTrackingSteps(order *models.Order, events []models.OrderEventLog) []gin.H {
+	reachedAt := make(map[models.OrderStatus]time.Time)
+	for _, e := range events {
+		reachedAt[models.OrderStatus(e.NewValue)] = e.CreatedAt
+	}
+	fallback := map[models.OrderStatus]*time.Time{
+		models.OrderStatusPending:        &order.CreatedAt,
+		models.OrderStatusAccepted:       order.AcceptedAt,
+		models.OrderStatusReady:          order.PreparedAt,
+		models.OrderStatusDriverAssigned: order.DriverAssignedAt,
+		models.OrderStatusPickedUp:       order.PickedUpAt,
+		models.OrderStatusDelivered:      order.DeliveredAt,
+		models.OrderStatusCancelled:      order.CancelledAt,
+		models.OrderStatusRefunded:       order.RefundedAt,
+	}
+	for status, at := range fallback {
+		if _, ok := reachedAt[status]; !ok && at != nil {
+			reachedAt[status] = *at
+		}
+	}
+
+	current := -1
+	for i, step := range trackingSteps {
+		if step.status == order.Status {
+			current = i
+		}
+	}
+
+	steps := make([]gin.H, 0, len(trackingSteps)+1)
+	for i, step := range trackingSteps {
+		at, reached := reachedAt[step.status]
+		completed := i <= current || (current == -1 && reached)
+		entry := gin.H{
+			"status":    step.status,
+			"label":     step.label,
+			"completed": completed,
+			"current":   i == current,
+		}
+		if completed && reached {
+			entry["at"] = at
+		}
+		steps = append(steps, entry)
+	}
+
+	// Cancelled and refunded orders end on a terminal step
+	switch order.Status {
+	case models.OrderStatusCancelled, models.OrderStatusRefunded:
+		label := "Cancelled"
+		if order.Status == models.OrderStatusRefunded {
+			label = "Refunded"
+		}
+		entry := gin.H{"status": order.Status, "label": label, "completed": true, "current": true}
+		if at, ok := reachedAt[order.Status]; ok {
+			entry["at"] = at
+		}
+		steps = append(steps, entry)
+	}
+
+	return steps
 }
 
 // GetOrderInvoice returns the invoice for an order, generating it on the fly if needed
@@ -403,6 +933,26 @@ func (h *OrderHandler) GetOrderInvoice(c *gin.Context) {
 	c.JSON(http.StatusOK, invoice.ToResponse())
 }
 
+// respondOrderStatusError writes the response for a failed services.UpdateOrderStatus
+func respondOrderStatusError(c *gin.Context, err error) {
+	var transitionErr *services.OrderTransitionError
+	if errors.As(err, &transitionErr) {
+		status := http.StatusConflict
+		if transitionErr.Code == services.ErrCodeInvalidOrderStatus {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{
+			"error":           transitionErr.Error(),
+			"code":            transitionErr.Code,
+			"currentStatus":   transitionErr.From,
+			"allowedStatuses": transitionErr.Allowed,
+		})
+		return
+	}
+	log.Printf("Failed to update order status: %v", err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update order"})
+}
+
 // Helper to generate order number
 func generateOrderNumber() string {
 	timestamp := time.Now().Format("0601021504")
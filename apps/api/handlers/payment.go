@@ -26,7 +26,7 @@ func NewPaymentHandler() *PaymentHandler {
 //
 // Payment flow:
 //   Customer pays total → Razorpay splits automatically:
-//     - Chef gets: Subtotal + ChefTip (food cost + chef tip)
+//     - Chef gets: Subtotal + food tax (if charged on top) + ChefTip
 //     - Driver gets: DeliveryFee + DriverTip (delivery fee + driver tip)
 //     - Fe3dr gets: ₹0 from orders (revenue comes only from subscriptions)
 //
@@ -45,8 +45,14 @@ func (h *PaymentHandler) CreateOrderPayment(c *gin.Context) {
 		return
 	}
 
+	// Optional saved payment method for one-tap checkout
+	var req struct {
+		PaymentMethodID *uuid.UUID `json:"paymentMethodId"`
+	}
+	c.ShouldBindJSON(&req)
+
 	var order models.Order
-	if err := database.DB.Preload("Chef").Preload("Delivery.DeliveryPartner").
+	if err := database.DB.Preload("Customer").Preload("Chef").Preload("Delivery.DeliveryPartner").
 		Where("id = ? AND customer_id = ?", orderID, userID).First(&order).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
 		return
@@ -57,12 +63,27 @@ func (h *PaymentHandler) CreateOrderPayment(c *gin.Context) {
 		return
 	}
 
+	// Fall back to the method chosen when the order was placed
+	if req.PaymentMethodID == nil {
+		req.PaymentMethodID = order.PaymentMethodID
+	}
+	var savedMethod *models.PaymentMethod
+	if req.PaymentMethodID != nil {
+		method, errMsg := loadCheckoutPaymentMethod(userID, *req.PaymentMethodID)
+		if method == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": errMsg})
+			return
+		}
+		savedMethod = method
+	}
+
 	// Build Route transfer splits
 	totalPaise := services.ToPaise(order.Total)
 	transfers := []services.TransferSpec{}
 
-	// Chef transfer: subtotal + tax + chef tip (food amount goes directly to chef)
-	chefAmount := order.Subtotal + order.Tax + order.ChefTip
+	// Chef transfer: subtotal + tax + chef tip (food amount goes directly to chef),
+	// less any promo discount the chef funds
+	chefAmount := order.Subtotal + order.ChefTaxShare() + order.ChefTip - order.ChefFundedDiscount()
 	if order.Chef.RazorpayAccountID != "" && chefAmount > 0 {
 		transfers = append(transfers, services.TransferSpec{
 			Account:  order.Chef.RazorpayAccountID,
@@ -112,9 +133,13 @@ func (h *PaymentHandler) CreateOrderPayment(c *gin.Context) {
 	}
 
 	// Save Razorpay order ID
-	database.DB.Model(&order).Update("razorpay_order_id", rzOrder.ID)
+	orderUpdates := map[string]interface{}{"razorpay_order_id": rzOrder.ID}
+	if savedMethod != nil {
+		orderUpdates["payment_method_id"] = savedMethod.ID
+	}
+	database.DB.Model(&order).Updates(orderUpdates)
 
-	c.JSON(http.StatusOK, gin.H{
+	response := gin.H{
 		"razorpayOrderId": rzOrder.ID,
 		"razorpayKeyId":   services.GetRazorpay().GetKeyID(),
 		"amount":          totalPaise,
@@ -125,13 +150,33 @@ func (h *PaymentHandler) CreateOrderPayment(c *gin.Context) {
 			"email": order.Customer.Email,
 			"phone": order.Customer.Phone,
 		},
-	})
+	}
+
+	// One-tap checkout: hand Checkout the saved customer/token so the customer
+	// only confirms (CVV or UPI PIN) instead of re-entering details
+	if savedMethod != nil {
+		saved := gin.H{
+			"paymentMethodId": savedMethod.ID,
+			"customerId":      savedMethod.GatewayCustomerID,
+			"method":          savedMethod.Type,
+		}
+		if savedMethod.GatewayTokenID != "" {
+			saved["tokenId"] = savedMethod.GatewayTokenID
+		}
+		if savedMethod.Type == models.PaymentMethodTypeUPI {
+			response["prefill"].(gin.H)["vpa"] = savedMethod.VPA
+		}
+		response["savedMethod"] = saved
+	}
+
+	c.JSON(http.StatusOK, response)
 }
 
 // VerifyPayment verifies a payment after Razorpay checkout on the client.
 // Called by the frontend after successful Razorpay checkout.
 // POST /payments/order/:orderId/verify
 func (h *PaymentHandler) VerifyPayment(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
 	orderID, err := uuid.Parse(c.Param("orderId"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
@@ -180,6 +225,10 @@ func (h *PaymentHandler) VerifyPayment(c *gin.Context) {
 		"payment_method":     payment.Method,
 		"razorpay_payment_id": req.RazorpayPaymentID,
 	})
+	if order.PaymentStatus != models.PaymentCompleted {
+		services.LogOrderEvent(database.DB, order.ID, models.OrderEventPayment, string(order.PaymentStatus),
+			string(models.PaymentCompleted), services.ByUser(services.ActorCustomer, userID).WithNotes(payment.Method))
+	}
 
 	// Publish event
 	if err := services.PublishEvent("orders.paid", "order.paid", order.CustomerID, map[string]interface{}{
@@ -253,6 +302,15 @@ func (h *PaymentHandler) InitiateRefund(c *gin.Context) {
 		return
 	}
 
+	refundActor := services.ActorChef
+	if initiatedBy == "admin" {
+		refundActor = services.ActorAdmin
+	}
+	if err := services.CheckOrderTransition(order.Status, models.OrderStatusRefunded, refundActor); err != nil {
+		respondOrderStatusError(c, err)
+		return
+	}
+
 	if order.RazorpayPaymentID == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "No payment found for this order"})
 		return
@@ -292,16 +350,29 @@ func (h *PaymentHandler) InitiateRefund(c *gin.Context) {
 		return
 	}
 
-	now := time.Now()
-	database.DB.Model(&order).Updates(map[string]interface{}{
-		"payment_status":    models.PaymentRefunded,
-		"status":            models.OrderStatusRefunded,
-		"refund_id":         rzRefund.ID,
-		"refund_amount":     refundAmount,
-		"refund_reason":     req.Reason,
+	if err := services.UpdateOrderStatus(database.DB, &order, models.OrderStatusRefunded, services.ByUser(refundActor, userID).WithNotes(req.Reason), map[string]interface{}{
+		"payment_status":      models.PaymentRefunded,
+		"refund_id":           rzRefund.ID,
+		"refund_amount":       refundAmount,
+		"refund_reason":       req.Reason,
 		"refund_initiated_by": initiatedBy,
-		"refunded_at":       &now,
-	})
+	}); err != nil {
+		// The gateway refund already went through, so record it regardless
+		log.Printf("Failed to move order %s to refunded: %v", order.OrderNumber, err)
+		now := time.Now()
+		database.DB.Model(&order).Updates(map[string]interface{}{
+			"payment_status":      models.PaymentRefunded,
+			"status":              models.OrderStatusRefunded,
+			"refund_id":           rzRefund.ID,
+			"refund_amount":       refundAmount,
+			"refund_reason":       req.Reason,
+			"refund_initiated_by": initiatedBy,
+			"refunded_at":         &now,
+		})
+	}
+
+	services.LogOrderEvent(database.DB, order.ID, models.OrderEventPayment, string(models.PaymentCompleted),
+		string(models.PaymentRefunded), services.ByUser(refundActor, userID).WithNotes(fmt.Sprintf("%.2f refunded", refundAmount)))
 
 	// Publish event
 	if err := services.PublishEvent("orders.refunded", "order.refunded", userID, map[string]interface{}{
@@ -384,14 +455,20 @@ func (h *PaymentHandler) handlePaymentCaptured(payload json.RawMessage) {
 	payment := data.Payment.Entity
 	log.Printf("Payment captured: %s (order: %s, amount: %d)", payment.ID, payment.OrderID, payment.Amount)
 
-	// Update order
-	database.DB.Model(&models.Order{}).
-		Where("razorpay_order_id = ?", payment.OrderID).
-		Updates(map[string]interface{}{
-			"payment_status":      models.PaymentCompleted,
-			"payment_method":      payment.Method,
-			"razorpay_payment_id": payment.ID,
-		})
+	// Update order (the Razorpay order may belong to a promotion instead)
+	var order models.Order
+	if err := database.DB.Where("razorpay_order_id = ?", payment.OrderID).First(&order).Error; err != nil {
+		return
+	}
+	database.DB.Model(&order).Updates(map[string]interface{}{
+		"payment_status":      models.PaymentCompleted,
+		"payment_method":      payment.Method,
+		"razorpay_payment_id": payment.ID,
+	})
+	if order.PaymentStatus != models.PaymentCompleted {
+		services.LogOrderEvent(database.DB, order.ID, models.OrderEventPayment, string(order.PaymentStatus),
+			string(models.PaymentCompleted), services.BySystem(models.OrderEventSourceWebhook).WithNotes("payment.captured"))
+	}
 }
 
 func (h *PaymentHandler) handlePaymentFailed(payload json.RawMessage) {
@@ -408,9 +485,15 @@ func (h *PaymentHandler) handlePaymentFailed(payload json.RawMessage) {
 	payment := data.Payment.Entity
 	log.Printf("Payment failed: %s (order: %s)", payment.ID, payment.OrderID)
 
-	database.DB.Model(&models.Order{}).
-		Where("razorpay_order_id = ?", payment.OrderID).
-		Update("payment_status", models.PaymentFailed)
+	var order models.Order
+	if err := database.DB.Where("razorpay_order_id = ?", payment.OrderID).First(&order).Error; err != nil {
+		return
+	}
+	database.DB.Model(&order).Update("payment_status", models.PaymentFailed)
+	if order.PaymentStatus != models.PaymentFailed {
+		services.LogOrderEvent(database.DB, order.ID, models.OrderEventPayment, string(order.PaymentStatus),
+			string(models.PaymentFailed), services.BySystem(models.OrderEventSourceWebhook).WithNotes("payment.failed"))
+	}
 }
 
 func (h *PaymentHandler) handleRefundProcessed(payload json.RawMessage) {
@@ -428,13 +511,17 @@ func (h *PaymentHandler) handleRefundProcessed(payload json.RawMessage) {
 	log.Printf("Refund processed: %s (payment: %s, amount: %d)", refund.ID, refund.PaymentID, refund.Amount)
 
 	// Update refund status
+	var order models.Order
+	if err := database.DB.Where("razorpay_payment_id = ?", refund.PaymentID).First(&order).Error; err != nil {
+		return
+	}
 	now := time.Now()
-	database.DB.Model(&models.Order{}).
-		Where("razorpay_payment_id = ?", refund.PaymentID).
-		Updates(map[string]interface{}{
-			"refund_id":   refund.ID,
-			"refunded_at": &now,
-		})
+	database.DB.Model(&order).Updates(map[string]interface{}{
+		"refund_id":   refund.ID,
+		"refunded_at": &now,
+	})
+	services.LogOrderEvent(database.DB, order.ID, models.OrderEventPayment, string(order.PaymentStatus),
+		"refund_processed", services.BySystem(models.OrderEventSourceWebhook).WithNotes(refund.ID))
 }
 
 func (h *PaymentHandler) handleSubscriptionCharged(payload json.RawMessage) {
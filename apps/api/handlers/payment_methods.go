@@ -0,0 +1,335 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/homechef/api/database"
+	"github.com/homechef/api/middleware"
+	"github.com/homechef/api/models"
+	"github.com/homechef/api/services"
+)
+
+const maxSavedPaymentMethods = 10
+
+var vpaRegex = regexp.MustCompile(`^[a-zA-Z0-9.\-_]{2,256}@[a-zA-Z]{2,64}$`)
+
+// AddPaymentMethodRequest saves a method the client has already tokenized with
+// the gateway (e.g. Razorpay Checkout with save=1, or a Stripe PaymentMethod).
+type AddPaymentMethodRequest struct {
+	Gateway           string `json:"gateway" binding:"required,oneof=razorpay stripe"`
+	Type              string `json:"type" binding:"required,oneof=card upi"`
+	GatewayCustomerID string `json:"gatewayCustomerId"` // Stripe only; Razorpay is resolved server-side
+	GatewayTokenID    string `json:"gatewayTokenId"`
+	VPA               string `json:"vpa"`
+	Last4             string `json:"last4"`
+	Brand             string `json:"brand"`
+	ExpMonth          int    `json:"expMonth"`
+	ExpYear           int    `json:"expYear"`
+	Nickname          string `json:"nickname"`
+	SetDefault        bool   `json:"setDefault"`
+}
+
+// GetPaymentMethods lists the customer's saved payment methods, default first.
+// GET /payment-methods
+func (h *PaymentHandler) GetPaymentMethods(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var methods []models.PaymentMethod
+	if err := database.DB.Where("user_id = ?", userID).
+		Order("is_default DESC, created_at DESC").
+		Find(&methods).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch payment methods"})
+		return
+	}
+
+	responses := make([]models.PaymentMethodResponse, len(methods))
+	for i := range methods {
+		responses[i] = methods[i].ToResponse()
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": responses})
+}
+
+// GetGatewayCustomer returns (creating if needed) the customer's Razorpay
+// customer ID, which the client passes to Checkout to save a card or UPI token.
+// POST /payment-methods/gateway-customer
+func (h *PaymentHandler) GetGatewayCustomer(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	customerID, err := ensureRazorpayCustomer(userID)
+	if err != nil {
+		log.Printf("Failed to resolve Razorpay customer for user %s: %v", userID, err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Payment gateway not available"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"gateway":    models.PaymentGatewayRazorpay,
+		"customerId": customerID,
+	})
+}
+
+// AddPaymentMethod saves a tokenized card or UPI handle.
+// POST /payment-methods
+func (h *PaymentHandler) AddPaymentMethod(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var req AddPaymentMethodRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var count int64
+	database.DB.Model(&models.PaymentMethod{}).Where("user_id = ?", userID).Count(&count)
+	if count >= maxSavedPaymentMethods {
+		c.JSON(http.StatusConflict, gin.H{"error": "Maximum saved payment methods reached", "max": maxSavedPaymentMethods})
+		return
+	}
+
+	method := models.PaymentMethod{
+		UserID:         userID,
+		Gateway:        req.Gateway,
+		Type:           req.Type,
+		GatewayTokenID: strings.TrimSpace(req.GatewayTokenID),
+		Last4:          req.Last4,
+		Brand:          req.Brand,
+		ExpMonth:       req.ExpMonth,
+		ExpYear:        req.ExpYear,
+		Nickname:       req.Nickname,
+	}
+
+	switch req.Gateway {
+	case models.PaymentGatewayRazorpay:
+		if req.Type == models.PaymentMethodTypeCard && method.GatewayTokenID == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "gatewayTokenId is required for cards"})
+			return
+		}
+
+		// Always resolve the customer server-side so a token can only be saved
+		// from the caller's own Razorpay customer
+		customerID, err := ensureRazorpayCustomer(userID)
+		if err != nil {
+			log.Printf("Failed to resolve Razorpay customer for user %s: %v", userID, err)
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Payment gateway not available"})
+			return
+		}
+		method.GatewayCustomerID = customerID
+
+		// Trust the gateway's view of the token over client-supplied display details
+		if method.GatewayTokenID != "" {
+			rz := services.GetRazorpay()
+			if rz == nil {
+				c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Payment gateway not configured"})
+				return
+			}
+			token, err := rz.FetchToken(customerID, method.GatewayTokenID)
+			if err != nil {
+				log.Printf("Failed to fetch Razorpay token %s: %v", method.GatewayTokenID, err)
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Payment token not found"})
+				return
+			}
+			if token.Method != req.Type {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Payment token does not match the method type"})
+				return
+			}
+			if token.ExpiredAt > 0 && time.Unix(token.ExpiredAt, 0).Before(time.Now()) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Payment token has expired"})
+				return
+			}
+			if token.Card != nil {
+				method.Last4 = token.Card.Last4
+				method.Brand = token.Card.Network
+				method.ExpMonth = token.Card.ExpiryMonth
+				method.ExpYear = token.Card.ExpiryYear
+			}
+			if token.VPA != nil && token.VPA.Username != "" {
+				method.VPA = token.VPA.Username + "@" + token.VPA.Handle
+			}
+		}
+
+	case models.PaymentGatewayStripe:
+		if !strings.HasPrefix(method.GatewayTokenID, "pm_") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "A Stripe PaymentMethod ID (pm_...) is required"})
+			return
+		}
+		method.GatewayCustomerID = strings.TrimSpace(req.GatewayCustomerID)
+		method.StripePaymentID = method.GatewayTokenID
+	}
+
+	if req.Type == models.PaymentMethodTypeUPI && method.VPA == "" {
+		method.VPA = strings.ToLower(strings.TrimSpace(req.VPA))
+	}
+	if req.Type == models.PaymentMethodTypeUPI {
+		if !vpaRegex.MatchString(method.VPA) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "A valid UPI ID (name@bank) is required"})
+			return
+		}
+		method.Last4, method.ExpMonth, method.ExpYear = "", 0, 0
+	}
+
+	if method.IsExpired(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Card has expired"})
+		return
+	}
+
+	// Reject duplicates of the same token or UPI handle
+	dup := database.DB.Model(&models.PaymentMethod{}).Where("user_id = ? AND gateway = ?", userID, method.Gateway)
+	if method.GatewayTokenID != "" {
+		dup = dup.Where("gateway_token_id = ?", method.GatewayTokenID)
+	} else {
+		dup = dup.Where("type = ? AND vpa = ?", models.PaymentMethodTypeUPI, method.VPA)
+	}
+	var dupCount int64
+	dup.Count(&dupCount)
+	if dupCount > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "This payment method is already saved"})
+		return
+	}
+
+	method.IsDefault = req.SetDefault || count == 0
+
+	tx := database.DB.Begin()
+	if method.IsDefault {
+		if err := tx.Model(&models.PaymentMethod{}).Where("user_id = ?", userID).
+			Update("is_default", false).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save payment method"})
+			return
+		}
+	}
+	if err := tx.Create(&method).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save payment method"})
+		return
+	}
+	tx.Commit()
+
+	c.JSON(http.StatusCreated, method.ToResponse())
+}
+
+// RemovePaymentMethod deletes a saved method and its gateway token. If it was
+// the default, the most recently added remaining method becomes the default.
+// DELETE /payment-methods/:id
+func (h *PaymentHandler) RemovePaymentMethod(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var method models.PaymentMethod
+	if err := database.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&method).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Payment method not found"})
+		return
+	}
+
+	tx := database.DB.Begin()
+	if err := tx.Delete(&method).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove payment method"})
+		return
+	}
+	if method.IsDefault {
+		var next models.PaymentMethod
+		if err := tx.Where("user_id = ?", userID).Order("created_at DESC").First(&next).Error; err == nil {
+			tx.Model(&next).Update("is_default", true)
+		}
+	}
+	tx.Commit()
+
+	// Revoke the token at the gateway; the local record is already gone either way
+	if method.Gateway == models.PaymentGatewayRazorpay && method.GatewayTokenID != "" {
+		go func() {
+			if rz := services.GetRazorpay(); rz != nil {
+				if err := rz.DeleteToken(method.GatewayCustomerID, method.GatewayTokenID); err != nil {
+					log.Printf("Failed to delete Razorpay token %s: %v", method.GatewayTokenID, err)
+				}
+			}
+		}()
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Payment method removed"})
+}
+
+// SetDefaultPaymentMethod makes a saved method the default.
+// PUT /payment-methods/:id/default
+func (h *PaymentHandler) SetDefaultPaymentMethod(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var method models.PaymentMethod
+	if err := database.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&method).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Payment method not found"})
+		return
+	}
+
+	if method.IsExpired(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Card has expired"})
+		return
+	}
+
+	tx := database.DB.Begin()
+	if err := tx.Model(&models.PaymentMethod{}).Where("user_id = ? AND id <> ?", userID, method.ID).
+		Update("is_default", false).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update default payment method"})
+		return
+	}
+	if err := tx.Model(&method).Update("is_default", true).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update default payment method"})
+		return
+	}
+	tx.Commit()
+
+	c.JSON(http.StatusOK, method.ToResponse())
+}
+
+// ensureRazorpayCustomer returns the user's Razorpay customer ID, reusing one
+// from an existing saved method or creating it (Razorpay dedupes by contact).
+func ensureRazorpayCustomer(userID uuid.UUID) (string, error) {
+	var existing models.PaymentMethod
+	if err := database.DB.Where("user_id = ? AND gateway = ? AND gateway_customer_id <> ''",
+		userID, models.PaymentGatewayRazorpay).First(&existing).Error; err == nil {
+		return existing.GatewayCustomerID, nil
+	}
+
+	rz := services.GetRazorpay()
+	if rz == nil {
+		return "", fmt.Errorf("razorpay client not configured")
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, "id = ?", userID).Error; err != nil {
+		return "", err
+	}
+
+	customer, err := rz.CreateCustomer(&services.CustomerRequest{
+		Name:    strings.TrimSpace(user.FirstName + " " + user.LastName),
+		Email:   user.Email,
+		Contact: user.Phone,
+	})
+	if err != nil {
+		return "", err
+	}
+	return customer.ID, nil
+}
+
+// loadCheckoutPaymentMethod loads a saved method for one-tap checkout, checking
+// ownership, gateway and expiry
+func loadCheckoutPaymentMethod(userID, methodID uuid.UUID) (*models.PaymentMethod, string) {
+	var method models.PaymentMethod
+	if err := database.DB.Where("id = ? AND user_id = ?", methodID, userID).First(&method).Error; err != nil {
+		return nil, "Payment method not found"
+	}
+	if method.Gateway != models.PaymentGatewayRazorpay {
+		return nil, "This saved payment method cannot be used for orders"
+	}
+	if method.IsExpired(time.Now()) {
+		return nil, "Saved card has expired"
+	}
+	return &method, ""
+}
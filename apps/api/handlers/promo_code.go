@@ -0,0 +1,322 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/homechef/api/database"
+	"github.com/homechef/api/middleware"
+	"github.com/homechef/api/models"
+	"github.com/homechef/api/services"
+)
+
+type PromoCodeHandler struct{}
+
+func NewPromoCodeHandler() *PromoCodeHandler {
+	return &PromoCodeHandler{}
+}
+
+// PromoCodeRequest is the admin create/update payload for a promo code
+type PromoCodeRequest struct {
+	Code           string                   `json:"code" binding:"required,min=3,max=32"`
+	Description    string                   `json:"description"`
+	DiscountType   models.PromoDiscountType `json:"discountType" binding:"required,oneof=percentage flat"`
+	DiscountValue  float64                  `json:"discountValue" binding:"required,gt=0"`
+	MaxDiscount    float64                  `json:"maxDiscount" binding:"min=0"`
+	MinSubtotal    float64                  `json:"minSubtotal" binding:"min=0"`
+	StartsAt       *time.Time               `json:"startsAt"`
+	EndsAt         *time.Time               `json:"endsAt"`
+	MaxRedemptions int                      `json:"maxRedemptions" binding:"min=0"`
+	PerUserLimit   *int                     `json:"perUserLimit" binding:"omitempty,min=0"`
+	FirstOrderOnly bool                     `json:"firstOrderOnly"`
+	ChefID         *uuid.UUID               `json:"chefId"`
+	City           string                   `json:"city"`
+	Country        string                   `json:"country" binding:"omitempty,len=2"`
+	FundedBy       models.PromoFundedBy     `json:"fundedBy" binding:"omitempty,oneof=platform chef"`
+	IsActive       *bool                    `json:"isActive"`
+}
+
+// ValidatePromoCode previews the discount a code would give on an order.
+// POST /promo-codes/validate
+func (h *PromoCodeHandler) ValidatePromoCode(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var req struct {
+		Code     string    `json:"code" binding:"required"`
+		ChefID   uuid.UUID `json:"chefId" binding:"required"`
+		Subtotal float64   `json:"subtotal" binding:"required,gt=0"`
+		City     string    `json:"city"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var chef models.ChefProfile
+	if err := database.DB.Where("id = ?", req.ChefID).First(&chef).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Chef not found"})
+		return
+	}
+
+	promo, discount, err := services.ValidatePromoCode(database.DB, req.Code, services.PromoCheckout{
+		UserID:   userID,
+		ChefID:   chef.ID,
+		City:     req.City,
+		Country:  services.ResolveChefCountry(&chef),
+		Subtotal: req.Subtotal,
+	})
+	if err != nil {
+		var promoErr *services.PromoError
+		if errors.As(err, &promoErr) {
+			c.JSON(http.StatusBadRequest, gin.H{"valid": false, "error": promoErr.Message})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate promo code"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"valid":       true,
+		"code":        promo.Code,
+		"description": promo.Description,
+		"discount":    discount,
+	})
+}
+
+// AdminListPromoCodes returns paginated promo codes.
+// GET /admin/promo-codes
+func (h *PromoCodeHandler) AdminListPromoCodes(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	query := database.DB.Model(&models.PromoCode{})
+	if active := c.Query("active"); active != "" {
+		query = query.Where("is_active = ?", active == "true")
+	}
+	if search := c.Query("search"); search != "" {
+		query = query.Where("code ILIKE ?", "%"+search+"%")
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var promos []models.PromoCode
+	query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&promos)
+
+	totalPages := int((total + int64(limit) - 1) / int64(limit))
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": promos,
+		"pagination": gin.H{
+			"page":       page,
+			"limit":      limit,
+			"total":      total,
+			"totalPages": totalPages,
+			"hasNext":    page < totalPages,
+			"hasPrev":    page > 1,
+		},
+	})
+}
+
+// AdminCreatePromoCode creates a promo code.
+// POST /admin/promo-codes
+func (h *PromoCodeHandler) AdminCreatePromoCode(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var req PromoCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	promo := models.PromoCode{
+		PerUserLimit: 1,
+		FundedBy:     models.PromoFundedByPlatform,
+		IsActive:     true,
+		CreatedBy:    &userID,
+	}
+	if errMsg := applyPromoCodeRequest(&promo, &req); errMsg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg})
+		return
+	}
+
+	var existing int64
+	database.DB.Model(&models.PromoCode{}).Where("code = ?", promo.Code).Count(&existing)
+	if existing > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "A promo code with this code already exists"})
+		return
+	}
+
+	if err := database.DB.Create(&promo).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create promo code"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, promo)
+}
+
+// AdminUpdatePromoCode updates a promo code. The code string itself cannot change
+// once it has been redeemed.
+// PUT /admin/promo-codes/:id
+func (h *PromoCodeHandler) AdminUpdatePromoCode(c *gin.Context) {
+	var promo models.PromoCode
+	if err := database.DB.Where("id = ?", c.Param("id")).First(&promo).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Promo code not found"})
+		return
+	}
+
+	var req PromoCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	oldCode := promo.Code
+	if errMsg := applyPromoCodeRequest(&promo, &req); errMsg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg})
+		return
+	}
+
+	if promo.Code != oldCode {
+		if promo.RedemptionCount > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot rename a promo code that has been redeemed"})
+			return
+		}
+		var existing int64
+		database.DB.Model(&models.PromoCode{}).Where("code = ? AND id != ?", promo.Code, promo.ID).Count(&existing)
+		if existing > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "A promo code with this code already exists"})
+			return
+		}
+	}
+
+	if err := database.DB.Save(&promo).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update promo code"})
+		return
+	}
+
+	c.JSON(http.StatusOK, promo)
+}
+
+// AdminDeactivatePromoCode switches a promo code off. Codes are never deleted so
+// redemption history stays intact.
+// DELETE /admin/promo-codes/:id
+func (h *PromoCodeHandler) AdminDeactivatePromoCode(c *gin.Context) {
+	result := database.DB.Model(&models.PromoCode{}).Where("id = ?", c.Param("id")).Update("is_active", false)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to deactivate promo code"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Promo code not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Promo code deactivated"})
+}
+
+// AdminGetPromoRedemptions returns redemptions of a promo code with totals.
+// GET /admin/promo-codes/:id/redemptions
+func (h *PromoCodeHandler) AdminGetPromoRedemptions(c *gin.Context) {
+	var promo models.PromoCode
+	if err := database.DB.Where("id = ?", c.Param("id")).First(&promo).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Promo code not found"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	var total int64
+	database.DB.Model(&models.PromoRedemption{}).Where("promo_code_id = ?", promo.ID).Count(&total)
+
+	var totalDiscount float64
+	database.DB.Model(&models.PromoRedemption{}).Where("promo_code_id = ? AND released_at IS NULL", promo.ID).
+		Select("COALESCE(SUM(discount), 0)").Scan(&totalDiscount)
+
+	var redemptions []models.PromoRedemption
+	database.DB.Where("promo_code_id = ?", promo.ID).
+		Order("created_at DESC").Offset(offset).Limit(limit).Find(&redemptions)
+
+	totalPages := int((total + int64(limit) - 1) / int64(limit))
+
+	c.JSON(http.StatusOK, gin.H{
+		"promoCode":     promo,
+		"totalDiscount": totalDiscount,
+		"data":          redemptions,
+		"pagination": gin.H{
+			"page":       page,
+			"limit":      limit,
+			"total":      total,
+			"totalPages": totalPages,
+			"hasNext":    page < totalPages,
+			"hasPrev":    page > 1,
+		},
+	})
+}
+
+// applyPromoCodeRequest copies and validates an admin payload onto a promo code.
+// Returns an error message for the client, or "" on success.
+func applyPromoCodeRequest(promo *models.PromoCode, req *PromoCodeRequest) string {
+	if req.DiscountType == models.PromoDiscountPercentage && req.DiscountValue > 100 {
+		return "Percentage discount cannot exceed 100"
+	}
+	if req.StartsAt != nil && req.EndsAt != nil && !req.EndsAt.After(*req.StartsAt) {
+		return "endsAt must be after startsAt"
+	}
+	if req.ChefID != nil {
+		var count int64
+		database.DB.Model(&models.ChefProfile{}).Where("id = ?", *req.ChefID).Count(&count)
+		if count == 0 {
+			return "Chef not found"
+		}
+	}
+
+	promo.Code = services.NormalizePromoCode(req.Code)
+	promo.Description = req.Description
+	promo.DiscountType = req.DiscountType
+	promo.DiscountValue = req.DiscountValue
+	promo.MaxDiscount = req.MaxDiscount
+	promo.MinSubtotal = req.MinSubtotal
+	promo.StartsAt = req.StartsAt
+	promo.EndsAt = req.EndsAt
+	promo.MaxRedemptions = req.MaxRedemptions
+	if req.PerUserLimit != nil {
+		promo.PerUserLimit = *req.PerUserLimit
+	}
+	promo.FirstOrderOnly = req.FirstOrderOnly
+	promo.ChefID = req.ChefID
+	promo.City = strings.TrimSpace(req.City)
+	promo.Country = strings.ToUpper(req.Country)
+	if req.FundedBy != "" {
+		promo.FundedBy = req.FundedBy
+	}
+	if req.IsActive != nil {
+		promo.IsActive = *req.IsActive
+	}
+
+	// Checked on the result, since an update may keep the stored funder
+	if promo.FundedBy == models.PromoFundedByChef && promo.ChefID == nil {
+		return "Chef-funded promo codes must be scoped to a chef"
+	}
+	return ""
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"unicode/utf8"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -77,6 +78,26 @@ func (h *ReviewHandler) CreateReview(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "overallRating must be between 1 and 5"})
 		return
 	}
+	if deliveryRating < 0 || deliveryRating > 5 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "deliveryRating must be between 1 and 5"})
+		return
+	}
+
+	// Reason tags and a note for the driver go with the delivery rating
+	deliveryTags, err := services.NormalizeDriverRatingTags(c.PostFormArray("deliveryTags"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	deliveryComment := c.PostForm("deliveryComment")
+	if utf8.RuneCountInString(deliveryComment) > services.MaxDriverFeedbackLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("deliveryComment must be at most %d characters", services.MaxDriverFeedbackLength)})
+		return
+	}
+	if deliveryRating == 0 && (len(deliveryTags) > 0 || deliveryComment != "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "deliveryRating is required with delivery tags or comments"})
+		return
+	}
 
 	title := c.PostForm("title")
 	comment := c.PostForm("comment")
@@ -142,6 +163,12 @@ func (h *ReviewHandler) CreateReview(c *gin.Context) {
 
 	// Update chef's rating stats
 	go updateChefRating(order.ChefID)
+	// The delivery rating goes to the driver who delivered the order
+	if deliveryRating > 0 {
+		if err := services.RateDeliveryPartner(database.DB, &review, &order, deliveryTags, deliveryComment); err != nil {
+			log.Printf("Failed to rate delivery partner for order %s: %v", order.ID, err)
+		}
+	}
 
 	// Reload with customer for response
 	database.DB.Preload("Customer").First(&review, review.ID)
@@ -0,0 +1,883 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/homechef/api/database"
+	"github.com/homechef/api/middleware"
+	"github.com/homechef/api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const maxPostImages = 10
+
+// postReportThreshold is how many users must report a post before it is
+// taken down for moderation
+const postReportThreshold = 3
+
+// Off-platform contact details in posts and comments are not allowed; posts that
+// contain them are held for moderation instead of being published.
+var (
+	contactEmailRegex = regexp.MustCompile(`(?i)[a-z0-9._%+\-]+@[a-z0-9.\-]+\.[a-z]{2,}`)
+	contactPhoneRegex = regexp.MustCompile(`(?:\+?\d[\s\-.()]*){10,}`)
+	contactLinkRegex  = regexp.MustCompile(`(?i)(wa\.me/|t\.me/|whatsapp\.com/)`)
+)
+
+type SocialHandler struct{}
+
+func NewSocialHandler() *SocialHandler {
+	return &SocialHandler{}
+}
+
+type CreatePostRequest struct {
+	Content    string     `json:"content" binding:"required"`
+	Images     []string   `json:"images"`
+	Hashtags   []string   `json:"hashtags"`
+	MenuItemID *uuid.UUID `json:"menuItemId"`
+	Status     string     `json:"status"` // draft or published (default)
+}
+
+type UpdatePostRequest struct {
+	Content    *string    `json:"content"`
+	Images     *[]string  `json:"images"`
+	Hashtags   *[]string  `json:"hashtags"`
+	MenuItemID *uuid.UUID `json:"menuItemId"`
+	Status     *string    `json:"status"` // draft, published or archived
+}
+
+type AddCommentRequest struct {
+	Content  string     `json:"content" binding:"required,max=1000"`
+	ParentID *uuid.UUID `json:"parentId"`
+}
+
+// ---------- Public feed ----------
+
+// GetFeed returns published posts, newest first.
+// GET /social/feed
+func (h *SocialHandler) GetFeed(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 50 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	query := database.DB.Model(&models.Post{}).
+		Joins("JOIN chef_profiles ON chef_profiles.id = posts.chef_id AND chef_profiles.is_active = ?", true).
+		Where("posts.status = ?", models.PostStatusPublished)
+
+	if chefID := c.Query("chefId"); chefID != "" {
+		query = query.Where("posts.chef_id = ?", chefID)
+	}
+	if hashtag := strings.TrimPrefix(c.Query("hashtag"), "#"); hashtag != "" {
+		query = query.Where("? = ANY(posts.hashtags)", strings.ToLower(hashtag))
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var posts []models.Post
+	if err := withViewerLikes(c, query.Preload("Chef").Preload("MenuItem")).
+		Order("posts.created_at DESC").
+		Offset(offset).Limit(limit).
+		Find(&posts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch feed"})
+		return
+	}
+
+	viewerID := viewerIDPtr(c)
+	responses := make([]models.PostResponse, len(posts))
+	for i := range posts {
+		responses[i] = posts[i].ToResponse(viewerID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": responses,
+		"pagination": gin.H{
+			"page":       page,
+			"limit":      limit,
+			"total":      total,
+			"totalPages": (total + int64(limit) - 1) / int64(limit),
+			"hasNext":    int64(offset+limit) < total,
+			"hasPrev":    page > 1,
+		},
+	})
+}
+
+// GetPost returns a single published post.
+// GET /social/posts/:id
+func (h *SocialHandler) GetPost(c *gin.Context) {
+	var post models.Post
+	if err := withViewerLikes(c, database.DB.Preload("Chef").Preload("MenuItem")).
+		Where("id = ? AND status = ?", c.Param("id"), models.PostStatusPublished).
+		First(&post).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, post.ToResponse(viewerIDPtr(c)))
+}
+
+// GetComments returns top-level comments on a post with their replies nested.
+// GET /social/posts/:id/comments
+func (h *SocialHandler) GetComments(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 50 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	var post models.Post
+	if err := database.DB.Where("id = ? AND status = ?", c.Param("id"), models.PostStatusPublished).
+		First(&post).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		return
+	}
+
+	query := database.DB.Model(&models.PostComment{}).
+		Where("post_id = ? AND parent_id IS NULL AND is_hidden = ?", post.ID, false)
+
+	var total int64
+	query.Count(&total)
+
+	var comments []models.PostComment
+	if err := query.Preload("User").
+		Preload("Replies", func(db *gorm.DB) *gorm.DB {
+			return db.Where("is_hidden = ?", false).Order("created_at ASC")
+		}).
+		Preload("Replies.User").
+		Order("created_at DESC").
+		Offset(offset).Limit(limit).
+		Find(&comments).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch comments"})
+		return
+	}
+
+	responses := make([]models.CommentResponse, len(comments))
+	for i := range comments {
+		responses[i] = comments[i].ToResponse(false)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": responses,
+		"pagination": gin.H{
+			"page":       page,
+			"limit":      limit,
+			"total":      total,
+			"totalPages": (total + int64(limit) - 1) / int64(limit),
+			"hasNext":    int64(offset+limit) < total,
+			"hasPrev":    page > 1,
+		},
+	})
+}
+
+// LikePost likes a post. Liking twice is a no-op.
+// POST /social/posts/:id/like
+func (h *SocialHandler) LikePost(c *gin.Context) {
+	h.setLike(c, true)
+}
+
+// UnlikePost removes the caller's like. Unliking a post that isn't liked is a no-op.
+// DELETE /social/posts/:id/like
+func (h *SocialHandler) UnlikePost(c *gin.Context) {
+	h.setLike(c, false)
+}
+
+func (h *SocialHandler) setLike(c *gin.Context, liked bool) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	var post models.Post
+	if err := database.DB.Where("id = ? AND status = ?", c.Param("id"), models.PostStatusPublished).
+		First(&post).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		return
+	}
+
+	tx := database.DB.Begin()
+
+	var err error
+	if liked {
+		err = tx.Exec(`INSERT INTO post_likes (post_id, user_id, created_at) VALUES (?, ?, ?)
+			ON CONFLICT (post_id, user_id) DO NOTHING`, post.ID, userID, time.Now()).Error
+	} else {
+		err = tx.Where("post_id = ? AND user_id = ?", post.ID, userID).Delete(&models.PostLike{}).Error
+	}
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update like"})
+		return
+	}
+
+	// Recount rather than increment so concurrent likes can never drift the counter
+	if err := tx.Exec(`UPDATE posts SET likes_count = (SELECT COUNT(*) FROM post_likes WHERE post_id = ?)
+		WHERE id = ?`, post.ID, post.ID).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update like"})
+		return
+	}
+
+	tx.Commit()
+
+	database.DB.Select("likes_count").First(&post, post.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"postId":     post.ID,
+		"isLiked":    liked,
+		"likesCount": post.LikesCount,
+	})
+}
+
+// AddComment adds a comment or a reply to a post. Replies to replies are
+// attached to the top-level comment so threads stay one level deep.
+// POST /social/posts/:id/comments
+func (h *SocialHandler) AddComment(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	var req AddCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	content := strings.TrimSpace(req.Content)
+	if content == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Comment cannot be empty"})
+		return
+	}
+
+	var post models.Post
+	if err := database.DB.Where("id = ? AND status = ?", c.Param("id"), models.PostStatusPublished).
+		First(&post).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		return
+	}
+
+	comment := models.PostComment{
+		PostID:  post.ID,
+		UserID:  userID,
+		Content: content,
+		// Comments sharing contact details are kept but hidden from the public thread
+		IsHidden: containsContactInfo(content),
+	}
+
+	if req.ParentID != nil {
+		var parent models.PostComment
+		if err := database.DB.Where("id = ? AND post_id = ?", *req.ParentID, post.ID).
+			First(&parent).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Parent comment not found"})
+			return
+		}
+		rootID := parent.ID
+		if parent.ParentID != nil {
+			rootID = *parent.ParentID
+		}
+		comment.ParentID = &rootID
+	}
+
+	if err := database.DB.Create(&comment).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add comment"})
+		return
+	}
+
+	syncCommentsCount(post.ID)
+
+	database.DB.Preload("User").First(&comment, comment.ID)
+
+	c.JSON(http.StatusCreated, comment.ToResponse(true))
+}
+
+// ReportPost records a user's report of a published post. Once
+// postReportThreshold users have reported it since it was last moderated, the
+// post is flagged for moderation.
+// POST /social/posts/:id/report
+func (h *SocialHandler) ReportPost(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	var req struct {
+		Reason string `json:"reason" binding:"required,max=500"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "reason is required"})
+		return
+	}
+
+	var post models.Post
+	if err := database.DB.Where("id = ? AND status = ?", c.Param("id"), models.PostStatusPublished).
+		First(&post).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to report post"})
+		return
+	}
+
+	result := database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.PostReport{
+		PostID: post.ID,
+		UserID: userID,
+		Reason: strings.TrimSpace(req.Reason),
+	})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to report post"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "You have already reported this post"})
+		return
+	}
+
+	// Reports a moderator has already looked at don't count again
+	reports := database.DB.Model(&models.PostReport{}).Where("post_id = ?", post.ID)
+	if post.ModeratedAt != nil {
+		reports = reports.Where("created_at > ?", *post.ModeratedAt)
+	}
+	var count int64
+	if err := reports.Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to report post"})
+		return
+	}
+
+	if count >= postReportThreshold {
+		if err := database.DB.Model(&models.Post{}).
+			Where("id = ? AND status = ?", post.ID, models.PostStatusPublished).
+			Updates(map[string]interface{}{
+				"status":       models.PostStatusFlagged,
+				"is_moderated": false,
+			}).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to report post"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Post reported. Our team will review it shortly."})
+}
+
+// ---------- Chef post management ----------
+
+// GetChefPosts returns the authenticated chef's posts in every state.
+// GET /chef/posts
+func (h *SocialHandler) GetChefPosts(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var chef models.ChefProfile
+	if err := database.DB.Where("user_id = ?", userID).First(&chef).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Chef profile not found"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 50 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	query := database.DB.Model(&models.Post{}).Where("chef_id = ?", chef.ID)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var posts []models.Post
+	if err := query.Preload("MenuItem").
+		Order("created_at DESC").
+		Offset(offset).Limit(limit).
+		Find(&posts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch posts"})
+		return
+	}
+
+	responses := make([]gin.H, len(posts))
+	for i := range posts {
+		posts[i].Chef = chef
+		responses[i] = chefPostResponse(&posts[i])
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": responses,
+		"pagination": gin.H{
+			"page":       page,
+			"limit":      limit,
+			"total":      total,
+			"totalPages": (total + int64(limit) - 1) / int64(limit),
+			"hasNext":    int64(offset+limit) < total,
+			"hasPrev":    page > 1,
+		},
+	})
+}
+
+// CreatePost creates a draft or published post for the authenticated chef.
+// POST /chef/posts
+func (h *SocialHandler) CreatePost(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var chef models.ChefProfile
+	if err := database.DB.Where("user_id = ?", userID).First(&chef).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Chef profile not found"})
+		return
+	}
+
+	var req CreatePostRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	status := models.PostStatusPublished
+	if req.Status != "" {
+		status = models.PostStatus(req.Status)
+		if status != models.PostStatusDraft && status != models.PostStatusPublished {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "status must be draft or published"})
+			return
+		}
+	}
+
+	if len(req.Images) > maxPostImages {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Too many images", "max": maxPostImages})
+		return
+	}
+
+	if req.MenuItemID != nil && !chefOwnsMenuItem(chef.ID, *req.MenuItemID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Menu item not found"})
+		return
+	}
+
+	post := models.Post{
+		ChefID:     chef.ID,
+		Status:     status,
+		Content:    strings.TrimSpace(req.Content),
+		Images:     ensureStringArray(req.Images),
+		Hashtags:   normalizeHashtags(req.Hashtags),
+		MenuItemID: req.MenuItemID,
+	}
+	applyContactScreening(&post)
+
+	if err := database.DB.Create(&post).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create post"})
+		return
+	}
+
+	database.DB.Preload("MenuItem").First(&post, post.ID)
+	post.Chef = chef
+
+	c.JSON(http.StatusCreated, chefPostResponse(&post))
+}
+
+// UpdatePost edits a post or moves it between draft, published and archived.
+// Flagged posts can be edited but only a moderator can release them, and a
+// moderated post can be taken down by its chef but only republished by a moderator.
+// PUT /chef/posts/:id
+func (h *SocialHandler) UpdatePost(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var chef models.ChefProfile
+	if err := database.DB.Where("user_id = ?", userID).First(&chef).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Chef profile not found"})
+		return
+	}
+
+	var post models.Post
+	if err := database.DB.Where("id = ? AND chef_id = ?", c.Param("id"), chef.ID).First(&post).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		return
+	}
+
+	var req UpdatePostRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Status != nil {
+		status := models.PostStatus(*req.Status)
+		switch status {
+		case models.PostStatusDraft, models.PostStatusPublished, models.PostStatusArchived:
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "status must be draft, published or archived"})
+			return
+		}
+		if post.Status == models.PostStatusFlagged && status != models.PostStatusArchived {
+			c.JSON(http.StatusConflict, gin.H{"error": "This post is under review and can only be archived"})
+			return
+		}
+		if post.IsModerated && status == models.PostStatusPublished && post.Status != models.PostStatusPublished {
+			c.JSON(http.StatusConflict, gin.H{"error": "This post has been moderated; only a moderator can publish it again"})
+			return
+		}
+		post.Status = status
+	}
+
+	if req.Content != nil {
+		content := strings.TrimSpace(*req.Content)
+		if content == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "content cannot be empty"})
+			return
+		}
+		post.Content = content
+	}
+	if req.Images != nil {
+		if len(*req.Images) > maxPostImages {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Too many images", "max": maxPostImages})
+			return
+		}
+		post.Images = ensureStringArray(*req.Images)
+	}
+	if req.Hashtags != nil {
+		post.Hashtags = normalizeHashtags(*req.Hashtags)
+	}
+	if req.MenuItemID != nil {
+		if !chefOwnsMenuItem(chef.ID, *req.MenuItemID) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Menu item not found"})
+			return
+		}
+		post.MenuItemID = req.MenuItemID
+	}
+
+	applyContactScreening(&post)
+
+	if err := database.DB.Save(&post).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update post"})
+		return
+	}
+
+	database.DB.Preload("MenuItem").First(&post, post.ID)
+	post.Chef = chef
+
+	c.JSON(http.StatusOK, chefPostResponse(&post))
+}
+
+// DeletePost soft-deletes one of the chef's posts.
+// DELETE /chef/posts/:id
+func (h *SocialHandler) DeletePost(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var chef models.ChefProfile
+	if err := database.DB.Where("user_id = ?", userID).First(&chef).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Chef profile not found"})
+		return
+	}
+
+	result := database.DB.Where("id = ? AND chef_id = ?", c.Param("id"), chef.ID).Delete(&models.Post{})
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Post deleted"})
+}
+
+// GetChefPostComments returns every comment on one of the chef's posts,
+// including hidden ones, so the chef can manage the thread.
+// GET /chef/posts/:id/comments
+func (h *SocialHandler) GetChefPostComments(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var chef models.ChefProfile
+	if err := database.DB.Where("user_id = ?", userID).First(&chef).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Chef profile not found"})
+		return
+	}
+
+	var post models.Post
+	if err := database.DB.Where("id = ? AND chef_id = ?", c.Param("id"), chef.ID).First(&post).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		return
+	}
+
+	var comments []models.PostComment
+	database.DB.Where("post_id = ? AND parent_id IS NULL", post.ID).
+		Preload("User").
+		Preload("Replies", func(db *gorm.DB) *gorm.DB { return db.Order("created_at ASC") }).
+		Preload("Replies.User").
+		Order("created_at DESC").
+		Find(&comments)
+
+	responses := make([]models.CommentResponse, len(comments))
+	for i := range comments {
+		responses[i] = comments[i].ToResponse(true)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": responses})
+}
+
+// HideComment hides or unhides a comment on one of the chef's posts.
+// PUT /chef/posts/:id/comments/:commentId/hide
+func (h *SocialHandler) HideComment(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var chef models.ChefProfile
+	if err := database.DB.Where("user_id = ?", userID).First(&chef).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Chef profile not found"})
+		return
+	}
+
+	var comment models.PostComment
+	if err := database.DB.Joins("JOIN posts ON posts.id = post_comments.post_id").
+		Where("post_comments.id = ? AND post_comments.post_id = ? AND posts.chef_id = ?",
+			c.Param("commentId"), c.Param("id"), chef.ID).
+		First(&comment).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
+		return
+	}
+
+	setCommentHidden(c, &comment)
+}
+
+// ---------- Moderation ----------
+
+// AdminGetFlaggedPosts returns the moderation queue, oldest first.
+// GET /admin/moderation/posts
+func (h *SocialHandler) AdminGetFlaggedPosts(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	query := database.DB.Model(&models.Post{}).Where("status = ?", models.PostStatusFlagged)
+	if c.Query("contactInfo") == "true" {
+		query = query.Where("contact_info_detected = ?", true)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var posts []models.Post
+	if err := query.Preload("Chef").Preload("MenuItem").
+		Order("updated_at ASC").
+		Offset(offset).Limit(limit).
+		Find(&posts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch moderation queue"})
+		return
+	}
+
+	// Show moderators why each post was reported
+	postIDs := make([]uuid.UUID, len(posts))
+	for i := range posts {
+		postIDs[i] = posts[i].ID
+	}
+	var reports []models.PostReport
+	if len(postIDs) > 0 {
+		database.DB.Where("post_id IN ?", postIDs).Order("created_at ASC").Find(&reports)
+	}
+	reasons := make(map[uuid.UUID][]string)
+	for _, r := range reports {
+		reasons[r.PostID] = append(reasons[r.PostID], r.Reason)
+	}
+
+	responses := make([]gin.H, len(posts))
+	for i := range posts {
+		responses[i] = chefPostResponse(&posts[i])
+		responses[i]["reportReasons"] = reasons[posts[i].ID]
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": responses,
+		"pagination": gin.H{
+			"page":       page,
+			"limit":      limit,
+			"total":      total,
+			"totalPages": (total + int64(limit) - 1) / int64(limit),
+			"hasNext":    int64(offset+limit) < total,
+			"hasPrev":    page > 1,
+		},
+	})
+}
+
+// AdminModeratePost approves (publishes) or removes (archives) a flagged post.
+// PUT /admin/moderation/posts/:id
+func (h *SocialHandler) AdminModeratePost(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+
+	var req struct {
+		Action string `json:"action" binding:"required,oneof=approve remove"`
+		Note   string `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "action must be approve or remove"})
+		return
+	}
+
+	var post models.Post
+	if err := database.DB.Where("id = ? AND status = ?", c.Param("id"), models.PostStatusFlagged).
+		First(&post).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Flagged post not found"})
+		return
+	}
+
+	newStatus := models.PostStatusPublished
+	if req.Action == "remove" {
+		newStatus = models.PostStatusArchived
+	}
+
+	now := time.Now()
+	if err := database.DB.Model(&post).Updates(map[string]interface{}{
+		"status":         newStatus,
+		"is_moderated":   true,
+		"moderated_at":   now,
+		"moderator_note": req.Note,
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to moderate post"})
+		return
+	}
+
+	database.DB.Create(&models.AuditLog{
+		UserID:     &userID,
+		Action:     "post." + req.Action,
+		EntityType: "post",
+		EntityID:   post.ID.String(),
+		OldValue:   string(models.PostStatusFlagged),
+		NewValue:   string(newStatus),
+		IPAddress:  c.ClientIP(),
+		UserAgent:  c.Request.UserAgent(),
+	})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Post " + req.Action + "d", "status": newStatus})
+}
+
+// AdminHideComment hides or unhides any comment.
+// PUT /admin/moderation/comments/:id/hide
+func (h *SocialHandler) AdminHideComment(c *gin.Context) {
+	var comment models.PostComment
+	if err := database.DB.Where("id = ?", c.Param("id")).First(&comment).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
+		return
+	}
+
+	setCommentHidden(c, &comment)
+}
+
+// ---------- Helpers ----------
+
+// setCommentHidden applies the {hidden} payload to a comment and refreshes the
+// post's comment count. Hiding a top-level comment hides its whole thread.
+func setCommentHidden(c *gin.Context, comment *models.PostComment) {
+	var req struct {
+		Hidden *bool `json:"hidden"`
+	}
+	c.ShouldBindJSON(&req)
+	hidden := true
+	if req.Hidden != nil {
+		hidden = *req.Hidden
+	}
+
+	if err := database.DB.Model(comment).Update("is_hidden", hidden).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update comment"})
+		return
+	}
+
+	syncCommentsCount(comment.PostID)
+
+	c.JSON(http.StatusOK, gin.H{"id": comment.ID, "isHidden": hidden})
+}
+
+// syncCommentsCount recounts visible comments (excluding replies under a hidden parent)
+func syncCommentsCount(postID uuid.UUID) {
+	database.DB.Exec(`UPDATE posts SET comments_count = (
+		SELECT COUNT(*) FROM post_comments pc
+		LEFT JOIN post_comments parent ON parent.id = pc.parent_id
+		WHERE pc.post_id = ? AND pc.is_hidden = false AND COALESCE(parent.is_hidden, false) = false
+	) WHERE id = ?`, postID, postID)
+}
+
+// withViewerLikes preloads only the caller's like so Post.ToResponse can set IsLiked
+func withViewerLikes(c *gin.Context, db *gorm.DB) *gorm.DB {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		return db
+	}
+	return db.Preload("Likes", "user_id = ?", userID)
+}
+
+func viewerIDPtr(c *gin.Context) *uuid.UUID {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		return nil
+	}
+	return &userID
+}
+
+// applyContactScreening holds a post for moderation when it would be published
+// with contact details in it
+func applyContactScreening(post *models.Post) {
+	post.ContactInfoDetected = containsContactInfo(post.Content)
+	if post.ContactInfoDetected && post.Status == models.PostStatusPublished {
+		post.Status = models.PostStatusFlagged
+		post.IsModerated = false
+	}
+}
+
+func containsContactInfo(text string) bool {
+	return contactEmailRegex.MatchString(text) ||
+		contactPhoneRegex.MatchString(text) ||
+		contactLinkRegex.MatchString(text)
+}
+
+func normalizeHashtags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	result := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(tag), "#")))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		result = append(result, tag)
+	}
+	return result
+}
+
+func chefOwnsMenuItem(chefID, menuItemID uuid.UUID) bool {
+	var count int64
+	database.DB.Model(&models.MenuItem{}).Where("id = ? AND chef_id = ?", menuItemID, chefID).Count(&count)
+	return count > 0
+}
+
+// chefPostResponse extends the public post view with the moderation fields the
+// owning chef and moderators need to see
+func chefPostResponse(post *models.Post) gin.H {
+	return gin.H{
+		"post":                post.ToResponse(nil),
+		"isModerated":         post.IsModerated,
+		"moderatedAt":         post.ModeratedAt,
+		"moderatorNote":       post.ModeratorNote,
+		"contactInfoDetected": post.ContactInfoDetected,
+		"updatedAt":           post.UpdatedAt,
+	}
+}
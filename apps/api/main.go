@@ -74,8 +74,64 @@ func main() {
 		} else {
 			defer notificationService.Stop()
 		}
+
+		// Start live order tracking fan-out
+		orderTracker := services.GetOrderTracker()
+		if err := orderTracker.Start(); err != nil {
+			log.Printf("Warning: Failed to start order tracker: %v", err)
+		} else {
+			defer orderTracker.Stop()
+		}
+
+		// Start order workers (auto-accept)
+		orderWorkers := services.GetOrderWorkerService()
+		if err := orderWorkers.Start(); err != nil {
+			log.Printf("Warning: Failed to start order workers: %v", err)
+		} else {
+			defer orderWorkers.Stop()
+		}
 	}
 
+	// Start background jobs
+	jobRunner := services.GetJobRunner()
+	jobRunner.Register(services.Job{
+		Name:     "catering-expiry",
+		Interval: services.CateringSweepInterval,
+		Run:      services.ExpireCatering,
+	})
+	jobRunner.Register(services.Job{
+		Name:     "menu-stock-reset",
+		Interval: services.StockResetInterval,
+		Run:      services.ResetDailyStock,
+	})
+	jobRunner.Register(services.Job{
+		Name:     "location-ping-purge",
+		Interval: services.LocationPingPurgeInterval,
+		Run:      services.PurgeLocationPings,
+	})
+	jobRunner.Register(services.Job{
+		Name:     "delivery-offer-sweep",
+		Interval: services.DispatchSweepInterval,
+		Run:      services.ExpireDeliveryOffers,
+	})
+	jobRunner.Register(services.Job{
+		Name:     "failed-delivery-sweep",
+		Interval: services.FailedDeliverySweepInterval,
+		Run:      services.ExpireFailedDeliveries,
+	})
+	jobRunner.Register(services.Job{
+		Name:     "zone-surge",
+		Interval: services.SurgeSweepInterval,
+		Run:      services.UpdateZoneSurge,
+	})
+	jobRunner.Register(services.Job{
+		Name:     "driver-metrics",
+		Interval: services.DriverMetricsInterval,
+		Run:      services.UpdateDriverMetrics,
+	})
+	jobRunner.Start()
+	defer jobRunner.Stop()
+
 	// Setup router
 	router := routes.SetupRouter()
 
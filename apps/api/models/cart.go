@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 type Cart struct {
@@ -25,6 +26,8 @@ type CartItem struct {
 	MenuItemID uuid.UUID `gorm:"type:uuid;not null" json:"menuItemId"`
 	Quantity   int       `gorm:"not null;default:1" json:"quantity"`
 	Notes      string    `gorm:"" json:"notes,omitempty"`
+	UnitPrice  float64   `gorm:"default:0" json:"unitPrice"` // Price last shown to the customer
+	ModifierOptionIDs pq.StringArray `gorm:"type:text[];default:'{}'" json:"modifierOptionIds"` // sorted
 	CreatedAt  time.Time `gorm:"autoCreateTime" json:"createdAt"`
 	UpdatedAt  time.Time `gorm:"autoUpdateTime" json:"updatedAt"`
 
@@ -34,11 +37,16 @@ type CartItem struct {
 
 // DTOs
 type CartResponse struct {
-	ID       uuid.UUID          `json:"id"`
-	ChefID   *uuid.UUID         `json:"chefId,omitempty"`
-	Chef     *ChefCartResponse  `json:"chef,omitempty"`
-	Items    []CartItemResponse `json:"items"`
-	Subtotal float64            `json:"subtotal"`
+	ID                  uuid.UUID          `json:"id"`
+	ChefID              *uuid.UUID         `json:"chefId,omitempty"`
+	Chef                *ChefCartResponse  `json:"chef,omitempty"`
+	Items               []CartItemResponse `json:"items"`
+	ItemCount           int                `json:"itemCount"`
+	Subtotal            float64            `json:"subtotal"`
+	MeetsMinimumOrder   bool               `json:"meetsMinimumOrder"`
+	AmountToMinimum     float64            `json:"amountToMinimum"`
+	HasUnavailableItems bool               `json:"hasUnavailableItems"`
+	HasPriceChanges     bool               `json:"hasPriceChanges"`
 }
 
 type ChefCartResponse struct {
@@ -53,31 +61,95 @@ type CartItemResponse struct {
 	MenuItem   MenuItemResponse `json:"menuItem"`
 	Quantity   int              `json:"quantity"`
 	Notes      string           `json:"notes,omitempty"`
+	Modifiers  []SelectedModifier `json:"modifiers"`
+	UnitPrice  float64          `json:"unitPrice"`
 	Subtotal   float64          `json:"subtotal"`
+	// Revalidation flags, recomputed on every read
+	IsAvailable   bool    `json:"isAvailable"`
+	PriceChanged  bool    `json:"priceChanged"`
+	PreviousPrice float64 `json:"previousPrice,omitempty"`
 }
 
+// IsOrderable reports whether the item can currently be ordered
+func (i *CartItem) IsOrderable() bool {
+	if i.MenuItem.ID == uuid.Nil || !i.MenuItem.IsAvailable {
+		return false
+	}
+	_, _, err := i.MenuItem.SelectModifiers(i.OptionIDs())
+	return err == nil
+}
+
+// OptionIDs parses the stored modifier option IDs
+func (i *CartItem) OptionIDs() []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(i.ModifierOptionIDs))
+	for _, s := range i.ModifierOptionIDs {
+		if id, err := uuid.Parse(s); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// LiveUnitPrice is the current menu price of one unit including the chosen
+// modifiers. Requires MenuItem preloaded with ModifierGroups.Options.
+func (i *CartItem) LiveUnitPrice() (float64, []SelectedModifier) {
+	selected, delta, err := i.MenuItem.SelectModifiers(i.OptionIDs())
+	if err != nil {
+		return i.MenuItem.Price, []SelectedModifier{}
+	}
+	return RoundAmount(i.MenuItem.Price + delta), selected
+}
+
+// ToResponse builds the cart view. Unavailable items are listed but excluded from
+// the subtotal, and an item whose UnitPrice differs from the live menu price is
+// flagged so the client can show the change before checkout.
 func (c *Cart) ToResponse() CartResponse {
 	items := make([]CartItemResponse, len(c.Items))
 	var subtotal float64
+	var itemCount int
+	var hasUnavailable, hasPriceChanges bool
 
 	for i, item := range c.Items {
-		itemSubtotal := item.MenuItem.Price * float64(item.Quantity)
+		available := item.IsOrderable()
+		unitPrice, modifiers := item.LiveUnitPrice()
+		itemSubtotal := unitPrice * float64(item.Quantity)
+		priceChanged := available && item.UnitPrice != unitPrice
+
 		items[i] = CartItemResponse{
-			ID:         item.ID,
-			MenuItemID: item.MenuItemID,
-			MenuItem:   item.MenuItem.ToResponse(),
-			Quantity:   item.Quantity,
-			Notes:      item.Notes,
-			Subtotal:   itemSubtotal,
+			ID:           item.ID,
+			MenuItemID:   item.MenuItemID,
+			MenuItem:     item.MenuItem.ToResponse(),
+			Quantity:     item.Quantity,
+			Notes:        item.Notes,
+			Modifiers:    modifiers,
+			UnitPrice:    unitPrice,
+			Subtotal:     itemSubtotal,
+			IsAvailable:  available,
+			PriceChanged: priceChanged,
+		}
+		if priceChanged {
+			items[i].PreviousPrice = item.UnitPrice
+			hasPriceChanges = true
+		}
+
+		if !available {
+			items[i].Subtotal = 0
+			hasUnavailable = true
+			continue
 		}
 		subtotal += itemSubtotal
+		itemCount += item.Quantity
 	}
 
 	response := CartResponse{
-		ID:       c.ID,
-		ChefID:   c.ChefID,
-		Items:    items,
-		Subtotal: subtotal,
+		ID:                  c.ID,
+		ChefID:              c.ChefID,
+		Items:               items,
+		ItemCount:           itemCount,
+		Subtotal:            subtotal,
+		MeetsMinimumOrder:   itemCount > 0,
+		HasUnavailableItems: hasUnavailable,
+		HasPriceChanges:     hasPriceChanges,
 	}
 
 	if c.Chef != nil {
@@ -86,6 +158,10 @@ func (c *Cart) ToResponse() CartResponse {
 			BusinessName: c.Chef.BusinessName,
 			MinimumOrder: c.Chef.MinimumOrder,
 		}
+		if subtotal < c.Chef.MinimumOrder {
+			response.MeetsMinimumOrder = false
+			response.AmountToMinimum = c.Chef.MinimumOrder - subtotal
+		}
 	}
 
 	return response
@@ -17,6 +17,7 @@ const (
 	CateringStatusConfirmed  CateringRequestStatus = "confirmed"
 	CateringStatusCompleted  CateringRequestStatus = "completed"
 	CateringStatusCancelled  CateringRequestStatus = "cancelled"
+	CateringStatusExpired    CateringRequestStatus = "expired"
 )
 
 type CateringQuoteStatus string
@@ -79,8 +80,8 @@ type CateringRequest struct {
 
 type CateringQuote struct {
 	ID        uuid.UUID           `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
-	RequestID uuid.UUID           `gorm:"type:uuid;not null;index" json:"requestId"`
-	ChefID    uuid.UUID           `gorm:"type:uuid;not null;index" json:"chefId"`
+	RequestID uuid.UUID           `gorm:"type:uuid;not null;uniqueIndex:idx_catering_quotes_request_chef" json:"requestId"`
+	ChefID    uuid.UUID           `gorm:"type:uuid;not null;uniqueIndex:idx_catering_quotes_request_chef;index" json:"chefId"`
 	Status    CateringQuoteStatus `gorm:"type:varchar(20);default:'pending'" json:"status"`
 
 	// Quote Details
@@ -84,6 +84,11 @@ type ChefSettings struct {
 	ChefID               uuid.UUID `gorm:"type:uuid;uniqueIndex;not null" json:"chefId"`
 	AutoAcceptOrders     bool      `gorm:"default:false" json:"autoAcceptOrders"`
 	AutoAcceptThreshold  float64   `gorm:"default:0" json:"autoAcceptThreshold"`
+	AutoAcceptMaxActive  int       `gorm:"not null;default:0" json:"autoAcceptMaxActive"`  // 0 = no cap
+	SlotMinutes          int       `gorm:"default:30" json:"slotMinutes"`                  // scheduled delivery window length
+	SlotCapacity         int       `gorm:"not null;default:5" json:"slotCapacity"`         // orders per window, 0 = no cap
+	ScheduleLeadMinutes  int       `gorm:"not null;default:60" json:"scheduleLeadMinutes"` // notice needed on top of prep time
+	Timezone             string    `gorm:"default:''" json:"timezone"`                     // IANA name, e.g. Asia/Kolkata; empty = server time
 	PushNewOrder         bool      `gorm:"default:true" json:"pushNewOrder"`
 	PushOrderUpdate      bool      `gorm:"default:true" json:"pushOrderUpdate"`
 	EmailDailySummary    bool      `gorm:"default:true" json:"emailDailySummary"`
@@ -38,6 +38,50 @@ const (
 	VerificationRejected VerificationStatus = "rejected"
 )
 
+// StopStatus tracks one stop (pickup or dropoff) of a delivery on a driver's route
+type StopStatus string
+
+const (
+	StopPending   StopStatus = "pending"
+	StopArrived   StopStatus = "arrived"
+	StopCompleted StopStatus = "completed"
+	StopFailed    StopStatus = "failed"
+	StopSkipped   StopStatus = "skipped"
+)
+
+// Done reports whether a stop no longer needs visiting
+func (s StopStatus) Done() bool {
+	return s == StopCompleted || s == StopFailed || s == StopSkipped
+}
+
+type BatchStatus string
+
+const (
+	BatchActive    BatchStatus = "active"
+	BatchCompleted BatchStatus = "completed"
+)
+
+// FailureReason is why a driver could not hand an order over
+type FailureReason string
+
+const (
+	FailureCustomerUnavailable FailureReason = "customer_unavailable"
+	FailureWrongAddress        FailureReason = "wrong_address"
+	FailureAccessRestricted    FailureReason = "access_restricted" // gated community, locked building
+	FailureRefused             FailureReason = "refused"
+	FailureUnsafeLocation      FailureReason = "unsafe_location"
+	FailureOrderDamaged        FailureReason = "order_damaged"
+	FailureOther               FailureReason = "other"
+)
+
+// DeliveryAttemptOutcome is how one leg of a delivery ended
+type DeliveryAttemptOutcome string
+
+const (
+	AttemptFailed   DeliveryAttemptOutcome = "failed"   // driver reached the customer but could not hand over
+	AttemptReturned DeliveryAttemptOutcome = "returned" // driver brought the order back to the kitchen
+)
+
 type AssignmentType string
 
 const (
@@ -73,14 +117,22 @@ type DeliveryPartner struct {
 	// Capacity
 	MaxConcurrent int `gorm:"default:1" json:"maxConcurrent"`
 
-	// Performance metrics
+	// Performance metrics — rates are percentages over the last 30 days, the
+	// Weekly ones over the last 7; counts are lifetime
 	AcceptanceRate float64 `gorm:"default:0" json:"acceptanceRate"`
 	OnTimeRate     float64 `gorm:"default:0" json:"onTimeRate"`
 	CSATScore      float64 `gorm:"default:0" json:"csatScore"`
+	CompletionRate float64 `gorm:"default:0" json:"completionRate"`
 	OfferedCount   int     `gorm:"default:0" json:"offeredCount"`
 	AcceptedCount  int     `gorm:"default:0" json:"acceptedCount"`
 	CompletedOnTime int    `gorm:"default:0" json:"completedOnTime"`
 
+	WeeklyAcceptanceRate float64    `gorm:"default:0" json:"weeklyAcceptanceRate"`
+	WeeklyOnTimeRate     float64    `gorm:"default:0" json:"weeklyOnTimeRate"`
+	WeeklyCSATScore      float64    `gorm:"default:0" json:"weeklyCsatScore"`
+	WeeklyCompletionRate float64    `gorm:"default:0" json:"weeklyCompletionRate"`
+	MetricsUpdatedAt     *time.Time `gorm:"" json:"metricsUpdatedAt,omitempty"`
+
 	// Verification
 	VerificationStatus VerificationStatus `gorm:"type:varchar(20);default:'pending'" json:"verificationStatus"`
 	VerifiedByID       *uuid.UUID         `gorm:"type:uuid" json:"verifiedById,omitempty"`
@@ -223,9 +275,39 @@ type Delivery struct {
 	ActualDuration    int     `gorm:"" json:"actualDuration"`
 
 	// Retry / failure tracking
-	AttemptNumber int    `gorm:"default:1" json:"attemptNumber"`
-	MaxAttempts   int    `gorm:"default:3" json:"maxAttempts"`
-	FailureReason string `gorm:"" json:"failureReason,omitempty"` // customer_unavailable, wrong_address, refused, etc.
+	AttemptNumber   int           `gorm:"default:1" json:"attemptNumber"`
+	MaxAttempts     int           `gorm:"default:3" json:"maxAttempts"`
+	FailureReason   FailureReason `gorm:"type:varchar(30)" json:"failureReason,omitempty"`
+	FailedAt        *time.Time    `gorm:"" json:"failedAt,omitempty"`
+	RetryDeadline   *time.Time    `gorm:"index" json:"retryDeadline,omitempty"` // customer may ask for another attempt until then
+	ReturnStartedAt *time.Time    `gorm:"" json:"returnStartedAt,omitempty"`    // driver sent back to the kitchen
+	ReturnedAt      *time.Time    `gorm:"" json:"returnedAt,omitempty"`
+
+	// Pickup verification — the chef gives the driver this code once they have
+	// checked it is the assigned driver at the door
+	PickupCode         string     `gorm:"type:varchar(6)" json:"-"`
+	PickupCodeAttempts int        `gorm:"default:0" json:"-"` // wrong codes entered
+	PickupVerifiedAt   *time.Time `gorm:"" json:"pickupVerifiedAt,omitempty"`
+
+	// Proof of delivery — the customer reads the handover code to the driver at
+	// the door; evidence is kept for disputes
+	HandoverCode           string     `gorm:"type:varchar(6)" json:"-"`
+	HandoverAttempts       int        `gorm:"default:0" json:"-"` // wrong codes entered
+	HandoverVerifiedAt     *time.Time `gorm:"" json:"handoverVerifiedAt,omitempty"`
+	HandoverLatitude       float64    `gorm:"" json:"handoverLatitude,omitempty"` // driver position when completed
+	HandoverLongitude      float64    `gorm:"" json:"handoverLongitude,omitempty"`
+	ProofPhotoPath         string     `gorm:"" json:"-"`
+	ProofPhotoAt           *time.Time `gorm:"" json:"proofPhotoAt,omitempty"`
+	HandoverOverrideByID   *uuid.UUID `gorm:"type:uuid" json:"handoverOverrideById,omitempty"`
+	HandoverOverrideReason string     `gorm:"type:text" json:"handoverOverrideReason,omitempty"`
+
+	// Batching — stops are numbered across all of the driver's active deliveries;
+	// a sequence of 0 means the stop is done
+	BatchID           *uuid.UUID `gorm:"type:uuid;index" json:"batchId,omitempty"`
+	PickupSequence    int        `gorm:"default:0" json:"pickupSequence"`
+	DropoffSequence   int        `gorm:"default:0" json:"dropoffSequence"`
+	PickupStopStatus  StopStatus `gorm:"type:varchar(20);default:'pending'" json:"pickupStopStatus"`
+	DropoffStopStatus StopStatus `gorm:"type:varchar(20);default:'pending'" json:"dropoffStopStatus"`
 
 	// Assignment tracking
 	AssignmentType AssignmentType `gorm:"type:varchar(20);default:'manual'" json:"assignmentType"`
@@ -283,6 +365,10 @@ type DeliveryResponse struct {
 	AssignedAt        time.Time      `json:"assignedAt"`
 	PickedUpAt        *time.Time     `json:"pickedUpAt,omitempty"`
 	DeliveredAt       *time.Time     `json:"deliveredAt,omitempty"`
+	AttemptNumber     int            `json:"attemptNumber"`
+	FailureReason     FailureReason  `json:"failureReason,omitempty"`
+	RetryDeadline     *time.Time     `json:"retryDeadline,omitempty"`
+	ReturnStartedAt   *time.Time     `json:"returnStartedAt,omitempty"`
 }
 
 func (d *Delivery) ToResponse() DeliveryResponse {
@@ -296,6 +382,10 @@ func (d *Delivery) ToResponse() DeliveryResponse {
 		AssignedAt:        d.AssignedAt,
 		PickedUpAt:        d.PickedUpAt,
 		DeliveredAt:       d.DeliveredAt,
+		AttemptNumber:     d.AttemptNumber,
+		FailureReason:     d.FailureReason,
+		RetryDeadline:     d.RetryDeadline,
+		ReturnStartedAt:   d.ReturnStartedAt,
 	}
 }
 
@@ -328,6 +418,12 @@ type DeliveryPartnerDetailResponse struct {
 	AcceptanceRate     float64            `json:"acceptanceRate"`
 	OnTimeRate         float64            `json:"onTimeRate"`
 	CSATScore          float64            `json:"csatScore"`
+	CompletionRate     float64            `json:"completionRate"`
+	WeeklyAcceptanceRate float64          `json:"weeklyAcceptanceRate"`
+	WeeklyOnTimeRate   float64            `json:"weeklyOnTimeRate"`
+	WeeklyCSATScore    float64            `json:"weeklyCsatScore"`
+	WeeklyCompletionRate float64          `json:"weeklyCompletionRate"`
+	MetricsUpdatedAt   *time.Time         `json:"metricsUpdatedAt,omitempty"`
 	OfferedCount       int                `json:"offeredCount"`
 	AcceptedCount      int                `json:"acceptedCount"`
 	CompletedOnTime    int                `json:"completedOnTime"`
@@ -373,6 +469,12 @@ func (p *DeliveryPartner) ToDetailResponse() DeliveryPartnerDetailResponse {
 		AcceptanceRate:     p.AcceptanceRate,
 		OnTimeRate:         p.OnTimeRate,
 		CSATScore:          p.CSATScore,
+		CompletionRate:     p.CompletionRate,
+		WeeklyAcceptanceRate: p.WeeklyAcceptanceRate,
+		WeeklyOnTimeRate:   p.WeeklyOnTimeRate,
+		WeeklyCSATScore:    p.WeeklyCSATScore,
+		WeeklyCompletionRate: p.WeeklyCompletionRate,
+		MetricsUpdatedAt:   p.MetricsUpdatedAt,
 		OfferedCount:       p.OfferedCount,
 		AcceptedCount:      p.AcceptedCount,
 		CompletedOnTime:    p.CompletedOnTime,
@@ -432,3 +534,87 @@ type DriverReferral struct {
 	Referrer DeliveryPartner `gorm:"foreignKey:ReferrerID" json:"referrer,omitempty"`
 	Referee  DeliveryPartner `gorm:"foreignKey:RefereeID" json:"referee,omitempty"`
 }
+
+// Location history
+
+// DeliveryLocationPing is one accepted driver position. Pings are append-only;
+// a ping taken while carrying several orders is stored once per delivery so
+// each delivery's route can be replayed on its own. DeliveryID is nil for pings
+// sent while the partner had no active delivery.
+type DeliveryLocationPing struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	PartnerID  uuid.UUID  `gorm:"type:uuid;not null;index:idx_location_pings_partner_time,priority:1" json:"partnerId"`
+	DeliveryID *uuid.UUID `gorm:"type:uuid;index:idx_location_pings_delivery_time,priority:1" json:"deliveryId,omitempty"`
+	Latitude   float64    `gorm:"not null" json:"latitude"`
+	Longitude  float64    `gorm:"not null" json:"longitude"`
+	Accuracy   float64    `gorm:"default:0" json:"accuracy,omitempty"` // metres, as reported by the device
+	RecordedAt time.Time  `gorm:"not null;index:idx_location_pings_partner_time,priority:2;index:idx_location_pings_delivery_time,priority:2;index" json:"recordedAt"`
+}
+
+// LocationJumpCandidate is a position that jumped too far from a partner's last
+// stored ping, kept until enough further pings confirm or replace it. One row
+// per partner.
+type LocationJumpCandidate struct {
+	PartnerID  uuid.UUID `gorm:"type:uuid;primaryKey" json:"partnerId"`
+	Latitude   float64   `gorm:"not null" json:"latitude"`
+	Longitude  float64   `gorm:"not null" json:"longitude"`
+	RecordedAt time.Time `gorm:"not null" json:"recordedAt"`
+	Pings      int       `gorm:"not null" json:"pings"`
+}
+
+// Dispatch offers
+
+type DeliveryOfferStatus string
+
+const (
+	OfferPending   DeliveryOfferStatus = "pending"
+	OfferAccepted  DeliveryOfferStatus = "accepted"
+	OfferDeclined  DeliveryOfferStatus = "declined"
+	OfferExpired   DeliveryOfferStatus = "expired"
+	OfferWithdrawn DeliveryOfferStatus = "withdrawn" // order was taken or called off before an answer
+)
+
+// DeliveryOffer records each partner the dispatcher offered an order to. A
+// partner is offered a given order at most once.
+type DeliveryOffer struct {
+	ID          uuid.UUID           `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	DeliveryID  uuid.UUID           `gorm:"type:uuid;not null;index" json:"deliveryId"`
+	OrderID     uuid.UUID           `gorm:"type:uuid;not null;uniqueIndex:idx_delivery_offers_order_partner" json:"orderId"`
+	PartnerID   uuid.UUID           `gorm:"type:uuid;not null;uniqueIndex:idx_delivery_offers_order_partner;index" json:"partnerId"`
+	Status      DeliveryOfferStatus `gorm:"type:varchar(20);default:'pending';index" json:"status"`
+	Distance    float64             `gorm:"" json:"distance"` // partner to kitchen, km
+	Score       float64             `gorm:"" json:"score"`
+	ExpiresAt   time.Time           `gorm:"not null" json:"expiresAt"`
+	RespondedAt *time.Time          `gorm:"" json:"respondedAt,omitempty"`
+	CreatedAt   time.Time           `gorm:"autoCreateTime" json:"createdAt"`
+}
+
+// DeliveryBatch groups the deliveries a driver carries at the same time
+type DeliveryBatch struct {
+	ID          uuid.UUID   `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	PartnerID   uuid.UUID   `gorm:"type:uuid;not null;index" json:"partnerId"`
+	Status      BatchStatus `gorm:"type:varchar(20);default:'active';index" json:"status"`
+	CompletedAt *time.Time  `gorm:"" json:"completedAt,omitempty"`
+	CreatedAt   time.Time   `gorm:"autoCreateTime" json:"createdAt"`
+	UpdatedAt   time.Time   `gorm:"autoUpdateTime" json:"updatedAt"`
+
+	Deliveries []Delivery `gorm:"foreignKey:BatchID" json:"deliveries,omitempty"`
+}
+
+// DeliveryAttempt records a leg of a delivery that did not end in a handover:
+// each failed attempt at the customer's door and the trip back to the kitchen.
+// Payout is what the driver was paid for that leg.
+type DeliveryAttempt struct {
+	ID            uuid.UUID              `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	DeliveryID    uuid.UUID              `gorm:"type:uuid;not null;index" json:"deliveryId"`
+	OrderID       uuid.UUID              `gorm:"type:uuid;not null;index" json:"orderId"`
+	PartnerID     uuid.UUID              `gorm:"type:uuid;not null;index" json:"partnerId"`
+	AttemptNumber int                    `gorm:"not null" json:"attemptNumber"`
+	Outcome       DeliveryAttemptOutcome `gorm:"type:varchar(20);not null" json:"outcome"`
+	FailureReason FailureReason          `gorm:"type:varchar(30)" json:"failureReason,omitempty"`
+	Notes         string                 `gorm:"type:text" json:"notes,omitempty"`
+	Payout        float64                `gorm:"default:0" json:"payout"`
+	StartedAt     time.Time              `gorm:"not null" json:"startedAt"`
+	EndedAt       time.Time              `gorm:"not null" json:"endedAt"`
+	CreatedAt     time.Time              `gorm:"autoCreateTime" json:"createdAt"`
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type DriverMetricKind string
+
+const (
+	MetricOffered   DriverMetricKind = "offered"
+	MetricAccepted  DriverMetricKind = "accepted"
+	MetricDeclined  DriverMetricKind = "declined"
+	MetricExpired   DriverMetricKind = "expired" // offer lapsed unanswered
+	MetricDelivered DriverMetricKind = "delivered"
+	MetricFailed    DriverMetricKind = "failed"
+	MetricDropped   DriverMetricKind = "dropped" // handed back after accepting
+	MetricRated     DriverMetricKind = "rated"
+)
+
+// DriverMetricEvent is one delivery event counted towards a partner's
+// performance metrics
+type DriverMetricEvent struct {
+	ID          uuid.UUID        `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	PartnerID   uuid.UUID        `gorm:"type:uuid;not null;index:idx_metric_event_partner" json:"partnerId"`
+	DeliveryID  *uuid.UUID       `gorm:"type:uuid;index" json:"deliveryId,omitempty"`
+	Kind        DriverMetricKind `gorm:"type:varchar(20);not null" json:"kind"`
+	OnTime      *bool            `gorm:"" json:"onTime,omitempty"`     // delivered; nil when no promise was made
+	LateMinutes int              `gorm:"default:0" json:"lateMinutes"` // delivered
+	AtFault     bool             `gorm:"default:false" json:"atFault"` // failed through the driver's doing
+	Rating      int              `gorm:"default:0" json:"rating"`      // rated, 1-5
+	OccurredAt  time.Time        `gorm:"not null;index:idx_metric_event_partner;index" json:"occurredAt"`
+}
+
+// DriverMetricSnapshot is a partner's metrics over a rolling window as of one
+// day, kept for trends
+type DriverMetricSnapshot struct {
+	ID             uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	PartnerID      uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_metric_snapshot" json:"partnerId"`
+	Date           time.Time `gorm:"type:date;not null;uniqueIndex:idx_metric_snapshot" json:"date"`
+	WindowDays     int       `gorm:"not null;uniqueIndex:idx_metric_snapshot" json:"windowDays"`
+	Offers         int       `gorm:"default:0" json:"offers"`
+	Accepted       int       `gorm:"default:0" json:"accepted"`
+	Declined       int       `gorm:"default:0" json:"declined"`
+	Expired        int       `gorm:"default:0" json:"expired"`
+	Delivered      int       `gorm:"default:0" json:"delivered"`
+	OnTime         int       `gorm:"default:0" json:"onTime"`
+	Late           int       `gorm:"default:0" json:"late"`
+	AvgLateMinutes float64   `gorm:"default:0" json:"avgLateMinutes"`
+	Failed         int       `gorm:"default:0" json:"failed"`
+	FailedAtFault  int       `gorm:"default:0" json:"failedAtFault"`
+	Dropped        int       `gorm:"default:0" json:"dropped"`
+	Ratings        int       `gorm:"default:0" json:"ratings"`
+	AvgRating      float64   `gorm:"default:0" json:"avgRating"`
+	AcceptanceRate float64   `gorm:"default:0" json:"acceptanceRate"`
+	OnTimeRate     float64   `gorm:"default:0" json:"onTimeRate"`
+	CompletionRate float64   `gorm:"default:0" json:"completionRate"`
+	CSATScore      float64   `gorm:"default:0" json:"csatScore"`
+	UpdatedAt      time.Time `gorm:"autoUpdateTime" json:"updatedAt"`
+}
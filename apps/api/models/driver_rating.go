@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Reason tags a customer can attach to a delivery rating
+const (
+	DriverTagLate                = "late"
+	DriverTagRude                = "rude"
+	DriverTagFoodSpilled         = "food_spilled"
+	DriverTagIgnoredInstructions = "ignored_instructions"
+	DriverTagFriendly            = "friendly"
+	DriverTagFast                = "fast"
+)
+
+// DriverRating is a customer's rating of the partner who delivered their
+// order, taken from the delivery part of a review
+type DriverRating struct {
+	ID         uuid.UUID      `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	ReviewID   uuid.UUID      `gorm:"type:uuid;uniqueIndex;not null" json:"reviewId"`
+	OrderID    uuid.UUID      `gorm:"type:uuid;not null;index" json:"orderId"`
+	DeliveryID uuid.UUID      `gorm:"type:uuid;not null;index" json:"deliveryId"`
+	PartnerID  uuid.UUID      `gorm:"type:uuid;not null;index:idx_driver_rating_partner" json:"partnerId"`
+	CustomerID uuid.UUID      `gorm:"type:uuid;not null" json:"-"`
+	Rating     int            `gorm:"not null" json:"rating"` // 1-5
+	Tags       pq.StringArray `gorm:"type:text[]" json:"tags"`
+	Comment    string         `gorm:"type:text" json:"comment,omitempty"`
+	CreatedAt  time.Time      `gorm:"autoCreateTime;index:idx_driver_rating_partner" json:"createdAt"`
+}
+
+// DriverFeedbackResponse is a rating as the partner sees it, without the
+// customer's identity
+type DriverFeedbackResponse struct {
+	ID        uuid.UUID `json:"id"`
+	Rating    int       `json:"rating"`
+	Tags      []string  `json:"tags"`
+	Comment   string    `json:"comment,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func (r *DriverRating) ToFeedbackResponse() DriverFeedbackResponse {
+	tags := []string(r.Tags)
+	if tags == nil {
+		tags = []string{}
+	}
+	return DriverFeedbackResponse{
+		ID:        r.ID,
+		Rating:    r.Rating,
+		Tags:      tags,
+		Comment:   r.Comment,
+		CreatedAt: r.CreatedAt,
+	}
+}
@@ -13,6 +13,8 @@ type InvoiceLineItem struct {
 	Quantity  int     `json:"quantity"`
 	UnitPrice float64 `json:"unitPrice"`
 	Total     float64 `json:"total"`
+
+	Modifiers []SelectedModifier `json:"modifiers,omitempty"`
 }
 
 // OrderInvoice stores generated invoice data for customer food orders
@@ -41,6 +43,7 @@ type OrderInvoice struct {
 	FoodTaxPercent     float64 `gorm:"default:0" json:"foodTaxPercent"`
 	ServiceTaxPercent  float64 `gorm:"default:0" json:"serviceTaxPercent"`
 	DeliveryTaxPercent float64 `gorm:"default:0" json:"deliveryTaxPercent"`
+	PricesIncludeTax   bool    `gorm:"default:false" json:"pricesIncludeTax"` // Food tax is inside Subtotal
 
 	// Parties
 	CustomerName    string `gorm:"" json:"customerName"`
@@ -93,6 +96,7 @@ type OrderInvoiceResponse struct {
 	FoodTaxPercent     float64 `json:"foodTaxPercent"`
 	ServiceTaxPercent  float64 `json:"serviceTaxPercent"`
 	DeliveryTaxPercent float64 `json:"deliveryTaxPercent"`
+	PricesIncludeTax   bool    `json:"pricesIncludeTax"`
 
 	// Parties
 	CustomerName    string `json:"customerName"`
@@ -146,6 +150,7 @@ func (inv *OrderInvoice) ToResponse() OrderInvoiceResponse {
 		FoodTaxPercent:     inv.FoodTaxPercent,
 		ServiceTaxPercent:  inv.ServiceTaxPercent,
 		DeliveryTaxPercent: inv.DeliveryTaxPercent,
+		PricesIncludeTax:   inv.PricesIncludeTax,
 		CustomerName:       inv.CustomerName,
 		CustomerEmail:      inv.CustomerEmail,
 		CustomerPhone:      inv.CustomerPhone,
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -41,6 +42,13 @@ type MenuItem struct {
 	SpiceLevel   int            `gorm:"default:0" json:"spiceLevel"`
 	IsAvailable  bool           `gorm:"default:true" json:"isAvailable"`
 	IsApproved   bool           `gorm:"default:false" json:"isApproved"`
+
+	// Daily portion stock. DailyLimit 0 means unlimited.
+	DailyLimit        int        `gorm:"default:0" json:"dailyLimit"`
+	RemainingQuantity int        `gorm:"default:0" json:"remainingQuantity"`
+	StockDate         string     `gorm:"type:varchar(10);default:''" json:"-"` // chef-local date RemainingQuantity belongs to
+	SoldOutAt         *time.Time `gorm:"" json:"soldOutAt,omitempty"`           // set when selling out switched the item off
+
 	IsFeatured   bool           `gorm:"default:false" json:"isFeatured"`
 	TotalOrders  int            `gorm:"default:0" json:"totalOrders"`
 	Rating       float64        `gorm:"default:0" json:"rating"`
@@ -54,6 +62,8 @@ type MenuItem struct {
 	Chef     ChefProfile     `gorm:"foreignKey:ChefID" json:"-"`
 	Category *MenuCategory   `gorm:"foreignKey:CategoryID" json:"-"`
 	Images   []MenuItemImage `gorm:"foreignKey:MenuItemID" json:"images,omitempty"`
+
+	ModifierGroups []MenuModifierGroup `gorm:"foreignKey:MenuItemID" json:"modifierGroups,omitempty"`
 }
 
 type MenuItemImage struct {
@@ -67,6 +77,114 @@ type MenuItemImage struct {
 	MenuItem MenuItem `gorm:"foreignKey:MenuItemID" json:"-"`
 }
 
+// MenuModifierGroup is a set of choices on a menu item, e.g. a "Portion" group
+// where exactly one of half/full plate must be picked, or optional "Add-ons"
+type MenuModifierGroup struct {
+	ID          uuid.UUID      `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	MenuItemID  uuid.UUID      `gorm:"type:uuid;not null;index" json:"menuItemId"`
+	Name        string         `gorm:"not null" json:"name"`
+	MinSelect   int            `gorm:"default:0" json:"minSelect"`
+	MaxSelect   int            `gorm:"not null" json:"maxSelect"` // 0 = no limit
+	IsRequired  bool           `gorm:"default:false" json:"isRequired"`
+	IsAvailable bool           `gorm:"default:true" json:"isAvailable"`
+	SortOrder   int            `gorm:"default:0" json:"sortOrder"`
+	CreatedAt   time.Time      `gorm:"autoCreateTime" json:"createdAt"`
+	UpdatedAt   time.Time      `gorm:"autoUpdateTime" json:"updatedAt"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+
+	Options []MenuModifierOption `gorm:"foreignKey:GroupID" json:"options"`
+}
+
+// MenuModifierOption is a single choice in a modifier group, priced as a delta
+// on the item's base price
+type MenuModifierOption struct {
+	ID          uuid.UUID      `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	GroupID     uuid.UUID      `gorm:"type:uuid;not null;index" json:"groupId"`
+	Name        string         `gorm:"not null" json:"name"`
+	PriceDelta  float64        `gorm:"default:0" json:"priceDelta"`
+	IsAvailable bool           `gorm:"default:true" json:"isAvailable"`
+	SortOrder   int            `gorm:"default:0" json:"sortOrder"`
+	CreatedAt   time.Time      `gorm:"autoCreateTime" json:"createdAt"`
+	UpdatedAt   time.Time      `gorm:"autoUpdateTime" json:"updatedAt"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// SelectedModifier is a snapshot of a chosen option, stored on order items and
+// invoice lines so later menu edits don't rewrite history
+type SelectedModifier struct {
+	GroupID    uuid.UUID `json:"groupId"`
+	GroupName  string    `json:"groupName"`
+	OptionID   uuid.UUID `json:"optionId"`
+	OptionName string    `json:"optionName"`
+	PriceDelta float64   `json:"priceDelta"`
+}
+
+// MinSelections is the fewest options a customer must pick from the group
+func (g *MenuModifierGroup) MinSelections() int {
+	if g.IsRequired && g.MinSelect < 1 {
+		return 1
+	}
+	return g.MinSelect
+}
+
+// SelectModifiers validates a customer's chosen options against the item's
+// modifier groups and returns the snapshot plus the total price delta. The
+// item must be loaded with ModifierGroups.Options. Errors are customer-facing.
+func (m *MenuItem) SelectModifiers(optionIDs []uuid.UUID) ([]SelectedModifier, float64, error) {
+	chosen := make(map[uuid.UUID]bool, len(optionIDs))
+	for _, id := range optionIDs {
+		if chosen[id] {
+			return nil, 0, fmt.Errorf("%s: an option was selected twice", m.Name)
+		}
+		chosen[id] = true
+	}
+
+	selected := []SelectedModifier{}
+	var delta float64
+	matched := 0
+	for _, group := range m.ModifierGroups {
+		count := 0
+		for _, opt := range group.Options {
+			if !chosen[opt.ID] {
+				continue
+			}
+			matched++
+			if !group.IsAvailable || !opt.IsAvailable {
+				return nil, 0, fmt.Errorf("%s: %s is not available", m.Name, opt.Name)
+			}
+			count++
+			delta += opt.PriceDelta
+			selected = append(selected, SelectedModifier{
+				GroupID:    group.ID,
+				GroupName:  group.Name,
+				OptionID:   opt.ID,
+				OptionName: opt.Name,
+				PriceDelta: opt.PriceDelta,
+			})
+		}
+
+		if !group.IsAvailable {
+			continue
+		}
+		if count < group.MinSelections() {
+			return nil, 0, fmt.Errorf("%s: choose at least %d for %s", m.Name, group.MinSelections(), group.Name)
+		}
+		if group.MaxSelect > 0 && count > group.MaxSelect {
+			return nil, 0, fmt.Errorf("%s: choose at most %d for %s", m.Name, group.MaxSelect, group.Name)
+		}
+	}
+
+	if matched != len(chosen) {
+		return nil, 0, fmt.Errorf("%s: an option is not on the menu", m.Name)
+	}
+	// Discounting options can never take the item below free
+	if m.Price+delta < 0 {
+		return nil, 0, fmt.Errorf("%s: this combination of options is not available", m.Name)
+	}
+
+	return selected, RoundAmount(delta), nil
+}
+
 // DTOs
 
 type MenuItemImageResponse struct {
@@ -87,6 +205,7 @@ type MenuItemResponse struct {
 	ComparePrice float64                `json:"comparePrice,omitempty"`
 	ImageURL     string                 `json:"imageUrl,omitempty"`
 	Images       []MenuItemImageResponse `json:"images"`
+	ModifierGroups []MenuModifierGroup  `json:"modifierGroups"`
 	PrepTime     int                    `json:"prepTime"`
 	PortionSize  string                 `json:"portionSize,omitempty"`
 	Serves       int                    `json:"serves"`
@@ -96,6 +215,8 @@ type MenuItemResponse struct {
 	IsAvailable  bool                   `json:"isAvailable"`
 	IsFeatured   bool                   `json:"isFeatured"`
 	Rating       float64                `json:"rating"`
+	RemainingToday *int                 `json:"remainingToday,omitempty"` // only for items with a daily limit
+	SoldOut        bool                 `json:"soldOut"`
 }
 
 func (m *MenuItem) ToResponse() MenuItemResponse {
@@ -120,6 +241,27 @@ func (m *MenuItem) ToResponse() MenuItemResponse {
 		}
 	}
 
+	// Hide groups and options the chef has switched off
+	groups := []MenuModifierGroup{}
+	for _, g := range m.ModifierGroups {
+		if !g.IsAvailable {
+			continue
+		}
+		options := []MenuModifierOption{}
+		for _, opt := range g.Options {
+			if opt.IsAvailable {
+				options = append(options, opt)
+			}
+		}
+		g.Options = options
+		groups = append(groups, g)
+	}
+
+	var remaining *int
+	if m.DailyLimit > 0 {
+		remaining = &m.RemainingQuantity
+	}
+
 	return MenuItemResponse{
 		ID:           m.ID,
 		ChefID:       m.ChefID,
@@ -130,6 +272,7 @@ func (m *MenuItem) ToResponse() MenuItemResponse {
 		ComparePrice: m.ComparePrice,
 		ImageURL:     m.ImageURL,
 		Images:       images,
+		ModifierGroups: groups,
 		PrepTime:     m.PrepTime,
 		PortionSize:  m.PortionSize,
 		Serves:       m.Serves,
@@ -139,5 +282,7 @@ func (m *MenuItem) ToResponse() MenuItemResponse {
 		IsAvailable:  m.IsAvailable,
 		IsFeatured:   m.IsFeatured,
 		Rating:       m.Rating,
+		RemainingToday: remaining,
+		SoldOut:        m.SoldOutAt != nil,
 	}
 }
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -10,15 +11,16 @@ import (
 type OrderStatus string
 
 const (
-	OrderStatusPending    OrderStatus = "pending"
-	OrderStatusAccepted   OrderStatus = "accepted"
-	OrderStatusPreparing  OrderStatus = "preparing"
-	OrderStatusReady      OrderStatus = "ready"
-	OrderStatusPickedUp   OrderStatus = "picked_up"
-	OrderStatusDelivering OrderStatus = "delivering"
-	OrderStatusDelivered  OrderStatus = "delivered"
-	OrderStatusCancelled  OrderStatus = "cancelled"
-	OrderStatusRefunded   OrderStatus = "refunded"
+	OrderStatusPending        OrderStatus = "pending"
+	OrderStatusAccepted       OrderStatus = "accepted"
+	OrderStatusPreparing      OrderStatus = "preparing"
+	OrderStatusReady          OrderStatus = "ready"
+	OrderStatusDriverAssigned OrderStatus = "driver_assigned" // a driver has taken the order and is on the way to the kitchen
+	OrderStatusPickedUp       OrderStatus = "picked_up"
+	OrderStatusDelivering     OrderStatus = "delivering"
+	OrderStatusDelivered      OrderStatus = "delivered"
+	OrderStatusCancelled      OrderStatus = "cancelled"
+	OrderStatusRefunded       OrderStatus = "refunded"
 )
 
 type PaymentStatus string
@@ -39,6 +41,7 @@ type Order struct {
 	Status        OrderStatus   `gorm:"type:varchar(20);default:'pending'" json:"status"`
 	PaymentStatus PaymentStatus `gorm:"type:varchar(20);default:'pending'" json:"paymentStatus"`
 	PaymentMethod string        `gorm:"" json:"paymentMethod"`
+	PaymentMethodID *uuid.UUID  `gorm:"type:uuid" json:"paymentMethodId,omitempty"` // Saved method chosen at checkout
 
 	// Pricing
 	Subtotal    float64 `gorm:"not null" json:"subtotal"`
@@ -51,6 +54,20 @@ type Order struct {
 	Discount    float64 `gorm:"default:0" json:"discount"`
 	Total       float64 `gorm:"not null" json:"total"`
 	PromoCode   string  `gorm:"" json:"promoCode,omitempty"`
+	PromoCodeID *uuid.UUID `gorm:"type:uuid;index" json:"promoCodeId,omitempty"`
+	DiscountFundedBy string `gorm:"type:varchar(20)" json:"discountFundedBy,omitempty"` // platform or chef
+
+	// Tax snapshot taken at checkout so the invoice and payment always match the order.
+	// Tax above is FoodTax + ServiceTax + DeliveryTax.
+	FoodTax            float64 `gorm:"default:0" json:"foodTax"`
+	ServiceTax         float64 `gorm:"default:0" json:"serviceTax"`
+	DeliveryTax        float64 `gorm:"default:0" json:"deliveryTax"`
+	TaxCountryCode     string  `gorm:"type:varchar(2)" json:"taxCountryCode,omitempty"`
+	TaxName            string  `gorm:"" json:"taxName,omitempty"`
+	FoodTaxPercent     float64 `gorm:"default:0" json:"foodTaxPercent"`
+	ServiceTaxPercent  float64 `gorm:"default:0" json:"serviceTaxPercent"`
+	DeliveryTaxPercent float64 `gorm:"default:0" json:"deliveryTaxPercent"`
+	PricesIncludeTax   bool    `gorm:"default:false" json:"pricesIncludeTax"` // Food tax is inside Subtotal
 
 	// Delivery Address
 	DeliveryAddressLine1      string  `gorm:"" json:"deliveryAddressLine1"`
@@ -65,9 +82,10 @@ type Order struct {
 	// Timing
 	EstimatedPrepTime     int        `gorm:"" json:"estimatedPrepTime"` // minutes
 	EstimatedDeliveryTime int        `gorm:"" json:"estimatedDeliveryTime"`
-	ScheduledFor          *time.Time `gorm:"" json:"scheduledFor,omitempty"`
+	ScheduledFor          *time.Time `gorm:"index" json:"scheduledFor,omitempty"`
 	AcceptedAt            *time.Time `gorm:"" json:"acceptedAt,omitempty"`
 	PreparedAt            *time.Time `gorm:"" json:"preparedAt,omitempty"`
+	DriverAssignedAt      *time.Time `gorm:"" json:"driverAssignedAt,omitempty"`
 	PickedUpAt            *time.Time `gorm:"" json:"pickedUpAt,omitempty"`
 	DeliveredAt           *time.Time `gorm:"" json:"deliveredAt,omitempty"`
 	CancelledAt           *time.Time `gorm:"" json:"cancelledAt,omitempty"`
@@ -110,12 +128,53 @@ type OrderItem struct {
 	Quantity   int       `gorm:"not null" json:"quantity"`
 	Subtotal   float64   `gorm:"not null" json:"subtotal"`
 	Notes      string    `gorm:"" json:"notes,omitempty"`
+	BasePrice  float64   `gorm:"default:0" json:"basePrice"`         // menu price before modifiers; Price includes them
+	Modifiers  string    `gorm:"type:jsonb;default:'[]'" json:"-"` // []SelectedModifier snapshot
 	CreatedAt  time.Time `gorm:"autoCreateTime" json:"createdAt"`
 
 	Order    Order    `gorm:"foreignKey:OrderID" json:"-"`
 	MenuItem MenuItem `gorm:"foreignKey:MenuItemID" json:"menuItem,omitempty"`
 }
 
+// SelectedModifiers returns the options chosen for this item at checkout
+func (i *OrderItem) SelectedModifiers() []SelectedModifier {
+	var modifiers []SelectedModifier
+	if i.Modifiers != "" {
+		json.Unmarshal([]byte(i.Modifiers), &modifiers)
+	}
+	if modifiers == nil {
+		modifiers = []SelectedModifier{}
+	}
+	return modifiers
+}
+
+// ChefFundedDiscount returns the part of the discount the chef absorbs out of
+// their food revenue. Platform-funded discounts leave chef payouts untouched.
+func (o *Order) ChefFundedDiscount() float64 {
+	if o.DiscountFundedBy == string(PromoFundedByChef) {
+		return o.Discount
+	}
+	return 0
+}
+
+// HasTaxSnapshot reports whether tax was priced by the tax engine at checkout.
+// Older orders only carry a flat Tax amount.
+func (o *Order) HasTaxSnapshot() bool {
+	return o.TaxCountryCode != ""
+}
+
+// ChefTaxShare returns the tax collected on top of the food subtotal, which is
+// passed on to the chef with the food payment
+func (o *Order) ChefTaxShare() float64 {
+	if !o.HasTaxSnapshot() {
+		return o.Tax
+	}
+	if o.PricesIncludeTax {
+		return 0
+	}
+	return o.FoodTax
+}
+
 // DTOs
 type OrderResponse struct {
 	ID              uuid.UUID              `json:"id"`
@@ -126,8 +185,14 @@ type OrderResponse struct {
 	DeliveryFee     float64                `json:"deliveryFee"`
 	ServiceFee      float64                `json:"serviceFee"`
 	Tax             float64                `json:"tax"`
+	TaxName         string                 `json:"taxName,omitempty"`
+	FoodTax         float64                `json:"foodTax"`
+	ServiceTax      float64                `json:"serviceTax"`
+	DeliveryTax     float64                `json:"deliveryTax"`
+	TaxIncluded     bool                   `json:"taxIncluded"`
 	Tip             float64                `json:"tip"`
 	Discount        float64                `json:"discount"`
+	PromoCode       string                 `json:"promoCode,omitempty"`
 	Total           float64                `json:"total"`
 	Items           []OrderItemResponse    `json:"items"`
 	DeliveryAddress AddressResponse        `json:"deliveryAddress"`
@@ -142,6 +207,7 @@ type OrderItemResponse struct {
 	Quantity   int       `json:"quantity"`
 	Subtotal   float64   `json:"subtotal"`
 	Notes      string    `json:"notes,omitempty"`
+	Modifiers  []SelectedModifier `json:"modifiers"`
 }
 
 type AddressResponse struct {
@@ -163,6 +229,7 @@ func (o *Order) ToResponse() OrderResponse {
 			Quantity:   item.Quantity,
 			Subtotal:   item.Subtotal,
 			Notes:      item.Notes,
+			Modifiers:  item.SelectedModifiers(),
 		}
 	}
 
@@ -175,8 +242,14 @@ func (o *Order) ToResponse() OrderResponse {
 		DeliveryFee:   o.DeliveryFee,
 		ServiceFee:    o.ServiceFee,
 		Tax:           o.Tax,
+		TaxName:       o.TaxName,
+		FoodTax:       o.FoodTax,
+		ServiceTax:    o.ServiceTax,
+		DeliveryTax:   o.DeliveryTax,
+		TaxIncluded:   o.PricesIncludeTax,
 		Tip:           o.Tip,
 		Discount:      o.Discount,
+		PromoCode:     o.PromoCode,
 		Total:         o.Total,
 		Items:         items,
 		DeliveryAddress: AddressResponse{
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type OrderEventType string
+
+const (
+	OrderEventCreated  OrderEventType = "created"
+	OrderEventStatus   OrderEventType = "status"
+	OrderEventPayment  OrderEventType = "payment"
+	OrderEventDelivery OrderEventType = "delivery"
+	OrderEventNote     OrderEventType = "note"
+)
+
+// Where an order change originated
+const (
+	OrderEventSourceHandler = "handler"
+	OrderEventSourceWebhook = "webhook"
+	OrderEventSourceJob     = "job"
+)
+
+// OrderEventLog is an append-only record of a change to an order
+type OrderEventLog struct {
+	ID        uuid.UUID      `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	OrderID   uuid.UUID      `gorm:"type:uuid;not null;index:idx_order_event_logs_order_created" json:"orderId"`
+	EventType OrderEventType `gorm:"type:varchar(20);not null" json:"eventType"`
+	OldValue  string         `gorm:"type:varchar(50)" json:"oldValue,omitempty"`
+	NewValue  string         `gorm:"type:varchar(50)" json:"newValue,omitempty"`
+
+	// Actor
+	ActorID   *uuid.UUID `gorm:"type:uuid;index" json:"actorId,omitempty"`
+	ActorRole string     `gorm:"type:varchar(20)" json:"actorRole"` // customer, chef, driver, admin, system
+	Source    string     `gorm:"type:varchar(20)" json:"source"`    // handler, webhook, job
+
+	Notes     string    `gorm:"type:text" json:"notes,omitempty"`
+	CreatedAt time.Time `gorm:"autoCreateTime;index:idx_order_event_logs_order_created" json:"createdAt"`
+}
+
+// OrderTimelineEntry is the customer-facing view of an order event. Actor
+// identities, sources and internal notes are stripped.
+type OrderTimelineEntry struct {
+	EventType OrderEventType `json:"eventType"`
+	OldValue  string         `json:"oldValue,omitempty"`
+	NewValue  string         `json:"newValue,omitempty"`
+	ActorRole string         `json:"actorRole"`
+	CreatedAt time.Time      `json:"createdAt"`
+}
+
+// ToTimelineEntry returns the redacted customer view of the event
+func (e *OrderEventLog) ToTimelineEntry() OrderTimelineEntry {
+	role := e.ActorRole
+	if role == "admin" {
+		role = "support"
+	}
+	return OrderTimelineEntry{
+		EventType: e.EventType,
+		OldValue:  e.OldValue,
+		NewValue:  e.NewValue,
+		ActorRole: role,
+		CreatedAt: e.CreatedAt,
+	}
+}
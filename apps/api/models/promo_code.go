@@ -0,0 +1,104 @@
+package models
+
+import (
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PromoDiscountType string
+
+const (
+	PromoDiscountPercentage PromoDiscountType = "percentage"
+	PromoDiscountFlat       PromoDiscountType = "flat"
+)
+
+// PromoFundedBy records who absorbs the cost of a discount
+type PromoFundedBy string
+
+const (
+	PromoFundedByPlatform PromoFundedBy = "platform"
+	PromoFundedByChef     PromoFundedBy = "chef"
+)
+
+// PromoCode is a customer-facing coupon applied to the food subtotal at checkout
+type PromoCode struct {
+	ID            uuid.UUID         `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	Code          string            `gorm:"type:varchar(32);uniqueIndex;not null" json:"code"` // Stored uppercase
+	Description   string            `gorm:"type:text" json:"description"`
+	DiscountType  PromoDiscountType `gorm:"type:varchar(20);not null" json:"discountType"`
+	DiscountValue float64           `gorm:"not null" json:"discountValue"` // Percent (0-100) or flat amount
+	MaxDiscount   float64           `gorm:"default:0" json:"maxDiscount"`  // Cap for percentage codes, 0 = no cap
+	MinSubtotal   float64           `gorm:"default:0" json:"minSubtotal"`
+
+	// Validity window (nil = open-ended)
+	StartsAt *time.Time `gorm:"" json:"startsAt,omitempty"`
+	EndsAt   *time.Time `gorm:"" json:"endsAt,omitempty"`
+
+	// Usage limits (0 = unlimited)
+	MaxRedemptions  int  `gorm:"default:0" json:"maxRedemptions"`
+	PerUserLimit    int  `gorm:"not null" json:"perUserLimit"`
+	FirstOrderOnly  bool `gorm:"default:false" json:"firstOrderOnly"`
+	RedemptionCount int  `gorm:"default:0" json:"redemptionCount"`
+
+	// Scope (empty = everywhere)
+	ChefID  *uuid.UUID `gorm:"type:uuid;index" json:"chefId,omitempty"`
+	City    string     `gorm:"" json:"city,omitempty"`
+	Country string     `gorm:"type:varchar(2)" json:"country,omitempty"`
+
+	FundedBy  PromoFundedBy `gorm:"type:varchar(20);default:'platform'" json:"fundedBy"`
+	IsActive  bool          `gorm:"not null" json:"isActive"`
+	CreatedBy *uuid.UUID    `gorm:"type:uuid" json:"createdBy,omitempty"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"createdAt"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updatedAt"`
+}
+
+// PromoRedemption records a single use of a promo code against an order
+type PromoRedemption struct {
+	ID          uuid.UUID     `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	PromoCodeID uuid.UUID     `gorm:"type:uuid;not null;index" json:"promoCodeId"`
+	UserID      uuid.UUID     `gorm:"type:uuid;not null;index" json:"userId"`
+	OrderID     uuid.UUID     `gorm:"type:uuid;not null;uniqueIndex" json:"orderId"`
+	Discount    float64       `gorm:"not null" json:"discount"`
+	FundedBy    PromoFundedBy `gorm:"type:varchar(20);not null" json:"fundedBy"`
+	ReleasedAt  *time.Time    `gorm:"" json:"releasedAt,omitempty"` // order cancelled or refunded; no longer counts
+	CreatedAt   time.Time     `gorm:"autoCreateTime" json:"createdAt"`
+
+	PromoCode PromoCode `gorm:"foreignKey:PromoCodeID" json:"-"`
+}
+
+// IsLive reports whether the code is active and inside its validity window
+func (p *PromoCode) IsLive(now time.Time) bool {
+	if !p.IsActive {
+		return false
+	}
+	if p.StartsAt != nil && now.Before(*p.StartsAt) {
+		return false
+	}
+	if p.EndsAt != nil && now.After(*p.EndsAt) {
+		return false
+	}
+	return true
+}
+
+// CalculateDiscount returns the discount for a subtotal, applying the cap and
+// never exceeding the subtotal itself
+func (p *PromoCode) CalculateDiscount(subtotal float64) float64 {
+	var discount float64
+	switch p.DiscountType {
+	case PromoDiscountPercentage:
+		discount = subtotal * p.DiscountValue / 100
+		if p.MaxDiscount > 0 && discount > p.MaxDiscount {
+			discount = p.MaxDiscount
+		}
+	case PromoDiscountFlat:
+		discount = p.DiscountValue
+	}
+	discount = math.Min(discount, subtotal)
+	if discount < 0 {
+		return 0
+	}
+	return RoundAmount(discount)
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ShiftStatus string
+
+const (
+	ShiftOpen      ShiftStatus = "open"     // published, nobody on it yet
+	ShiftAssigned  ShiftStatus = "assigned" // claimed by or assigned to an agent
+	ShiftCancelled ShiftStatus = "cancelled"
+)
+
+// DriverShift is one slot on a zone's weekly roster for internal agents
+type DriverShift struct {
+	ID            uuid.UUID   `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	ZoneID        uuid.UUID   `gorm:"type:uuid;not null;index:idx_shift_zone_week" json:"zoneId"`
+	WeekStart     time.Time   `gorm:"type:date;not null;index:idx_shift_zone_week" json:"weekStart"` // Monday of the roster week
+	StartsAt      time.Time   `gorm:"not null;index" json:"startsAt"`
+	EndsAt        time.Time   `gorm:"not null" json:"endsAt"`
+	Status        ShiftStatus `gorm:"type:varchar(20);default:'open';index" json:"status"`
+	PartnerID     *uuid.UUID  `gorm:"type:uuid;index" json:"partnerId,omitempty"`
+	AssignedAt    *time.Time  `gorm:"" json:"assignedAt,omitempty"`
+	AssignedByID  *uuid.UUID  `gorm:"type:uuid" json:"assignedById,omitempty"` // nil when the agent claimed it
+	PublishedByID uuid.UUID   `gorm:"type:uuid;not null" json:"publishedById"`
+	Notes         string      `gorm:"type:text" json:"notes,omitempty"`
+	CreatedAt     time.Time   `gorm:"autoCreateTime" json:"createdAt"`
+	UpdatedAt     time.Time   `gorm:"autoUpdateTime" json:"updatedAt"`
+
+	Zone    DeliveryZone     `gorm:"foreignKey:ZoneID" json:"-"`
+	Partner *DeliveryPartner `gorm:"foreignKey:PartnerID" json:"-"`
+}
+
+// PartnerOnlineSession is one stretch a partner spent online, opened and
+// closed by ToggleOnline
+type PartnerOnlineSession struct {
+	ID           uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	PartnerID    uuid.UUID  `gorm:"type:uuid;not null;index:idx_online_session_partner" json:"partnerId"`
+	ShiftID      *uuid.UUID `gorm:"type:uuid;index" json:"shiftId,omitempty"`
+	StartedAt    time.Time  `gorm:"not null;index:idx_online_session_partner" json:"startedAt"`
+	EndedAt      *time.Time `gorm:"" json:"endedAt,omitempty"`
+	OutsideShift bool       `gorm:"default:false;index" json:"outsideShift"` // internal agent online with no shift
+}
+
+type DriverShiftResponse struct {
+	ID          uuid.UUID   `json:"id"`
+	ZoneID      uuid.UUID   `json:"zoneId"`
+	ZoneName    string      `json:"zoneName,omitempty"`
+	WeekStart   string      `json:"weekStart"`
+	StartsAt    time.Time   `json:"startsAt"`
+	EndsAt      time.Time   `json:"endsAt"`
+	Status      ShiftStatus `json:"status"`
+	PartnerID   *uuid.UUID  `json:"partnerId,omitempty"`
+	PartnerName string      `json:"partnerName,omitempty"`
+	AssignedAt  *time.Time  `json:"assignedAt,omitempty"`
+	Claimed     bool        `json:"claimed"`
+	Notes       string      `json:"notes,omitempty"`
+}
+
+func (s *DriverShift) ToResponse() DriverShiftResponse {
+	resp := DriverShiftResponse{
+		ID:         s.ID,
+		ZoneID:     s.ZoneID,
+		ZoneName:   s.Zone.Name,
+		WeekStart:  s.WeekStart.Format("2006-01-02"),
+		StartsAt:   s.StartsAt,
+		EndsAt:     s.EndsAt,
+		Status:     s.Status,
+		PartnerID:  s.PartnerID,
+		AssignedAt: s.AssignedAt,
+		Claimed:    s.PartnerID != nil && s.AssignedByID == nil,
+		Notes:      s.Notes,
+	}
+	if s.Partner != nil {
+		resp.PartnerName = s.Partner.User.FirstName + " " + s.Partner.User.LastName
+	}
+	return resp
+}
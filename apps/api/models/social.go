@@ -52,8 +52,8 @@ type Post struct {
 
 type PostLike struct {
 	ID        uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
-	PostID    uuid.UUID `gorm:"type:uuid;not null;index" json:"postId"`
-	UserID    uuid.UUID `gorm:"type:uuid;not null;index" json:"userId"`
+	PostID    uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_post_likes_post_user" json:"postId"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_post_likes_post_user;index" json:"userId"`
 	CreatedAt time.Time `gorm:"autoCreateTime" json:"createdAt"`
 
 	Post Post `gorm:"foreignKey:PostID" json:"-"`
@@ -75,6 +75,16 @@ type PostComment struct {
 	Replies []PostComment `gorm:"foreignKey:ParentID" json:"replies,omitempty"`
 }
 
+// PostReport is one user's report of a post; enough of them flag the post
+// for moderation
+type PostReport struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	PostID    uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_post_reports_post_user" json:"postId"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_post_reports_post_user;index" json:"userId"`
+	Reason    string    `gorm:"type:text;not null" json:"reason"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"createdAt"`
+}
+
 // DTOs
 type PostResponse struct {
 	ID            uuid.UUID       `json:"id"`
@@ -108,8 +118,10 @@ type MenuItemBasic struct {
 
 type CommentResponse struct {
 	ID        uuid.UUID         `json:"id"`
+	ParentID  *uuid.UUID        `json:"parentId,omitempty"`
 	Content   string            `json:"content"`
 	User      CommentUserInfo   `json:"user"`
+	IsHidden  bool              `json:"isHidden,omitempty"`
 	Replies   []CommentResponse `json:"replies,omitempty"`
 	CreatedAt time.Time         `json:"createdAt"`
 }
@@ -173,3 +185,29 @@ func (p *Post) ToResponse(userID *uuid.UUID) PostResponse {
 
 	return response
 }
+
+// ToResponse converts a comment and its loaded replies. Hidden replies are
+// skipped unless includeHidden is set (post owner and moderators).
+func (c *PostComment) ToResponse(includeHidden bool) CommentResponse {
+	response := CommentResponse{
+		ID:        c.ID,
+		ParentID:  c.ParentID,
+		Content:   c.Content,
+		IsHidden:  c.IsHidden,
+		CreatedAt: c.CreatedAt,
+		User: CommentUserInfo{
+			ID:        c.User.ID,
+			FirstName: c.User.FirstName,
+			Avatar:    c.User.Avatar,
+		},
+	}
+
+	for _, reply := range c.Replies {
+		if reply.IsHidden && !includeHidden {
+			continue
+		}
+		response.Replies = append(response.Replies, reply.ToResponse(includeHidden))
+	}
+
+	return response
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -86,21 +87,93 @@ type Address struct {
 	User User `gorm:"foreignKey:UserID" json:"-"`
 }
 
+// Payment gateways that can hold a saved payment method token
+const (
+	PaymentGatewayRazorpay = "razorpay"
+	PaymentGatewayStripe   = "stripe"
+)
+
+// Saved payment method types
+const (
+	PaymentMethodTypeCard = "card"
+	PaymentMethodTypeUPI  = "upi"
+)
+
+// PaymentMethod is a customer's saved, tokenized payment method. Card data never
+// touches our servers; we only keep the gateway's customer and token references
+// plus display details.
 type PaymentMethod struct {
-	ID              uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
-	UserID          uuid.UUID `gorm:"type:uuid;not null;index" json:"userId"`
-	StripePaymentID string    `gorm:"not null" json:"-"`
-	Type            string    `gorm:"not null" json:"type"` // card, bank_account
-	Last4           string    `gorm:"" json:"last4"`
-	Brand           string    `gorm:"" json:"brand"`
-	ExpMonth        int       `gorm:"" json:"expMonth"`
-	ExpYear         int       `gorm:"" json:"expYear"`
-	IsDefault       bool      `gorm:"default:false" json:"isDefault"`
-	CreatedAt       time.Time `gorm:"autoCreateTime" json:"createdAt"`
+	ID                uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	UserID            uuid.UUID `gorm:"type:uuid;not null;index" json:"userId"`
+	StripePaymentID   string    `gorm:"" json:"-"` // Legacy: Stripe payment method ID (see GatewayTokenID)
+	Gateway           string    `gorm:"type:varchar(20);not null;default:'razorpay'" json:"gateway"`
+	GatewayCustomerID string    `gorm:"" json:"-"` // e.g. Razorpay cust_xxx
+	GatewayTokenID    string    `gorm:"index" json:"-"` // e.g. Razorpay token_xxx, Stripe pm_xxx
+	Type              string    `gorm:"not null" json:"type"` // card, upi
+	Last4             string    `gorm:"" json:"last4"`
+	Brand             string    `gorm:"" json:"brand"`
+	ExpMonth          int       `gorm:"" json:"expMonth"`
+	ExpYear           int       `gorm:"" json:"expYear"`
+	VPA               string    `gorm:"" json:"-"` // UPI handle, e.g. name@okbank
+	Nickname          string    `gorm:"" json:"nickname"`
+	IsDefault         bool      `gorm:"default:false" json:"isDefault"`
+	CreatedAt         time.Time `gorm:"autoCreateTime" json:"createdAt"`
+	UpdatedAt         time.Time `gorm:"autoUpdateTime" json:"updatedAt"`
 
 	User User `gorm:"foreignKey:UserID" json:"-"`
 }
 
+type PaymentMethodResponse struct {
+	ID        uuid.UUID `json:"id"`
+	Gateway   string    `json:"gateway"`
+	Type      string    `json:"type"`
+	Last4     string    `json:"last4,omitempty"`
+	Brand     string    `json:"brand,omitempty"`
+	ExpMonth  int       `json:"expMonth,omitempty"`
+	ExpYear   int       `json:"expYear,omitempty"`
+	VPA       string    `json:"vpa,omitempty"` // Masked
+	Nickname  string    `json:"nickname,omitempty"`
+	IsDefault bool      `json:"isDefault"`
+	IsExpired bool      `json:"isExpired"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// IsExpired reports whether a card has passed its expiry month
+func (p *PaymentMethod) IsExpired(now time.Time) bool {
+	if p.Type != PaymentMethodTypeCard || p.ExpYear == 0 {
+		return false
+	}
+	year, month, _ := now.Date()
+	return p.ExpYear < year || (p.ExpYear == year && p.ExpMonth < int(month))
+}
+
+func (p *PaymentMethod) ToResponse() PaymentMethodResponse {
+	vpa := ""
+	if p.VPA != "" {
+		// Show the first two characters and the bank handle: ab****@okbank
+		if at := strings.Index(p.VPA, "@"); at > 2 {
+			vpa = p.VPA[:2] + strings.Repeat("*", at-2) + p.VPA[at:]
+		} else {
+			vpa = p.VPA
+		}
+	}
+
+	return PaymentMethodResponse{
+		ID:        p.ID,
+		Gateway:   p.Gateway,
+		Type:      p.Type,
+		Last4:     p.Last4,
+		Brand:     p.Brand,
+		ExpMonth:  p.ExpMonth,
+		ExpYear:   p.ExpYear,
+		VPA:       vpa,
+		Nickname:  p.Nickname,
+		IsDefault: p.IsDefault,
+		IsExpired: p.IsExpired(time.Now()),
+		CreatedAt: p.CreatedAt,
+	}
+}
+
 // DTOs for API responses
 type UserResponse struct {
 	ID                  uuid.UUID `json:"id"`
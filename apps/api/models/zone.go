@@ -22,7 +22,10 @@ type DeliveryZone struct {
 	MaxLongitude float64 `gorm:"" json:"maxLongitude"`
 
 	// Detailed boundary (GeoJSON)
-	Boundary string `gorm:"type:jsonb;default:'{}'" json:"boundary"` // GeoJSON polygon
+	Boundary string `gorm:"type:jsonb;default:'{}'" json:"boundary"` // GeoJSON Polygon or MultiPolygon
+
+	// Where zones overlap, the higher priority zone serves the point
+	Priority int `gorm:"default:0;index" json:"priority"`
 
 	// Pricing — all amounts in the zone's local currency
 	Currency        string  `gorm:"type:varchar(3);default:'INR'" json:"currency"` // ISO 4217 currency code
@@ -31,6 +34,17 @@ type DeliveryZone struct {
 	MinimumFare     float64 `gorm:"default:0" json:"minimumFare"`
 	SurgeMultiplier float64 `gorm:"default:1.0" json:"surgeMultiplier"`
 
+	// Automatic surge — the surge job moves SurgeMultiplier between the floor and
+	// ceiling one step at a time as demand outgrows the drivers online
+	AutoSurge       bool       `gorm:"not null;default:false" json:"autoSurge"`
+	SurgeFloor      float64    `gorm:"default:1.0" json:"surgeFloor"`
+	SurgeCeiling    float64    `gorm:"default:2.0" json:"surgeCeiling"`
+	SurgeStep       float64    `gorm:"default:0.25" json:"surgeStep"`
+	SurgeDemand     int        `gorm:"default:0" json:"surgeDemand"` // ready orders without a driver at the last run
+	SurgeSupply     int        `gorm:"default:0" json:"surgeSupply"` // online partners in the zone at the last run
+	SurgeComputedAt *time.Time `gorm:"" json:"surgeComputedAt,omitempty"`
+	SurgeChangedAt  *time.Time `gorm:"" json:"surgeChangedAt,omitempty"`
+
 	// Tipping
 	TipEnabled       bool    `gorm:"default:true" json:"tipEnabled"`
 	DefaultTipPercent float64 `gorm:"default:10" json:"defaultTipPercent"` // Suggested tip %
@@ -56,11 +70,17 @@ type DeliveryZoneResponse struct {
 	MaxLatitude         float64   `json:"maxLatitude"`
 	MinLongitude        float64   `json:"minLongitude"`
 	MaxLongitude        float64   `json:"maxLongitude"`
+	Boundary            string    `json:"boundary"`
+	Priority            int       `json:"priority"`
 	Currency            string    `json:"currency"`
 	BaseFare            float64   `json:"baseFare"`
 	PerKmRate           float64   `json:"perKmRate"`
 	MinimumFare         float64   `json:"minimumFare"`
 	SurgeMultiplier     float64   `json:"surgeMultiplier"`
+	AutoSurge           bool      `json:"autoSurge"`
+	SurgeFloor          float64   `json:"surgeFloor"`
+	SurgeCeiling        float64   `json:"surgeCeiling"`
+	SurgeStep           float64   `json:"surgeStep"`
 	TipEnabled          bool      `json:"tipEnabled"`
 	DefaultTipPercent   float64   `json:"defaultTipPercent"`
 	MaxTipAmount        float64   `json:"maxTipAmount"`
@@ -81,11 +101,17 @@ func (z *DeliveryZone) ToResponse() DeliveryZoneResponse {
 		MaxLatitude:         z.MaxLatitude,
 		MinLongitude:        z.MinLongitude,
 		MaxLongitude:        z.MaxLongitude,
+		Boundary:            z.Boundary,
+		Priority:            z.Priority,
 		Currency:            z.Currency,
 		BaseFare:            z.BaseFare,
 		PerKmRate:           z.PerKmRate,
 		MinimumFare:         z.MinimumFare,
 		SurgeMultiplier:     z.SurgeMultiplier,
+		AutoSurge:           z.AutoSurge,
+		SurgeFloor:          z.SurgeFloor,
+		SurgeCeiling:        z.SurgeCeiling,
+		SurgeStep:           z.SurgeStep,
 		TipEnabled:          z.TipEnabled,
 		DefaultTipPercent:   z.DefaultTipPercent,
 		MaxTipAmount:        z.MaxTipAmount,
@@ -94,3 +120,23 @@ func (z *DeliveryZone) ToResponse() DeliveryZoneResponse {
 		CreatedAt:           z.CreatedAt,
 	}
 }
+
+type SurgeSource string
+
+const (
+	SurgeSourceAuto  SurgeSource = "auto"  // surge job
+	SurgeSourceAdmin SurgeSource = "admin" // edited by hand
+)
+
+// ZoneSurgeChange records one change to a zone's surge multiplier
+type ZoneSurgeChange struct {
+	ID             uuid.UUID   `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	ZoneID         uuid.UUID   `gorm:"type:uuid;not null;index" json:"zoneId"`
+	FromMultiplier float64     `gorm:"not null" json:"fromMultiplier"`
+	ToMultiplier   float64     `gorm:"not null" json:"toMultiplier"`
+	Demand         int         `gorm:"default:0" json:"demand"`
+	Supply         int         `gorm:"default:0" json:"supply"`
+	Source         SurgeSource `gorm:"type:varchar(10);not null" json:"source"`
+	ChangedByID    *uuid.UUID  `gorm:"type:uuid" json:"changedById,omitempty"`
+	CreatedAt      time.Time   `gorm:"autoCreateTime;index" json:"createdAt"`
+}
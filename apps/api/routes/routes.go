@@ -43,6 +43,9 @@ func SetupRouter() *gin.Engine {
 	authHandler := handlers.NewAuthHandler()
 	chefHandler := handlers.NewChefHandler()
 	orderHandler := handlers.NewOrderHandler()
+	cartHandler := handlers.NewCartHandler()
+	socialHandler := handlers.NewSocialHandler()
+	cateringHandler := handlers.NewCateringHandler()
 	healthHandler := handlers.NewHealthHandler()
 	uploadHandler := handlers.NewUploadHandler()
 	menuHandler := handlers.NewMenuHandler()
@@ -62,7 +65,9 @@ func SetupRouter() *gin.Engine {
 	subscriptionHandler := handlers.NewSubscriptionHandler()
 	paymentHandler := handlers.NewPaymentHandler()
 	promotionHandler := handlers.NewPromotionHandler()
+	promoCodeHandler := handlers.NewPromoCodeHandler()
 	providerHandler := handlers.NewDeliveryProviderHandler()
+	shiftHandler := handlers.NewShiftHandler()
 
 	// Health check endpoints
 	r.GET("/health", healthHandler.Health)
@@ -141,6 +146,8 @@ func SetupRouter() *gin.Engine {
 			chefs.GET("/:id", chefHandler.GetChef)
 			chefs.GET("/:id/menu", chefHandler.GetChefMenu)
 			chefs.GET("/:id/reviews", chefHandler.GetChefReviews)
+			chefs.GET("/:id/delivery-quote", orderHandler.QuoteDeliveryFee)
+			chefs.GET("/:id/slots", orderHandler.GetDeliverySlots)
 		}
 
 		// Chef onboarding (authenticated, but no chef role required — user is becoming a chef)
@@ -172,6 +179,12 @@ func SetupRouter() *gin.Engine {
 			chefMenu.DELETE("/items/:itemId", menuHandler.DeleteMenuItem)
 			chefMenu.POST("/items/:itemId/images", menuHandler.UploadMenuItemImage)
 			chefMenu.DELETE("/items/:itemId/images/:imageId", menuHandler.DeleteMenuItemImage)
+			chefMenu.POST("/items/:itemId/modifier-groups", menuHandler.CreateModifierGroup)
+			chefMenu.PUT("/items/:itemId/modifier-groups/:groupId", menuHandler.UpdateModifierGroup)
+			chefMenu.DELETE("/items/:itemId/modifier-groups/:groupId", menuHandler.DeleteModifierGroup)
+			chefMenu.POST("/items/:itemId/modifier-groups/:groupId/options", menuHandler.CreateModifierOption)
+			chefMenu.PUT("/items/:itemId/modifier-groups/:groupId/options/:optionId", menuHandler.UpdateModifierOption)
+			chefMenu.DELETE("/items/:itemId/modifier-groups/:groupId/options/:optionId", menuHandler.DeleteModifierOption)
 		}
 
 		// Chef dashboard routes (chef only)
@@ -183,6 +196,7 @@ func SetupRouter() *gin.Engine {
 			chefDashboard.PUT("/profile", chefHandler.UpdateChefProfile)
 			chefDashboard.GET("/orders", chefHandler.GetChefOrders)
 			chefDashboard.PUT("/orders/:orderId/status", chefHandler.UpdateOrderStatus)
+			chefDashboard.GET("/pickups", chefHandler.GetPendingPickups)
 			chefDashboard.GET("/reviews", chefHandler.GetChefReviewsForDashboard)
 			chefDashboard.POST("/reviews/:reviewId/reply", chefHandler.ReplyToReview)
 			chefDashboard.GET("/settings", chefHandler.GetChefSettings)
@@ -202,7 +216,10 @@ func SetupRouter() *gin.Engine {
 			orders.GET("", orderHandler.GetOrders)
 			orders.GET("/:id", orderHandler.GetOrder)
 			orders.POST("/:id/cancel", orderHandler.CancelOrder)
+			orders.POST("/:id/delivery-failure", orderHandler.RespondToFailedDelivery)
 			orders.GET("/:id/track", orderHandler.TrackOrder)
+			orders.GET("/:id/stream", orderHandler.StreamOrderTracking)
+			orders.GET("/:id/timeline", orderHandler.GetOrderTimeline)
 			orders.GET("/:id/invoice", orderHandler.GetOrderInvoice)
 		}
 
@@ -210,31 +227,45 @@ func SetupRouter() *gin.Engine {
 		cart := v1.Group("/cart")
 		cart.Use(middleware.AuthMiddleware())
 		{
-			// cart.GET("", cartHandler.GetCart)
-			// cart.POST("/items", cartHandler.AddItem)
-			// cart.PUT("/items/:itemId", cartHandler.UpdateItem)
-			// cart.DELETE("/items/:itemId", cartHandler.RemoveItem)
-			// cart.DELETE("", cartHandler.ClearCart)
+			cart.GET("", cartHandler.GetCart)
+			cart.POST("/accept-prices", cartHandler.AcceptPrices)
+			cart.POST("/items", cartHandler.AddItem)
+			cart.PUT("/items/:itemId", cartHandler.UpdateItem)
+			cart.DELETE("/items/:itemId", cartHandler.RemoveItem)
+			cart.DELETE("", cartHandler.ClearCart)
+			cart.POST("/checkout", cartHandler.Checkout)
+		}
+
+		// Promo code routes
+		promoCodes := v1.Group("/promo-codes")
+		promoCodes.Use(middleware.AuthMiddleware())
+		{
+			promoCodes.POST("/validate", promoCodeHandler.ValidatePromoCode)
 		}
 
 		// Social feed routes
 		social := v1.Group("/social")
 		social.Use(middleware.OptionalAuthMiddleware())
 		{
-			// social.GET("/feed", socialHandler.GetFeed)
-			// social.GET("/posts/:id", socialHandler.GetPost)
-			// social.POST("/posts/:id/like", socialHandler.LikePost) // requires auth
-			// social.POST("/posts/:id/comments", socialHandler.AddComment) // requires auth
+			social.GET("/feed", socialHandler.GetFeed)
+			social.GET("/posts/:id", socialHandler.GetPost)
+			social.GET("/posts/:id/comments", socialHandler.GetComments)
+			social.POST("/posts/:id/like", socialHandler.LikePost) // requires auth
+			social.DELETE("/posts/:id/like", socialHandler.UnlikePost) // requires auth
+			social.POST("/posts/:id/comments", socialHandler.AddComment) // requires auth
+			social.POST("/posts/:id/report", socialHandler.ReportPost) // requires auth
 		}
 
 		// Chef social posts (chef only)
 		chefSocial := v1.Group("/chef/posts")
 		chefSocial.Use(middleware.AuthMiddleware(), middleware.RequireChef())
 		{
-			// chefSocial.GET("", socialHandler.GetChefPosts)
-			// chefSocial.POST("", socialHandler.CreatePost)
-			// chefSocial.PUT("/:id", socialHandler.UpdatePost)
-			// chefSocial.DELETE("/:id", socialHandler.DeletePost)
+			chefSocial.GET("", socialHandler.GetChefPosts)
+			chefSocial.POST("", socialHandler.CreatePost)
+			chefSocial.PUT("/:id", socialHandler.UpdatePost)
+			chefSocial.DELETE("/:id", socialHandler.DeletePost)
+			chefSocial.GET("/:id/comments", socialHandler.GetChefPostComments)
+			chefSocial.PUT("/:id/comments/:commentId/hide", socialHandler.HideComment)
 		}
 
 		// Catering routes
@@ -242,20 +273,21 @@ func SetupRouter() *gin.Engine {
 		catering.Use(middleware.AuthMiddleware())
 		{
 			// Customer catering
-			// catering.POST("/requests", cateringHandler.CreateRequest)
-			// catering.GET("/requests", cateringHandler.GetMyRequests)
-			// catering.GET("/requests/:id", cateringHandler.GetRequest)
-			// catering.GET("/requests/:id/quotes", cateringHandler.GetQuotes)
-			// catering.POST("/quotes/:id/accept", cateringHandler.AcceptQuote)
+			catering.POST("/requests", cateringHandler.CreateRequest)
+			catering.GET("/requests", cateringHandler.GetMyRequests)
+			catering.GET("/requests/:id", cateringHandler.GetRequest)
+			catering.POST("/requests/:id/cancel", cateringHandler.CancelRequest)
+			catering.GET("/requests/:id/quotes", cateringHandler.GetQuotes)
+			catering.POST("/quotes/:id/accept", cateringHandler.AcceptQuote)
 		}
 
 		// Chef catering (chef only)
 		chefCatering := v1.Group("/chef/catering")
 		chefCatering.Use(middleware.AuthMiddleware(), middleware.RequireChef())
 		{
-			// chefCatering.GET("/requests", cateringHandler.GetAvailableRequests)
-			// chefCatering.POST("/requests/:id/quote", cateringHandler.SubmitQuote)
-			// chefCatering.GET("/quotes", cateringHandler.GetChefQuotes)
+			chefCatering.GET("/requests", cateringHandler.GetAvailableRequests)
+			chefCatering.POST("/requests/:id/quote", cateringHandler.SubmitQuote)
+			chefCatering.GET("/quotes", cateringHandler.GetChefQuotes)
 		}
 
 		// Delivery partner onboarding (authenticated, no delivery role required)
@@ -311,9 +343,12 @@ func SetupRouter() *gin.Engine {
 			deliveryStaff.GET("/fleet/overview", middleware.RequireStaffPermission(models.SPViewFleet), deliveryHandler.FleetOverview)
 			deliveryStaff.GET("/fleet/partners", middleware.RequireStaffPermission(models.SPViewDeliveryPartners), deliveryHandler.AdminGetDeliveryPartners)
 			deliveryStaff.GET("/fleet/partners/:id", middleware.RequireStaffPermission(models.SPViewDeliveryPartners), deliveryHandler.GetPartnerDetail)
+			deliveryStaff.GET("/fleet/partners/:id/metrics", middleware.RequireStaffPermission(models.SPViewDeliveryPartners), deliveryHandler.GetPartnerMetrics)
+			deliveryStaff.GET("/fleet/partners/:id/feedback", middleware.RequireStaffPermission(models.SPViewDeliveryPartners), deliveryHandler.GetPartnerFeedback)
 			deliveryStaff.PUT("/fleet/partners/:id/verify", middleware.RequireStaffPermission(models.SPVerifyDeliveryPartners), deliveryHandler.AdminVerifyPartner)
 			deliveryStaff.PUT("/fleet/partners/:id/suspend", middleware.RequireStaffPermission(models.SPManageDeliveryPartners), deliveryHandler.AdminSuspendPartner)
 			deliveryStaff.POST("/fleet/partners/:id/assign", middleware.RequireStaffPermission(models.SPAssignDeliveries), deliveryHandler.ManualAssignDelivery)
+			deliveryStaff.POST("/fleet/deliveries/:id/pickup-code/reset", middleware.RequireStaffPermission(models.SPAssignDeliveries), deliveryHandler.ResetPickupCode)
 		}
 
 		// Delivery partner routes (delivery role required)
@@ -324,13 +359,21 @@ func SetupRouter() *gin.Engine {
 			delivery.GET("/profile", deliveryHandler.GetProfile)
 			delivery.PUT("/profile", deliveryHandler.UpdateProfile)
 			delivery.PUT("/online", deliveryHandler.ToggleOnline)
+			delivery.GET("/shifts", shiftHandler.GetMyShifts)
+			delivery.POST("/shifts/:id/claim", shiftHandler.ClaimShift)
 			delivery.PUT("/location", deliveryHandler.UpdateLocation)
 			delivery.GET("/current", deliveryHandler.GetCurrentDelivery)
+			delivery.GET("/run", deliveryHandler.GetCurrentRun)
 			delivery.GET("/available", deliveryHandler.GetAvailableDeliveries)
 			delivery.POST("/:id/accept", deliveryHandler.AcceptDelivery)
+			delivery.GET("/offers", deliveryHandler.GetDeliveryOffers)
+			delivery.POST("/offers/:id/accept", deliveryHandler.AcceptDeliveryOffer)
+			delivery.POST("/offers/:id/decline", deliveryHandler.DeclineDeliveryOffer)
 			delivery.PUT("/:id/status", deliveryHandler.UpdateDeliveryStatus)
+			delivery.POST("/:id/proof-photo", deliveryHandler.UploadProofPhoto)
 			delivery.GET("/orders", deliveryHandler.GetDeliveryHistory)
 			delivery.GET("/earnings", deliveryHandler.GetEarnings)
+			delivery.GET("/feedback", deliveryHandler.GetMyFeedback)
 			delivery.POST("/documents", deliveryHandler.UploadPartnerDocument)
 			delivery.GET("/documents", deliveryHandler.GetPartnerDocuments)
 		}
@@ -406,16 +449,36 @@ func SetupRouter() *gin.Engine {
 			// Order management
 			admin.GET("/orders", adminHandler.GetAllOrders)
 			admin.GET("/orders/:id", adminHandler.GetOrderDetails)
+			admin.GET("/orders/:id/timeline", middleware.RequireStaffPermission(models.SPViewOrders), adminHandler.GetOrderTimeline)
+			admin.PUT("/orders/:id/status", middleware.RequireStaffPermission(models.SPManageOrders), adminHandler.UpdateOrderStatus)
+
+			// Content moderation
+			admin.GET("/moderation/posts", middleware.RequireStaffPermission(models.SPModerateContent), socialHandler.AdminGetFlaggedPosts)
+			admin.PUT("/moderation/posts/:id", middleware.RequireStaffPermission(models.SPModerateContent), socialHandler.AdminModeratePost)
+			admin.PUT("/moderation/comments/:id/hide", middleware.RequireStaffPermission(models.SPModerateContent), socialHandler.AdminHideComment)
 
 			// Promotions (featured ads)
 			admin.GET("/promotions", promotionHandler.AdminListPromotions)
 			admin.GET("/promotions/stats", promotionHandler.AdminGetPromotionStats)
 
+			// Promo codes
+			admin.GET("/promo-codes", promoCodeHandler.AdminListPromoCodes)
+			admin.POST("/promo-codes", middleware.RequireStaffPermission(models.SPManageSettings), promoCodeHandler.AdminCreatePromoCode)
+			admin.PUT("/promo-codes/:id", middleware.RequireStaffPermission(models.SPManageSettings), promoCodeHandler.AdminUpdatePromoCode)
+			admin.DELETE("/promo-codes/:id", middleware.RequireStaffPermission(models.SPManageSettings), promoCodeHandler.AdminDeactivatePromoCode)
+			admin.GET("/promo-codes/:id/redemptions", promoCodeHandler.AdminGetPromoRedemptions)
+
 			// Delivery management
 			admin.GET("/delivery/stats", deliveryHandler.AdminGetDeliveryStats)
 			admin.GET("/delivery/list", deliveryHandler.AdminListDeliveries)
+			admin.GET("/delivery/routes/:id", middleware.RequireStaffPermission(models.SPViewDeliveryOrders), deliveryHandler.AdminGetDeliveryRoute)
+			admin.GET("/delivery/proof/:id", middleware.RequireStaffPermission(models.SPViewDeliveryOrders), deliveryHandler.AdminGetDeliveryProof)
+			admin.POST("/delivery/proof/:id/override", middleware.RequireStaffPermission(models.SPAssignDeliveries), deliveryHandler.AdminOverrideHandover)
+			admin.POST("/delivery/:id/pickup-code/reset", middleware.RequireStaffPermission(models.SPAssignDeliveries), deliveryHandler.ResetPickupCode)
 			admin.GET("/delivery/partners", deliveryHandler.AdminGetDeliveryPartners)
 			admin.GET("/delivery/partners/:id", deliveryHandler.GetPartnerDetail)
+			admin.GET("/delivery/partners/:id/metrics", deliveryHandler.GetPartnerMetrics)
+			admin.GET("/delivery/partners/:id/feedback", deliveryHandler.GetPartnerFeedback)
 			admin.PUT("/delivery/partners/:id/verify", deliveryHandler.AdminVerifyPartner)
 			admin.PUT("/delivery/partners/:id/suspend", deliveryHandler.AdminSuspendPartner)
 
@@ -432,9 +495,19 @@ func SetupRouter() *gin.Engine {
 			// Delivery zone management
 			admin.GET("/delivery/zones", deliveryHandler.ListZones)
 			admin.POST("/delivery/zones", deliveryHandler.CreateZone)
+			admin.POST("/delivery/zones/import", deliveryHandler.ImportZones)
 			admin.PUT("/delivery/zones/:id", deliveryHandler.UpdateZone)
+			admin.GET("/delivery/zones/:id/surge-history", deliveryHandler.GetZoneSurgeHistory)
 			admin.DELETE("/delivery/zones/:id", deliveryHandler.DeleteZone)
 
+			// Shift roster and attendance for internal agents
+			admin.GET("/fleet/shifts", middleware.RequireStaffPermission(models.SPViewFleet), shiftHandler.ListShifts)
+			admin.POST("/fleet/shifts", middleware.RequireStaffPermission(models.SPManageFleet), shiftHandler.PublishShifts)
+			admin.PUT("/fleet/shifts/:id/assign", middleware.RequireStaffPermission(models.SPManageFleet), shiftHandler.AssignShift)
+			admin.PUT("/fleet/shifts/:id/cancel", middleware.RequireStaffPermission(models.SPManageFleet), shiftHandler.CancelShift)
+			admin.GET("/fleet/attendance", middleware.RequireStaffPermission(models.SPViewFleet), shiftHandler.GetAttendanceReport)
+			admin.GET("/fleet/off-shift", middleware.RequireStaffPermission(models.SPViewFleet), shiftHandler.ListOffShiftSessions)
+
 			// Staff management — enforced with granular staff permissions
 			admin.GET("/staff/me", staffHandler.GetMyStaffProfile) // No permission needed — own profile
 			admin.GET("/staff/roles", staffHandler.GetStaffRoles)  // No permission needed — role definitions
@@ -487,10 +560,11 @@ func SetupRouter() *gin.Engine {
 		payments := v1.Group("/payment-methods")
 		payments.Use(middleware.AuthMiddleware())
 		{
-			// payments.GET("", paymentHandler.GetPaymentMethods)
-			// payments.POST("", paymentHandler.AddPaymentMethod)
-			// payments.DELETE("/:id", paymentHandler.RemovePaymentMethod)
-			// payments.PUT("/:id/default", paymentHandler.SetDefaultPaymentMethod)
+			payments.GET("", paymentHandler.GetPaymentMethods)
+			payments.POST("", paymentHandler.AddPaymentMethod)
+			payments.POST("/gateway-customer", paymentHandler.GetGatewayCustomer)
+			payments.DELETE("/:id", paymentHandler.RemovePaymentMethod)
+			payments.PUT("/:id/default", paymentHandler.SetDefaultPaymentMethod)
 		}
 
 		// Customer profile & onboarding
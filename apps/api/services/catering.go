@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/homechef/api/database"
+	"github.com/homechef/api/models"
+	"gorm.io/gorm"
+)
+
+// CateringSweepInterval is how often stale quotes and requests are expired
+const CateringSweepInterval = 5 * time.Minute
+
+// CateringChefMatches reports whether a chef may see and quote on a request:
+// at least one shared cuisine (requests without cuisines match everyone) and the
+// venue within the chef's service radius. Without coordinates on either side we
+// fall back to a same-city match.
+func CateringChefMatches(req *models.CateringRequest, chef *models.ChefProfile) bool {
+	if len(req.CuisineTypes) > 0 {
+		chefCuisines := make(map[string]bool, len(chef.Cuisines))
+		for _, cuisine := range chef.Cuisines {
+			chefCuisines[strings.ToLower(strings.TrimSpace(cuisine))] = true
+		}
+		matched := false
+		for _, cuisine := range req.CuisineTypes {
+			if chefCuisines[strings.ToLower(strings.TrimSpace(cuisine))] {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	if req.Latitude == 0 && req.Longitude == 0 || chef.Latitude == 0 && chef.Longitude == 0 {
+		return req.City != "" && strings.EqualFold(req.City, chef.City)
+	}
+
+	return haversineDistance(chef.Latitude, chef.Longitude, req.Latitude, req.Longitude) <= chef.ServiceRadius
+}
+
+// ScopeCateringRequestsForChef narrows a catering request query to the requests
+// CateringChefMatches would let the chef see, so listings can filter and
+// paginate in SQL. Keep the two in step.
+func ScopeCateringRequestsForChef(db *gorm.DB, chef *models.ChefProfile) *gorm.DB {
+	cuisines := make([]string, 0, len(chef.Cuisines))
+	for _, cuisine := range chef.Cuisines {
+		cuisines = append(cuisines, strings.ToLower(strings.TrimSpace(cuisine)))
+	}
+	db = db.Where("(COALESCE(cardinality(cuisine_types), 0) = 0 OR EXISTS (SELECT 1 FROM unnest(cuisine_types) AS cuisine WHERE LOWER(TRIM(cuisine)) IN ?))", cuisines)
+
+	sameCity := db.Session(&gorm.Session{NewDB: true}).
+		Where("city <> '' AND LOWER(city) = LOWER(?)", chef.City)
+	if chef.Latitude == 0 && chef.Longitude == 0 {
+		return db.Where(sameCity)
+	}
+
+	// Bounding box first so the distance check runs on nearby requests only
+	latSpan := chef.ServiceRadius / 111.0
+	lngSpan := latSpan / math.Max(math.Cos(chef.Latitude*math.Pi/180), 0.01)
+	withinRadius := db.Session(&gorm.Session{NewDB: true}).
+		Where("NOT (latitude = 0 AND longitude = 0)").
+		Where("latitude BETWEEN ? AND ? AND longitude BETWEEN ? AND ?",
+			chef.Latitude-latSpan, chef.Latitude+latSpan, chef.Longitude-lngSpan, chef.Longitude+lngSpan).
+		Where("6371 * 2 * ASIN(SQRT(POWER(SIN(RADIANS(latitude - ?) / 2), 2) + COS(RADIANS(?)) * COS(RADIANS(latitude)) * POWER(SIN(RADIANS(longitude - ?) / 2), 2))) <= ?",
+			chef.Latitude, chef.Latitude, chef.Longitude, chef.ServiceRadius)
+	noVenue := db.Session(&gorm.Session{NewDB: true}).Where("latitude = 0 AND longitude = 0").Where(sameCity)
+	return db.Where(db.Session(&gorm.Session{NewDB: true}).Where(noVenue).Or(withinRadius))
+}
+
+// CateringRequestAcceptsQuotes reports whether chefs can still quote on a request
+func CateringRequestAcceptsQuotes(req *models.CateringRequest, now time.Time) bool {
+	if req.Status != models.CateringStatusOpen && req.Status != models.CateringStatusQuoted {
+		return false
+	}
+	if req.QuoteDeadline != nil && now.After(*req.QuoteDeadline) {
+		return false
+	}
+	return now.Before(req.EventDate)
+}
+
+// ExpireCatering expires pending quotes past ValidUntil (or whose event has
+// passed) and open/quoted requests past QuoteDeadline that have no live quote
+// left to accept.
+func ExpireCatering(ctx context.Context) error {
+	now := time.Now()
+	db := database.DB.WithContext(ctx)
+
+	quotes := db.Model(&models.CateringQuote{}).
+		Where("status = ?", models.QuoteStatusPending).
+		Where("(valid_until < ? OR request_id IN (?))", now,
+			db.Model(&models.CateringRequest{}).Select("id").Where("event_date < ?", now)).
+		Update("status", models.QuoteStatusExpired)
+	if quotes.Error != nil {
+		return quotes.Error
+	}
+
+	requests := db.Model(&models.CateringRequest{}).
+		Where("status IN ?", []models.CateringRequestStatus{models.CateringStatusOpen, models.CateringStatusQuoted}).
+		Where("((quote_deadline IS NOT NULL AND quote_deadline < ?) OR event_date < ?)", now, now).
+		Where("NOT EXISTS (SELECT 1 FROM catering_quotes q WHERE q.request_id = catering_requests.id AND q.status = ?)",
+			models.QuoteStatusPending).
+		Update("status", models.CateringStatusExpired)
+	if requests.Error != nil {
+		return requests.Error
+	}
+
+	if quotes.RowsAffected > 0 || requests.RowsAffected > 0 {
+		log.Printf("Catering sweep: expired %d quotes and %d requests", quotes.RowsAffected, requests.RowsAffected)
+	}
+	return nil
+}
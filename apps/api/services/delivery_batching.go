@@ -0,0 +1,243 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/homechef/api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Batching limits for drivers carrying several orders at once
+const (
+	// BatchPickupRadiusKm is how far apart two kitchens may be to share a run
+	BatchPickupRadiusKm = 2.0
+
+	// BatchMaxBearingDiff is the widest angle, in degrees, between the
+	// directions two deliveries head off in from their kitchens
+	BatchMaxBearingDiff = 45.0
+)
+
+// Stop kinds
+const (
+	StopKindPickup  = "pickup"
+	StopKindDropoff = "dropoff"
+)
+
+// BatchStop is one stop on a driver's route
+type BatchStop struct {
+	Sequence   int               `json:"sequence"`
+	Kind       string            `json:"kind"`
+	DeliveryID uuid.UUID         `json:"deliveryId"`
+	OrderID    uuid.UUID         `json:"orderId"`
+	Status     models.StopStatus `json:"status"`
+	Address    string            `json:"address"`
+	City       string            `json:"city"`
+	Latitude   float64           `json:"latitude"`
+	Longitude  float64           `json:"longitude"`
+}
+
+// LockDriverLoad locks a driver's row so concurrent assignments to them are
+// serialised, and returns the deliveries they are working on
+func LockDriverLoad(tx *gorm.DB, partnerID uuid.UUID) ([]models.Delivery, error) {
+	var partner models.DeliveryPartner
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").
+		Where("id = ?", partnerID).First(&partner).Error; err != nil {
+		return nil, fmt.Errorf("failed to lock driver: %w", err)
+	}
+
+	var active []models.Delivery
+	if err := tx.Where("delivery_partner_id = ? AND status IN ?", partnerID, ActiveDeliveryStatuses).
+		Find(&active).Error; err != nil {
+		return nil, fmt.Errorf("failed to load active deliveries: %w", err)
+	}
+	return active, nil
+}
+
+// CheckBatchFit returns a *DispatchError when a driver cannot take another
+// delivery alongside the ones they are carrying: they are at MaxConcurrent, or
+// the new delivery is not on the way
+func CheckBatchFit(partner *models.DeliveryPartner, active []models.Delivery, delivery *models.Delivery) error {
+	maxConcurrent := partner.MaxConcurrent
+	if maxConcurrent < 1 {
+		maxConcurrent = 1
+	}
+	if len(active) >= maxConcurrent {
+		if maxConcurrent == 1 {
+			return &DispatchError{Message: "You already have an active delivery"}
+		}
+		return &DispatchError{Message: fmt.Sprintf("You are already carrying your maximum of %d deliveries", maxConcurrent)}
+	}
+	if !CanBatch(active, delivery) {
+		return &DispatchError{Message: "This order is not on the way of your current deliveries"}
+	}
+	return nil
+}
+
+// CanBatch reports whether a delivery can share a run with every active one:
+// the kitchens are within BatchPickupRadiusKm of each other and the dropoffs
+// lie in roughly the same direction
+func CanBatch(active []models.Delivery, delivery *models.Delivery) bool {
+	heading := bearing(delivery.PickupLatitude, delivery.PickupLongitude, delivery.DropoffLatitude, delivery.DropoffLongitude)
+	for _, d := range active {
+		if haversineDistance(d.PickupLatitude, d.PickupLongitude, delivery.PickupLatitude, delivery.PickupLongitude) > BatchPickupRadiusKm {
+			return false
+		}
+		other := bearing(d.PickupLatitude, d.PickupLongitude, d.DropoffLatitude, d.DropoffLongitude)
+		if angleBetween(heading, other) > BatchMaxBearingDiff {
+			return false
+		}
+	}
+	return true
+}
+
+// AddToBatch puts a newly assigned delivery on the driver's route. Alone it
+// gets a simple pickup-then-dropoff plan; alongside other active deliveries
+// they all join one batch and every remaining stop is re-planned. active is
+// the driver's load from LockDriverLoad, without the new delivery.
+func AddToBatch(tx *gorm.DB, partner *models.DeliveryPartner, active []models.Delivery, delivery *models.Delivery) error {
+	if len(active) == 0 {
+		delivery.BatchID = nil
+		return ResequenceStops(tx, partner, []models.Delivery{*delivery})
+	}
+
+	var batchID *uuid.UUID
+	for _, d := range active {
+		if d.BatchID != nil {
+			batchID = d.BatchID
+			break
+		}
+	}
+	if batchID == nil {
+		batch := models.DeliveryBatch{PartnerID: partner.ID, Status: models.BatchActive}
+		if err := tx.Create(&batch).Error; err != nil {
+			return fmt.Errorf("failed to create delivery batch: %w", err)
+		}
+		batchID = &batch.ID
+	}
+
+	members := append(append([]models.Delivery{}, active...), *delivery)
+	ids := make([]uuid.UUID, len(members))
+	for i := range members {
+		members[i].BatchID = batchID
+		ids[i] = members[i].ID
+	}
+	if err := tx.Model(&models.Delivery{}).Where("id IN ?", ids).Update("batch_id", batchID).Error; err != nil {
+		return fmt.Errorf("failed to join delivery batch: %w", err)
+	}
+	delivery.BatchID = batchID
+
+	return ResequenceStops(tx, partner, members)
+}
+
+// RefreshBatch re-plans the remaining stops of a driver's batch after a stop
+// changes, and closes the batch once every delivery in it is finished
+func RefreshBatch(tx *gorm.DB, partner *models.DeliveryPartner, batchID uuid.UUID) error {
+	var members []models.Delivery
+	if err := tx.Where("batch_id = ? AND status IN ?", batchID, ActiveDeliveryStatuses).Find(&members).Error; err != nil {
+		return fmt.Errorf("failed to load delivery batch: %w", err)
+	}
+	if len(members) == 0 {
+		now := time.Now()
+		return tx.Model(&models.DeliveryBatch{}).Where("id = ? AND status = ?", batchID, models.BatchActive).
+			Updates(map[string]interface{}{"status": models.BatchCompleted, "completed_at": now}).Error
+	}
+	return ResequenceStops(tx, partner, members)
+}
+
+// ResequenceStops plans the order of the remaining stops from the driver's
+// current position and stores each delivery's pickup and dropoff sequence
+func ResequenceStops(tx *gorm.DB, partner *models.DeliveryPartner, deliveries []models.Delivery) error {
+	stops := PlanBatchStops(partner.CurrentLatitude, partner.CurrentLongitude, deliveries)
+
+	sequences := make(map[uuid.UUID]map[string]interface{}, len(deliveries))
+	for _, d := range deliveries {
+		sequences[d.ID] = map[string]interface{}{"pickup_sequence": 0, "dropoff_sequence": 0}
+	}
+	for _, stop := range stops {
+		sequences[stop.DeliveryID][stop.Kind+"_sequence"] = stop.Sequence
+	}
+
+	for id, updates := range sequences {
+		if err := tx.Model(&models.Delivery{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+			return fmt.Errorf("failed to store stop sequence: %w", err)
+		}
+	}
+	return nil
+}
+
+// PlanBatchStops orders the stops still to be visited, always going to the
+// nearest next stop but never dropping an order off before collecting it.
+// Without a driver position the plan starts from the first pickup.
+func PlanBatchStops(fromLat, fromLng float64, deliveries []models.Delivery) []BatchStop {
+	var pending []BatchStop
+	pickedUp := make(map[uuid.UUID]bool, len(deliveries))
+	for _, d := range deliveries {
+		if !d.PickupStopStatus.Done() {
+			pending = append(pending, BatchStop{
+				Kind: StopKindPickup, DeliveryID: d.ID, OrderID: d.OrderID, Status: d.PickupStopStatus,
+				Address: d.PickupAddressLine1, City: d.PickupAddressCity,
+				Latitude: d.PickupLatitude, Longitude: d.PickupLongitude,
+			})
+		} else {
+			pickedUp[d.ID] = true
+		}
+		if !d.DropoffStopStatus.Done() {
+			pending = append(pending, BatchStop{
+				Kind: StopKindDropoff, DeliveryID: d.ID, OrderID: d.OrderID, Status: d.DropoffStopStatus,
+				Address: d.DropoffAddressLine1, City: d.DropoffAddressCity,
+				Latitude: d.DropoffLatitude, Longitude: d.DropoffLongitude,
+			})
+		}
+	}
+	if len(pending) == 0 {
+		return []BatchStop{}
+	}
+	if fromLat == 0 && fromLng == 0 {
+		fromLat, fromLng = pending[0].Latitude, pending[0].Longitude
+	}
+
+	plan := make([]BatchStop, 0, len(pending))
+	for len(pending) > 0 {
+		next, nearest := -1, math.MaxFloat64
+		for i, stop := range pending {
+			if stop.Kind == StopKindDropoff && !pickedUp[stop.DeliveryID] {
+				continue
+			}
+			if d := haversineDistance(fromLat, fromLng, stop.Latitude, stop.Longitude); d < nearest {
+				next, nearest = i, d
+			}
+		}
+
+		stop := pending[next]
+		stop.Sequence = len(plan) + 1
+		plan = append(plan, stop)
+		if stop.Kind == StopKindPickup {
+			pickedUp[stop.DeliveryID] = true
+		}
+		fromLat, fromLng = stop.Latitude, stop.Longitude
+		pending = append(pending[:next], pending[next+1:]...)
+	}
+	return plan
+}
+
+// bearing is the initial compass heading in degrees from one point to another
+func bearing(lat1, lng1, lat2, lng2 float64) float64 {
+	phi1, phi2 := lat1*math.Pi/180, lat2*math.Pi/180
+	dLng := (lng2 - lng1) * math.Pi / 180
+	y := math.Sin(dLng) * math.Cos(phi2)
+	x := math.Cos(phi1)*math.Sin(phi2) - math.Sin(phi1)*math.Cos(phi2)*math.Cos(dLng)
+	return math.Mod(math.Atan2(y, x)*180/math.Pi+360, 360)
+}
+
+// angleBetween is the smaller angle in degrees between two headings
+func angleBetween(a, b float64) float64 {
+	diff := math.Abs(a - b)
+	if diff > 180 {
+		diff = 360 - diff
+	}
+	return diff
+}
@@ -0,0 +1,458 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/homechef/api/database"
+	"github.com/homechef/api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Failed-delivery handling
+const (
+	// FailedDeliveryResponseWindow is how long a customer has to ask for another
+	// attempt before the order is sent back to the kitchen
+	FailedDeliveryResponseWindow = 15 * time.Minute
+
+	// FailedDeliverySweepInterval is how often unanswered failures are sent back
+	FailedDeliverySweepInterval = time.Minute
+
+	// Share of the delivery fee paid to the driver for a leg that did not end
+	// in a handover
+	FailedLegPayoutShare = 0.5
+	ReturnLegPayoutShare = 0.5
+)
+
+// Choices offered to a customer after a failed attempt
+const (
+	FailureOptionRetry  = "retry"
+	FailureOptionReturn = "return"
+)
+
+// RefundPolicy is what a customer gets back when their order never arrives
+type RefundPolicy string
+
+const (
+	RefundNone RefundPolicy = "none" // the customer caused the failure
+	RefundFood RefundPolicy = "food" // everything but the delivery fee
+	RefundFull RefundPolicy = "full" // the platform caused the failure
+)
+
+// FailureClass describes how a failure reason is handled
+type FailureClass struct {
+	Label     string
+	Retryable bool // another attempt could succeed
+	Refund    RefundPolicy
+}
+
+var failureClasses = map[models.FailureReason]FailureClass{
+	models.FailureCustomerUnavailable: {Label: "We couldn't reach you at the drop-off", Retryable: true, Refund: RefundNone},
+	models.FailureWrongAddress:        {Label: "Our driver couldn't find your address", Retryable: true, Refund: RefundNone},
+	models.FailureAccessRestricted:    {Label: "Our driver couldn't get access to your building", Retryable: true, Refund: RefundNone},
+	models.FailureRefused:             {Label: "The order was refused at the door", Retryable: false, Refund: RefundNone},
+	models.FailureUnsafeLocation:      {Label: "The drop-off location wasn't safe to deliver to", Retryable: false, Refund: RefundFood},
+	models.FailureOrderDamaged:        {Label: "Your order was damaged on the way", Retryable: false, Refund: RefundFull},
+	models.FailureOther:               {Label: "Your order couldn't be delivered", Retryable: true, Refund: RefundFood},
+}
+
+// ClassifyFailure returns how a failure reason is handled, and false for an
+// unknown reason
+func ClassifyFailure(reason models.FailureReason) (FailureClass, bool) {
+	class, ok := failureClasses[reason]
+	return class, ok
+}
+
+// DeliveryFailureError is a customer-facing reason a failed delivery could not
+// be re-attempted or sent back
+type DeliveryFailureError struct {
+	Message string
+}
+
+func (e *DeliveryFailureError) Error() string {
+	return e.Message
+}
+
+// HandleDeliveryFailure runs once a driver has marked a delivery failed. It
+// pays the driver for the leg and records it, then either gives the customer
+// FailedDeliveryResponseWindow to ask for another attempt or, when the reason
+// rules one out or attempts are used up, sends the order back to the kitchen.
+func HandleDeliveryFailure(db *gorm.DB, delivery *models.Delivery, notes string, now time.Time) error {
+	class, ok := ClassifyFailure(delivery.FailureReason)
+	if !ok {
+		class = failureClasses[models.FailureOther]
+	}
+
+	payout := math.Round(delivery.DeliveryFee*FailedLegPayoutShare*100) / 100
+	attempt := models.DeliveryAttempt{
+		DeliveryID:    delivery.ID,
+		OrderID:       delivery.OrderID,
+		PartnerID:     delivery.DeliveryPartnerID,
+		AttemptNumber: delivery.AttemptNumber,
+		Outcome:       models.AttemptFailed,
+		FailureReason: delivery.FailureReason,
+		Notes:         notes,
+		Payout:        payout,
+		StartedAt:     delivery.AssignedAt,
+		EndedAt:       now,
+	}
+	if err := db.Create(&attempt).Error; err != nil {
+		return fmt.Errorf("failed to record delivery attempt: %w", err)
+	}
+	go recordLegPayout(delivery.DeliveryPartnerID, delivery.ID, payout)
+
+	if !class.Retryable || delivery.AttemptNumber >= delivery.MaxAttempts {
+		return StartDeliveryReturn(delivery.ID, class.Label)
+	}
+
+	deadline := now.Add(FailedDeliveryResponseWindow)
+	if err := db.Model(delivery).Update("retry_deadline", deadline).Error; err != nil {
+		return fmt.Errorf("failed to set retry deadline: %w", err)
+	}
+	delivery.RetryDeadline = &deadline
+
+	publishDeliveryFailed(delivery, class, map[string]interface{}{
+		"options":        []string{FailureOptionRetry, FailureOptionReturn},
+		"retry_deadline": deadline.Format(time.RFC3339),
+		"attempts_left":  delivery.MaxAttempts - delivery.AttemptNumber,
+	})
+	return nil
+}
+
+// RetryFailedDelivery answers a customer's request for another attempt. The
+// driver still has the food, so they are assigned the next attempt straight
+// away, already on their way to the customer.
+func RetryFailedDelivery(customerID, orderID uuid.UUID, instructions string) (*models.Delivery, error) {
+	tx := database.DB.Begin()
+	defer tx.Rollback()
+
+	var order models.Order
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Chef").
+		Where("id = ? AND customer_id = ?", orderID, customerID).First(&order).Error; err != nil {
+		return nil, &DeliveryFailureError{Message: "Order not found"}
+	}
+
+	var failed models.Delivery
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("order_id = ?", orderID).First(&failed).Error; err != nil {
+		return nil, &DeliveryFailureError{Message: "This order has no delivery to re-attempt"}
+	}
+	now := time.Now()
+	if failed.Status != models.DeliveryFailed || failed.ReturnStartedAt != nil ||
+		failed.RetryDeadline == nil || now.After(*failed.RetryDeadline) {
+		return nil, &DeliveryFailureError{Message: "This delivery can no longer be re-attempted"}
+	}
+
+	if instructions != "" {
+		if err := tx.Model(&order).Update("delivery_instructions", instructions).Error; err != nil {
+			return nil, fmt.Errorf("failed to update delivery instructions: %w", err)
+		}
+	}
+
+	var partner models.DeliveryPartner
+	if err := tx.First(&partner, "id = ?", failed.DeliveryPartnerID).Error; err != nil {
+		return nil, fmt.Errorf("failed to load driver: %w", err)
+	}
+	active, err := LockDriverLoad(tx, partner.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	next := NewOrderDelivery(&order, partner.ID)
+	next.Status = models.DeliveryInTransit
+	next.AssignmentType = failed.AssignmentType
+	next.AssignedByID = failed.AssignedByID
+	next.AttemptNumber = failed.AttemptNumber + 1
+	next.MaxAttempts = failed.MaxAttempts
+	next.PickupStopStatus = models.StopCompleted
+	next.PickedUpAt = failed.PickedUpAt
+	next.HandoverCode = failed.HandoverCode
+	next.AssignedAt = now
+	if err := SaveOrderDelivery(tx, &next); err != nil {
+		return nil, fmt.Errorf("failed to save delivery: %w", err)
+	}
+	// The driver is carrying the food whatever else is on their run
+	if err := AddToBatch(tx, &partner, active, &next); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, fmt.Errorf("failed to commit: %w", err)
+	}
+
+	LogOrderEvent(database.DB, order.ID, models.OrderEventDelivery, string(models.DeliveryFailed),
+		string(next.Status), ByUser(ActorCustomer, customerID).WithNotes(fmt.Sprintf("Attempt %d requested", next.AttemptNumber)))
+
+	go func() {
+		if err := PublishEvent(SubjectDeliveryUpdated, "delivery.updated", customerID, map[string]interface{}{
+			"delivery_id":    next.ID.String(),
+			"order_id":       order.ID.String(),
+			"partner_id":     partner.ID.String(),
+			"old_status":     string(models.DeliveryFailed),
+			"status":         string(next.Status),
+			"attempt_number": next.AttemptNumber,
+		}); err != nil {
+			log.Printf("Failed to publish delivery updated event: %v", err)
+		}
+	}()
+
+	return &next, nil
+}
+
+// ReturnFailedDelivery answers a customer who would rather not have another
+// attempt
+func ReturnFailedDelivery(customerID, orderID uuid.UUID) error {
+	var delivery models.Delivery
+	if err := database.DB.Joins("JOIN orders ON orders.id = deliveries.order_id").
+		Where("deliveries.order_id = ? AND orders.customer_id = ?", orderID, customerID).
+		First(&delivery).Error; err != nil {
+		return &DeliveryFailureError{Message: "Order not found"}
+	}
+	if delivery.Status != models.DeliveryFailed || delivery.ReturnStartedAt != nil {
+		return &DeliveryFailureError{Message: "This delivery is not waiting on your answer"}
+	}
+	return StartDeliveryReturn(delivery.ID, "Customer asked for no further attempts")
+}
+
+// StartDeliveryReturn sends the driver of a failed delivery back to the
+// kitchen and closes the order, refunding the customer as the failure reason
+// dictates. It does nothing if the delivery has been re-attempted or is
+// already on its way back. If the order cannot be closed the delivery is left
+// waiting so the next call tries again; a refund already made is kept on the
+// order and not repeated.
+func StartDeliveryReturn(deliveryID uuid.UUID, notes string) error {
+	tx := database.DB.Begin()
+	defer tx.Rollback()
+
+	var delivery models.Delivery
+	if err := tx.Select("id", "order_id").First(&delivery, "id = ?", deliveryID).Error; err != nil {
+		return fmt.Errorf("failed to load delivery: %w", err)
+	}
+
+	// Order first, as on every assignment path
+	var order models.Order
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, "id = ?", delivery.OrderID).Error; err != nil {
+		return fmt.Errorf("failed to lock order: %w", err)
+	}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&delivery, "id = ?", deliveryID).Error; err != nil {
+		return fmt.Errorf("failed to lock delivery: %w", err)
+	}
+	if delivery.Status != models.DeliveryFailed || delivery.ReturnStartedAt != nil {
+		return nil
+	}
+
+	class, ok := ClassifyFailure(delivery.FailureReason)
+	if !ok {
+		class = failureClasses[models.FailureOther]
+	}
+	refundAmount, closeErr := closeFailedOrder(tx, &order, class, notes)
+	if closeErr != nil {
+		// Keep any refund that went through; the return is retried later
+		if err := tx.Commit().Error; err != nil {
+			log.Printf("Failed to save refund %s for order %s: %v", order.RefundID, order.OrderNumber, err)
+		}
+		return fmt.Errorf("failed to close order %s: %w", order.OrderNumber, closeErr)
+	}
+
+	now := time.Now()
+	if err := tx.Model(&delivery).Updates(map[string]interface{}{
+		"return_started_at": now,
+		"retry_deadline":    nil,
+	}).Error; err != nil {
+		return fmt.Errorf("failed to start return: %w", err)
+	}
+	delivery.ReturnStartedAt = &now
+	delivery.RetryDeadline = nil
+
+	if err := tx.Commit().Error; err != nil {
+		if order.RefundID != "" {
+			log.Printf("Refund %s for order %s was made but not saved: %v", order.RefundID, order.OrderNumber, err)
+		}
+		return fmt.Errorf("failed to commit: %w", err)
+	}
+
+	publishDeliveryFailed(&delivery, class, map[string]interface{}{
+		"returning":     true,
+		"refund_amount": refundAmount,
+		"order_status":  string(order.Status),
+	})
+	return nil
+}
+
+// CompleteDeliveryReturn records the driver handing a failed order back to
+// the kitchen and pays them for the trip
+func CompleteDeliveryReturn(db *gorm.DB, delivery *models.Delivery, now time.Time) error {
+	startedAt := now
+	if delivery.ReturnStartedAt != nil {
+		startedAt = *delivery.ReturnStartedAt
+	}
+	payout := math.Round(delivery.DeliveryFee*ReturnLegPayoutShare*100) / 100
+	attempt := models.DeliveryAttempt{
+		DeliveryID:    delivery.ID,
+		OrderID:       delivery.OrderID,
+		PartnerID:     delivery.DeliveryPartnerID,
+		AttemptNumber: delivery.AttemptNumber,
+		Outcome:       models.AttemptReturned,
+		FailureReason: delivery.FailureReason,
+		Payout:        payout,
+		StartedAt:     startedAt,
+		EndedAt:       now,
+	}
+	if err := db.Create(&attempt).Error; err != nil {
+		return fmt.Errorf("failed to record return: %w", err)
+	}
+	go recordLegPayout(delivery.DeliveryPartnerID, delivery.ID, payout)
+	return nil
+}
+
+// ExpireFailedDeliveries sends back every failed delivery whose customer did
+// not answer within FailedDeliveryResponseWindow. Runs as a background job.
+func ExpireFailedDeliveries(ctx context.Context) error {
+	var ids []uuid.UUID
+	if err := database.DB.WithContext(ctx).Model(&models.Delivery{}).
+		Where("status = ? AND return_started_at IS NULL AND retry_deadline < ?", models.DeliveryFailed, time.Now()).
+		Pluck("id", &ids).Error; err != nil {
+		return fmt.Errorf("failed to find unanswered failed deliveries: %w", err)
+	}
+
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := StartDeliveryReturn(id, "No answer from customer"); err != nil {
+			log.Printf("Failed to send back delivery %s: %v", id, err)
+		}
+	}
+	return nil
+}
+
+// closeFailedOrder ends an order that will not be delivered. A customer at
+// fault has it cancelled; otherwise the refund policy is paid back through the
+// gateway. If that cannot be done the order is cancelled and the amount owed is
+// left in the event log for the chef or an admin to refund. Runs inside the
+// caller's transaction with the order locked. A refund is written to the order
+// as soon as the gateway makes it, and the status change runs in a savepoint,
+// so a failed status change leaves the refund recorded and is not refunded
+// again on retry.
+func closeFailedOrder(tx *gorm.DB, order *models.Order, class FailureClass, notes string) (float64, error) {
+	by := BySystem(models.OrderEventSourceJob).WithNotes(notes)
+	cancel := func(logNotes string) error {
+		return UpdateOrderStatus(tx, order, models.OrderStatusCancelled, by.WithNotes(logNotes),
+			map[string]interface{}{"cancel_reason": class.Label})
+	}
+	refunded := func() error {
+		return UpdateOrderStatus(tx, order, models.OrderStatusRefunded, by,
+			map[string]interface{}{"cancel_reason": class.Label})
+	}
+
+	// Refunded on an earlier try whose status change failed
+	if order.RefundID != "" {
+		return order.RefundAmount, refunded()
+	}
+
+	var amount float64
+	switch class.Refund {
+	case RefundFull:
+		amount = order.Total
+	case RefundFood:
+		amount = math.Max(order.Total-order.DeliveryFee, 0)
+	}
+	if amount == 0 || order.PaymentStatus != models.PaymentCompleted {
+		return 0, cancel(notes)
+	}
+
+	owed := fmt.Sprintf("%s; refund of %.2f due", notes, amount)
+	rz := GetRazorpay()
+	if rz == nil || order.RazorpayPaymentID == "" {
+		return 0, cancel(owed)
+	}
+	rzRefund, err := rz.CreateRefund(order.RazorpayPaymentID, &RefundRequest{
+		Amount: ToPaise(amount),
+		Speed:  "normal",
+		Notes: map[string]string{
+			"order_id":     order.ID.String(),
+			"order_number": order.OrderNumber,
+			"reason":       class.Label,
+			"initiated_by": "system",
+		},
+		Receipt: fmt.Sprintf("refund-%s", order.OrderNumber),
+	})
+	if err != nil {
+		log.Printf("Failed to create refund for order %s: %v", order.OrderNumber, err)
+		return 0, cancel(owed)
+	}
+
+	if err := tx.Model(order).Updates(map[string]interface{}{
+		"payment_status":      models.PaymentRefunded,
+		"refund_id":           rzRefund.ID,
+		"refund_amount":       amount,
+		"refund_reason":       class.Label,
+		"refund_initiated_by": "system",
+	}).Error; err != nil {
+		log.Printf("Refund %s for order %s was made but not saved: %v", rzRefund.ID, order.OrderNumber, err)
+		return amount, fmt.Errorf("failed to record refund: %w", err)
+	}
+	order.RefundID = rzRefund.ID
+	order.RefundAmount = amount
+	if err := tx.Transaction(func(stx *gorm.DB) error {
+		return RecordOrderEvent(stx, order.ID, models.OrderEventPayment, string(models.PaymentCompleted),
+			string(models.PaymentRefunded), by.WithNotes(fmt.Sprintf("%.2f refunded", amount)))
+	}); err != nil {
+		log.Printf("Order %s: %v", order.ID, err)
+	}
+
+	return amount, refunded()
+}
+
+// recordLegPayout books a driver's pay for a leg against their subscription
+func recordLegPayout(partnerID, deliveryID uuid.UUID, amount float64) {
+	if amount <= 0 {
+		return
+	}
+	var partner models.DeliveryPartner
+	if err := database.DB.Select("id", "user_id").First(&partner, "id = ?", partnerID).Error; err != nil {
+		return
+	}
+	var sub models.Subscription
+	if err := database.DB.Where("user_id = ? AND subscriber_type = ? AND status IN ?",
+		partner.UserID, models.SubscriberDriver,
+		[]models.SubscriptionStatus{models.SubStatusTrial, models.SubStatusActive}).
+		First(&sub).Error; err != nil {
+		return
+	}
+	if err := RecordEarning(partner.UserID, sub.ID, models.EarningDeliveryFee, amount, "INR", nil, &deliveryID); err != nil {
+		log.Printf("Failed to record leg payout for delivery %s: %v", deliveryID, err)
+		return
+	}
+	CheckEarningsThreshold(sub.ID)
+}
+
+// publishDeliveryFailed tells the customer what happens next with their order
+func publishDeliveryFailed(delivery *models.Delivery, class FailureClass, extra map[string]interface{}) {
+	var order models.Order
+	if err := database.DB.Select("id", "order_number", "customer_id").First(&order, "id = ?", delivery.OrderID).Error; err != nil {
+		log.Printf("Failed to load order for failed delivery event: %v", err)
+		return
+	}
+	data := map[string]interface{}{
+		"delivery_id":    delivery.ID.String(),
+		"order_id":       order.ID.String(),
+		"order_number":   order.OrderNumber,
+		"customer_id":    order.CustomerID.String(),
+		"reason":         string(delivery.FailureReason),
+		"message":        class.Label,
+		"attempt_number": delivery.AttemptNumber,
+	}
+	for k, v := range extra {
+		data[k] = v
+	}
+	go func() {
+		if err := PublishEvent(SubjectDeliveryFailed, "delivery.failed", order.CustomerID, data); err != nil {
+			log.Printf("Failed to publish delivery failed event: %v", err)
+		}
+	}()
+}
@@ -0,0 +1,211 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"math/big"
+
+	"github.com/homechef/api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Pickup and handover codes
+const (
+	// PickupCodeLength is the number of digits in a pickup code
+	PickupCodeLength = 4
+
+	// PickupAlertAttempts is how many wrong pickup codes are entered before
+	// ops are alerted to a possible impostor at the kitchen
+	PickupAlertAttempts = 3
+
+	// MaxPickupAttempts is how many wrong pickup codes a driver may enter before
+	// the order is locked until an admin or fleet manager resets its code with
+	// ResetPickupCode
+	MaxPickupAttempts = 5
+
+	// HandoverCodeLength is the number of digits in a handover code
+	HandoverCodeLength = 4
+
+	// MaxHandoverAttempts is how many wrong codes a driver may enter before
+	// the delivery can only be completed by an admin
+	MaxHandoverAttempts = 5
+
+	// ProofPhotoMaxSize is the largest doorstep photo a driver may upload
+	ProofPhotoMaxSize = 5 * 1024 * 1024
+)
+
+// HandoverError is a driver-facing reason an order could not be collected or
+// handed over
+type HandoverError struct {
+	Message string
+}
+
+func (e *HandoverError) Error() string {
+	return e.Message
+}
+
+// IssuePickupCode gives a newly assigned delivery a fresh pickup code. A
+// driver taking over an order never inherits the previous driver's code.
+func IssuePickupCode(delivery *models.Delivery) error {
+	code, err := generateNumericCode(PickupCodeLength)
+	if err != nil {
+		return err
+	}
+	delivery.PickupCode = code
+	delivery.PickupCodeAttempts = 0
+	return nil
+}
+
+// VerifyPickupCode checks the code a driver entered at the kitchen. The delivery
+// row is locked while the attempts are checked and counted, so parallel guesses
+// cannot get past MaxPickupAttempts or skip the alert. Every wrong code goes in
+// the order's event log, and ops are alerted once PickupAlertAttempts have been
+// entered. Deliveries assigned before pickup codes existed have none and always
+// pass.
+func VerifyPickupCode(db *gorm.DB, delivery *models.Delivery, partner *models.DeliveryPartner, code string) error {
+	if delivery.PickupCode == "" {
+		return nil
+	}
+
+	var rejected error
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var locked models.Delivery
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "pickup_code", "pickup_code_attempts").
+			First(&locked, "id = ?", delivery.ID).Error; err != nil {
+			return fmt.Errorf("failed to lock delivery: %w", err)
+		}
+		delivery.PickupCodeAttempts = locked.PickupCodeAttempts
+
+		if locked.PickupCodeAttempts >= MaxPickupAttempts {
+			rejected = &HandoverError{Message: "Too many incorrect codes. Ask the kitchen to contact support"}
+			return nil
+		}
+		if subtle.ConstantTimeCompare([]byte(code), []byte(locked.PickupCode)) == 1 {
+			return nil
+		}
+
+		if err := tx.Model(&locked).UpdateColumn("pickup_code_attempts", gorm.Expr("pickup_code_attempts + 1")).Error; err != nil {
+			return fmt.Errorf("failed to count pickup attempt: %w", err)
+		}
+		delivery.PickupCodeAttempts++
+
+		if err := RecordOrderEvent(tx, delivery.OrderID, models.OrderEventDelivery, string(delivery.Status), "pickup_code_rejected",
+			ByUser(ActorDriver, partner.UserID).WithNotes(fmt.Sprintf("Wrong pickup code, attempt %d", delivery.PickupCodeAttempts))); err != nil {
+			return err
+		}
+
+		left := MaxPickupAttempts - delivery.PickupCodeAttempts
+		if left == 0 {
+			rejected = &HandoverError{Message: "Incorrect pickup code. Ask the kitchen to contact support"}
+		} else {
+			rejected = &HandoverError{Message: fmt.Sprintf("Incorrect pickup code, %d tries left", left)}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if rejected != nil && delivery.PickupCodeAttempts == PickupAlertAttempts {
+		data := map[string]interface{}{
+			"delivery_id": delivery.ID.String(),
+			"order_id":    delivery.OrderID.String(),
+			"partner_id":  partner.ID.String(),
+			"attempts":    delivery.PickupCodeAttempts,
+		}
+		go func() {
+			if err := PublishEvent(SubjectPickupAlert, "delivery.pickup_alert", partner.UserID, data); err != nil {
+				log.Printf("Failed to publish pickup alert event: %v", err)
+			}
+		}()
+	}
+	return rejected
+}
+
+// ResetPickupCode unlocks a delivery after too many wrong pickup codes. A new
+// code is issued, since the old one may have been guessed at, and the reset
+// goes in the order's event log. The kitchen sees the new code with the order.
+func ResetPickupCode(db *gorm.DB, delivery *models.Delivery, by OrderChangeBy) error {
+	switch delivery.Status {
+	case models.DeliveryAssigned, models.DeliveryAtPickup:
+	default:
+		return &HandoverError{Message: "Only a delivery waiting at the kitchen can have its pickup code reset"}
+	}
+
+	if err := IssuePickupCode(delivery); err != nil {
+		return fmt.Errorf("failed to generate pickup code: %w", err)
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(delivery).Updates(map[string]interface{}{
+			"pickup_code":          delivery.PickupCode,
+			"pickup_code_attempts": 0,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to reset pickup code: %w", err)
+		}
+		return RecordOrderEvent(tx, delivery.OrderID, models.OrderEventDelivery, string(delivery.Status), "pickup_code_reset", by)
+	})
+}
+
+// GenerateHandoverCode returns a random numeric code of HandoverCodeLength digits
+func GenerateHandoverCode() (string, error) {
+	return generateNumericCode(HandoverCodeLength)
+}
+
+// VerifyHandoverCode checks the code a driver entered at the door, counting
+// wrong entries against the delivery. The delivery row is locked while the
+// attempts are checked and counted, so parallel guesses cannot get past
+// MaxHandoverAttempts. Deliveries picked up before handover codes existed have
+// none and always pass.
+func VerifyHandoverCode(db *gorm.DB, delivery *models.Delivery, code string) error {
+	if delivery.HandoverCode == "" {
+		return nil
+	}
+
+	var rejected error
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var locked models.Delivery
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "handover_code", "handover_attempts").
+			First(&locked, "id = ?", delivery.ID).Error; err != nil {
+			return fmt.Errorf("failed to lock delivery: %w", err)
+		}
+		delivery.HandoverAttempts = locked.HandoverAttempts
+
+		if locked.HandoverAttempts >= MaxHandoverAttempts {
+			rejected = &HandoverError{Message: "Too many incorrect codes. Contact support to complete this delivery"}
+			return nil
+		}
+		if subtle.ConstantTimeCompare([]byte(code), []byte(locked.HandoverCode)) == 1 {
+			return nil
+		}
+
+		if err := tx.Model(&locked).UpdateColumn("handover_attempts", gorm.Expr("handover_attempts + 1")).Error; err != nil {
+			return fmt.Errorf("failed to count handover attempt: %w", err)
+		}
+		delivery.HandoverAttempts++
+
+		left := MaxHandoverAttempts - delivery.HandoverAttempts
+		if left == 0 {
+			rejected = &HandoverError{Message: "Incorrect handover code. Contact support to complete this delivery"}
+		} else {
+			rejected = &HandoverError{Message: fmt.Sprintf("Incorrect handover code, %d tries left", left)}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return rejected
+}
+
+// generateNumericCode returns n random decimal digits
+func generateNumericCode(n int) (string, error) {
+	max := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+	v, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate code: %w", err)
+	}
+	return fmt.Sprintf("%0*d", n, v), nil
+}
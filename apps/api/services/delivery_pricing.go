@@ -0,0 +1,152 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/homechef/api/database"
+	"github.com/homechef/api/models"
+	"gorm.io/gorm"
+)
+
+// DeliveryFeeQuote is the priced breakdown of a delivery fee
+type DeliveryFeeQuote struct {
+	ZoneID          *uuid.UUID `json:"zoneId,omitempty"`
+	ZoneName        string     `json:"zoneName,omitempty"`
+	Currency        string     `json:"currency"`
+	DistanceKm      float64    `json:"distanceKm"`
+	BaseFare        float64    `json:"baseFare"`
+	DistanceCharge  float64    `json:"distanceCharge"`
+	MinimumFare     float64    `json:"minimumFare"`
+	SurgeMultiplier float64    `json:"surgeMultiplier"`
+	Fee             float64    `json:"fee"`
+}
+
+// ResolveDeliveryZone finds the active zone serving a delivery. It prefers a
+// zone whose boundary holds both the chef and the dropoff, then one holding the
+// dropoff, and finally falls back to a zone in the chef's city. Overlapping
+// zones are ranked by priority, then size. Returns nil when no zone covers the
+// delivery.
+func ResolveDeliveryZone(chef *models.ChefProfile, dropLat, dropLng float64) (*models.DeliveryZone, error) {
+	if dropLat != 0 || dropLng != 0 {
+		zones, err := zonesAt(database.DB, dropLat, dropLng)
+		if err != nil {
+			return nil, err
+		}
+		if chef.Latitude != 0 || chef.Longitude != 0 {
+			for i := range zones {
+				if zoneCovers(&zones[i], chef.Latitude, chef.Longitude) {
+					return &zones[i], nil
+				}
+			}
+		}
+		if len(zones) > 0 {
+			return &zones[0], nil
+		}
+	}
+
+	if chef.City != "" {
+		var zone models.DeliveryZone
+		err := database.DB.Where("is_active = ? AND LOWER(city) = ?", true, strings.ToLower(chef.City)).
+			Order("created_at").First(&zone).Error
+		if err == nil {
+			return &zone, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+
+	return nil, nil
+}
+
+// QuoteDeliveryFee prices a delivery from a chef to a dropoff point:
+// max(base fare + distance × per-km rate, minimum fare) × surge. Outside every
+// zone the flat fee configured for the chef's country is charged; with none
+// configured the quote is refused with a *ZoneError.
+func QuoteDeliveryFee(chef *models.ChefProfile, dropLat, dropLng float64) (*DeliveryFeeQuote, error) {
+	zone, err := ResolveDeliveryZone(chef, dropLat, dropLng)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve delivery zone: %w", err)
+	}
+
+	distance := 0.0
+	if (chef.Latitude != 0 || chef.Longitude != 0) && (dropLat != 0 || dropLng != 0) {
+		distance = math.Round(haversineDistance(chef.Latitude, chef.Longitude, dropLat, dropLng)*100) / 100
+	}
+
+	if zone == nil {
+		countryCode := ResolveChefCountry(chef)
+		fee, currency, ok := fallbackDeliveryFee(countryCode)
+		if !ok {
+			return nil, &ZoneError{Message: "Delivery is not available from this kitchen to that address"}
+		}
+		log.Printf("No delivery zone for chef %s, using the %s fallback delivery fee", chef.ID, countryCode)
+		return &DeliveryFeeQuote{
+			Currency:        currency,
+			DistanceKm:      distance,
+			BaseFare:        fee,
+			SurgeMultiplier: 1,
+			Fee:             fee,
+		}, nil
+	}
+
+	surge := zone.SurgeMultiplier
+	if surge < 1 {
+		surge = 1
+	}
+
+	distanceCharge := models.RoundAmount(distance * zone.PerKmRate)
+	fee := math.Max(zone.BaseFare+distanceCharge, zone.MinimumFare) * surge
+
+	return &DeliveryFeeQuote{
+		ZoneID:          &zone.ID,
+		ZoneName:        zone.Name,
+		Currency:        zone.Currency,
+		DistanceKm:      distance,
+		BaseFare:        zone.BaseFare,
+		DistanceCharge:  distanceCharge,
+		MinimumFare:     zone.MinimumFare,
+		SurgeMultiplier: surge,
+		Fee:             models.RoundAmount(fee),
+	}, nil
+}
+
+// fallbackDeliveryFee loads the flat fee charged outside every zone in a
+// country, from the delivery.<CC>.default_fee platform setting. The currency
+// comes from delivery.<CC>.currency, else from the country's zones. Reports
+// false when either is missing.
+func fallbackDeliveryFee(countryCode string) (float64, string, bool) {
+	cc := strings.ToUpper(countryCode)
+
+	var setting models.PlatformSettings
+	if err := database.DB.Where("key = ?", fmt.Sprintf("delivery.%s.default_fee", cc)).First(&setting).Error; err != nil {
+		return 0, "", false
+	}
+	fee, err := strconv.ParseFloat(setting.Value, 64)
+	if err != nil || fee < 0 {
+		log.Printf("Invalid fallback delivery fee %q for %s", setting.Value, cc)
+		return 0, "", false
+	}
+
+	var currency string
+	var currencySetting models.PlatformSettings
+	if err := database.DB.Where("key = ?", fmt.Sprintf("delivery.%s.currency", cc)).First(&currencySetting).Error; err == nil {
+		currency = strings.ToUpper(strings.TrimSpace(currencySetting.Value))
+	} else {
+		var zone models.DeliveryZone
+		if err := database.DB.Select("currency").Where("country = ? AND currency <> ''", cc).
+			Order("is_active DESC, created_at").First(&zone).Error; err == nil {
+			currency = zone.Currency
+		}
+	}
+	if currency == "" {
+		return 0, "", false
+	}
+	return models.RoundAmount(fee), currency, true
+}
@@ -0,0 +1,191 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/homechef/api/models"
+	"gorm.io/gorm"
+)
+
+// Slot defaults for chefs without saved settings
+const (
+	DefaultSlotMinutes         = 30
+	DefaultSlotCapacity        = 5
+	DefaultScheduleLeadMinutes = 60
+	DefaultPrepMinutes         = 30
+
+	// MaxSlotDays is how far ahead an order may be scheduled
+	MaxSlotDays = 14
+)
+
+// DeliverySlot is a bookable delivery window for scheduled orders
+type DeliverySlot struct {
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end"`
+	Capacity  int       `json:"capacity"` // 0 = unlimited
+	Booked    int       `json:"booked"`
+	Available bool      `json:"available"`
+}
+
+// SlotError is a customer-facing reason a scheduled time was refused
+type SlotError struct {
+	Message string
+}
+
+func (e *SlotError) Error() string {
+	return e.Message
+}
+
+// SlotSettings are the scheduling knobs from a chef's settings
+type SlotSettings struct {
+	SlotMinutes int
+	Capacity    int
+	LeadMinutes int
+	PrepMinutes int
+}
+
+var prepTimePattern = regexp.MustCompile(`\d+`)
+
+// ChefPrepMinutes reads the upper bound of a chef's free-text prep time such as
+// "30-45 min", falling back to DefaultPrepMinutes
+func ChefPrepMinutes(chef *models.ChefProfile) int {
+	nums := prepTimePattern.FindAllString(chef.PrepTime, -1)
+	if len(nums) == 0 {
+		return DefaultPrepMinutes
+	}
+	minutes, err := strconv.Atoi(nums[len(nums)-1])
+	if err != nil || minutes <= 0 {
+		return DefaultPrepMinutes
+	}
+	return minutes
+}
+
+// LoadSlotSettings returns a chef's slot settings with defaults filled in
+func LoadSlotSettings(db *gorm.DB, chef *models.ChefProfile) SlotSettings {
+	cfg := SlotSettings{
+		SlotMinutes: DefaultSlotMinutes,
+		Capacity:    DefaultSlotCapacity,
+		LeadMinutes: DefaultScheduleLeadMinutes,
+		PrepMinutes: ChefPrepMinutes(chef),
+	}
+
+	var settings models.ChefSettings
+	if err := db.Where("chef_id = ?", chef.ID).First(&settings).Error; err == nil {
+		if settings.SlotMinutes > 0 {
+			cfg.SlotMinutes = settings.SlotMinutes
+		}
+		cfg.Capacity = settings.SlotCapacity
+		cfg.LeadMinutes = settings.ScheduleLeadMinutes
+	}
+	return cfg
+}
+
+// GenerateDeliverySlots turns a chef's weekly schedule into delivery windows for
+// the given number of days starting on from's date in the chef's timezone. A window opens no earlier
+// than prep time after the kitchen opens, ends by closing time, and starts at
+// least lead + prep time after now. Windows already at capacity are returned
+// with Available false.
+func GenerateDeliverySlots(db *gorm.DB, chef *models.ChefProfile, cfg SlotSettings, from, now time.Time, days int) ([]DeliverySlot, error) {
+	var schedules []models.ChefSchedule
+	if err := db.Where("chef_id = ? AND is_closed = ?", chef.ID, false).Find(&schedules).Error; err != nil {
+		return nil, fmt.Errorf("failed to load chef schedule: %w", err)
+	}
+
+	loc := ChefLocation(db, chef.ID)
+	from = from.In(loc)
+	day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, loc)
+	earliest := now.Add(time.Duration(cfg.LeadMinutes+cfg.PrepMinutes) * time.Minute)
+	slotLen := time.Duration(cfg.SlotMinutes) * time.Minute
+
+	slots := []DeliverySlot{}
+	for d := 0; d < days; d++ {
+		date := day.AddDate(0, 0, d)
+		for _, s := range schedules {
+			if s.DayOfWeek != int(date.Weekday()) {
+				continue
+			}
+			open, err1 := time.Parse("15:04", s.OpenTime)
+			closeAt, err2 := time.Parse("15:04", s.CloseTime)
+			if err1 != nil || err2 != nil {
+				continue
+			}
+
+			openAt := date.Add(time.Duration(open.Hour())*time.Hour + time.Duration(open.Minute())*time.Minute)
+			end := date.Add(time.Duration(closeAt.Hour())*time.Hour + time.Duration(closeAt.Minute())*time.Minute)
+			if !end.After(openAt) {
+				end = end.AddDate(0, 0, 1) // runs past midnight
+			}
+
+			for start := openAt.Add(time.Duration(cfg.PrepMinutes) * time.Minute); !start.Add(slotLen).After(end); start = start.Add(slotLen) {
+				if start.Before(earliest) {
+					continue
+				}
+				slots = append(slots, DeliverySlot{Start: start, End: start.Add(slotLen), Capacity: cfg.Capacity})
+			}
+		}
+	}
+
+	if len(slots) == 0 {
+		return slots, nil
+	}
+
+	booked, err := scheduledOrderTimes(db, chef.ID, slots[0].Start, slots[len(slots)-1].End)
+	if err != nil {
+		return nil, err
+	}
+	for i := range slots {
+		for _, at := range booked {
+			if !at.Before(slots[i].Start) && at.Before(slots[i].End) {
+				slots[i].Booked++
+			}
+		}
+		slots[i].Available = slots[i].Capacity == 0 || slots[i].Booked < slots[i].Capacity
+	}
+	return slots, nil
+}
+
+// ValidateScheduledFor checks a requested delivery time against the chef's
+// bookable slots and returns the slot it falls in. Returns a *SlotError when
+// the time is too soon, outside the chef's hours or in a full slot. Pass the
+// checkout transaction so the capacity count sees concurrent bookings.
+func ValidateScheduledFor(db *gorm.DB, chef *models.ChefProfile, cfg SlotSettings, at, now time.Time) (*DeliverySlot, error) {
+	earliest := now.Add(time.Duration(cfg.LeadMinutes+cfg.PrepMinutes) * time.Minute)
+	if at.Before(earliest) {
+		return nil, &SlotError{Message: fmt.Sprintf("Scheduled orders need at least %d minutes notice", cfg.LeadMinutes+cfg.PrepMinutes)}
+	}
+	if at.After(now.AddDate(0, 0, MaxSlotDays)) {
+		return nil, &SlotError{Message: fmt.Sprintf("Orders can be scheduled at most %d days ahead", MaxSlotDays)}
+	}
+
+	// Start a day early so windows running past midnight are included
+	slots, err := GenerateDeliverySlots(db, chef, cfg, at.AddDate(0, 0, -1), now, 2)
+	if err != nil {
+		return nil, err
+	}
+	for i := range slots {
+		if !at.Before(slots[i].Start) && at.Before(slots[i].End) {
+			if !slots[i].Available {
+				return nil, &SlotError{Message: "This delivery slot is full, please pick another time"}
+			}
+			return &slots[i], nil
+		}
+	}
+	return nil, &SlotError{Message: "The chef is not delivering at the requested time"}
+}
+
+// scheduledOrderTimes returns the scheduled times of a chef's live orders in a range
+func scheduledOrderTimes(db *gorm.DB, chefID uuid.UUID, from, to time.Time) ([]time.Time, error) {
+	var times []time.Time
+	err := db.Model(&models.Order{}).
+		Where("chef_id = ? AND scheduled_for >= ? AND scheduled_for < ? AND status NOT IN ?", chefID, from, to,
+			[]models.OrderStatus{models.OrderStatusCancelled, models.OrderStatusRefunded}).
+		Pluck("scheduled_for", &times).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count scheduled orders: %w", err)
+	}
+	return times, nil
+}
@@ -0,0 +1,241 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/homechef/api/database"
+	"github.com/homechef/api/models"
+	"gorm.io/gorm"
+)
+
+const (
+	// SurgeSweepInterval is how often zone surge multipliers are recomputed
+	SurgeSweepInterval = 2 * time.Minute
+
+	// SurgeMinHold is how long a zone keeps a surge multiplier before the job
+	// may move it again, so a zone does not flap between steps
+	SurgeMinHold = 10 * time.Minute
+
+	// SurgeRaiseRatio is the waiting orders per online driver at which surge
+	// steps up
+	SurgeRaiseRatio = 1.5
+
+	// SurgeLowerRatio is the waiting orders per online driver at which surge
+	// steps down. Between the two ratios the multiplier holds.
+	SurgeLowerRatio = 0.75
+)
+
+// Surge limits used when a zone has none set
+const (
+	defaultSurgeFloor   = 1.0
+	defaultSurgeCeiling = 2.0
+	defaultSurgeStep    = 0.25
+)
+
+// ValidateSurgeLimits checks a zone's automatic surge settings
+func ValidateSurgeLimits(zone *models.DeliveryZone) error {
+	switch {
+	case zone.SurgeFloor < 1:
+		return &ZoneError{Message: "Surge floor cannot be below 1"}
+	case zone.SurgeCeiling < zone.SurgeFloor:
+		return &ZoneError{Message: "Surge ceiling cannot be below the floor"}
+	case zone.SurgeStep <= 0:
+		return &ZoneError{Message: "Surge step must be positive"}
+	case zone.SurgeMultiplier < 1:
+		return &ZoneError{Message: "Surge multiplier cannot be below 1"}
+	}
+	return nil
+}
+
+// RecordSurgeChange saves a zone's new surge multiplier and adds it to the
+// zone's surge history
+func RecordSurgeChange(tx *gorm.DB, zone *models.DeliveryZone, from float64, source models.SurgeSource, changedBy *uuid.UUID) error {
+	now := time.Now()
+	if err := tx.Model(zone).Updates(map[string]interface{}{
+		"surge_multiplier": zone.SurgeMultiplier,
+		"surge_changed_at": now,
+	}).Error; err != nil {
+		return fmt.Errorf("failed to update surge: %w", err)
+	}
+	zone.SurgeChangedAt = &now
+
+	change := models.ZoneSurgeChange{
+		ZoneID:         zone.ID,
+		FromMultiplier: from,
+		ToMultiplier:   zone.SurgeMultiplier,
+		Demand:         zone.SurgeDemand,
+		Supply:         zone.SurgeSupply,
+		Source:         source,
+		ChangedByID:    changedBy,
+	}
+	if err := tx.Create(&change).Error; err != nil {
+		return fmt.Errorf("failed to record surge change: %w", err)
+	}
+	return nil
+}
+
+// surgePoint is a coordinate counted towards a zone's demand or supply
+type surgePoint struct {
+	Lat float64
+	Lng float64
+}
+
+// UpdateZoneSurge recomputes every active zone's demand, the ready orders
+// waiting for a driver at a kitchen in the zone, and supply, the partners
+// online in it. Zones on automatic surge then step their multiplier up or
+// down within their limits.
+func UpdateZoneSurge(ctx context.Context) error {
+	db := database.DB.WithContext(ctx)
+	now := time.Now()
+
+	var zones []models.DeliveryZone
+	if err := db.Where("is_active = ?", true).
+		Order("priority DESC, (max_latitude - min_latitude) * (max_longitude - min_longitude)").
+		Find(&zones).Error; err != nil {
+		return err
+	}
+	if len(zones) == 0 {
+		return nil
+	}
+	shapes := make([]*ZoneShape, len(zones))
+	for i := range zones {
+		shape, err := NewZoneShape(&zones[i])
+		if err != nil {
+			log.Printf("Surge: zone %s has an invalid boundary: %v", zones[i].ID, err)
+			continue
+		}
+		shapes[i] = shape
+	}
+
+	var waiting []surgePoint
+	if err := db.Model(&models.Order{}).
+		Select("chef_profiles.latitude AS lat, chef_profiles.longitude AS lng").
+		Joins("JOIN chef_profiles ON chef_profiles.id = orders.chef_id").
+		Where("orders.status = ?", models.OrderStatusReady).
+		Scan(&waiting).Error; err != nil {
+		return err
+	}
+	var online []surgePoint
+	if err := db.Model(&models.DeliveryPartner{}).
+		Select("current_latitude AS lat, current_longitude AS lng").
+		Where("is_online = ? AND is_active = ? AND (current_latitude <> 0 OR current_longitude <> 0)", true, true).
+		Scan(&online).Error; err != nil {
+		return err
+	}
+
+	demand := make([]int, len(zones))
+	for _, p := range waiting {
+		if i := locateZone(zones, shapes, p); i >= 0 {
+			demand[i]++
+		}
+	}
+	supply := make([]int, len(zones))
+	for _, p := range online {
+		if i := locateZone(zones, shapes, p); i >= 0 {
+			supply[i]++
+		}
+	}
+
+	changed := 0
+	for i := range zones {
+		zone := &zones[i]
+		zone.SurgeDemand, zone.SurgeSupply = demand[i], supply[i]
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(zone).Updates(map[string]interface{}{
+				"surge_demand":      zone.SurgeDemand,
+				"surge_supply":      zone.SurgeSupply,
+				"surge_computed_at": now,
+			}).Error; err != nil {
+				return err
+			}
+			if !zone.AutoSurge {
+				return nil
+			}
+
+			from := zone.SurgeMultiplier
+			zone.SurgeMultiplier = nextSurgeMultiplier(zone, now)
+			if zone.SurgeMultiplier == from {
+				return nil
+			}
+			changed++
+			return RecordSurgeChange(tx, zone, from, models.SurgeSourceAuto, nil)
+		})
+		if err != nil {
+			log.Printf("Surge: failed to update zone %s: %v", zone.ID, err)
+		}
+	}
+
+	if changed > 0 {
+		log.Printf("Surge sweep: %d zones changed", changed)
+	}
+	return nil
+}
+
+// locateZone returns the index of the best zone covering a point, or -1.
+// zones must be ordered best first.
+func locateZone(zones []models.DeliveryZone, shapes []*ZoneShape, p surgePoint) int {
+	if p.Lat == 0 && p.Lng == 0 {
+		return -1
+	}
+	for i := range zones {
+		z := &zones[i]
+		if shapes[i] == nil || p.Lat < z.MinLatitude || p.Lat > z.MaxLatitude || p.Lng < z.MinLongitude || p.Lng > z.MaxLongitude {
+			continue
+		}
+		if shapes[i].Contains(p.Lat, p.Lng) {
+			return i
+		}
+	}
+	return -1
+}
+
+// nextSurgeMultiplier steps a zone's multiplier one step towards its demand.
+// Surge rises once waiting orders reach SurgeRaiseRatio per driver and falls
+// once they drop to SurgeLowerRatio, holding in between and for SurgeMinHold
+// after every change. A multiplier outside the limits is brought back at once.
+func nextSurgeMultiplier(zone *models.DeliveryZone, now time.Time) float64 {
+	floor, ceiling, step := zone.SurgeFloor, zone.SurgeCeiling, zone.SurgeStep
+	if floor < 1 {
+		floor = defaultSurgeFloor
+	}
+	if ceiling < floor {
+		ceiling = math.Max(defaultSurgeCeiling, floor)
+	}
+	if step <= 0 {
+		step = defaultSurgeStep
+	}
+
+	current := zone.SurgeMultiplier
+	if current < floor {
+		return floor
+	}
+	if current > ceiling {
+		return ceiling
+	}
+	if zone.SurgeChangedAt != nil && now.Sub(*zone.SurgeChangedAt) < SurgeMinHold {
+		return current
+	}
+
+	var ratio float64
+	switch {
+	case zone.SurgeSupply > 0:
+		ratio = float64(zone.SurgeDemand) / float64(zone.SurgeSupply)
+	case zone.SurgeDemand > 0:
+		ratio = math.Inf(1)
+	}
+
+	next := current
+	switch {
+	case ratio >= SurgeRaiseRatio:
+		next = math.Min(current+step, ceiling)
+	case ratio <= SurgeLowerRatio:
+		next = math.Max(current-step, floor)
+	}
+	return math.Round(next*100) / 100
+}
@@ -0,0 +1,580 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/homechef/api/models"
+	"gorm.io/gorm"
+)
+
+// ZoneImportMaxSize is the largest GeoJSON file accepted by a zone import
+const ZoneImportMaxSize = 10 * 1024 * 1024
+
+// ZoneError is an admin-facing reason a zone boundary was rejected
+type ZoneError struct {
+	Message string
+}
+
+func (e *ZoneError) Error() string {
+	return e.Message
+}
+
+// zonePoint is a GeoJSON position: longitude first, then latitude
+type zonePoint [2]float64
+
+// zoneRing is a closed linear ring; the first and last points are equal
+type zoneRing []zonePoint
+
+// zonePolygon is an outer ring followed by any holes cut out of it
+type zonePolygon []zoneRing
+
+// ZoneShape is the area a delivery zone covers, parsed from its GeoJSON
+// boundary or, for zones without one, its bounding box.
+type ZoneShape struct {
+	polygons []zonePolygon
+}
+
+// geoJSONObject holds the fields of a GeoJSON object this package reads
+type geoJSONObject struct {
+	Type        string                 `json:"type"`
+	Coordinates json.RawMessage        `json:"coordinates,omitempty"`
+	Geometry    *geoJSONObject         `json:"geometry,omitempty"`
+	Properties  map[string]interface{} `json:"properties,omitempty"`
+	Features    []geoJSONObject        `json:"features,omitempty"`
+}
+
+// hasZoneBoundary reports whether a stored boundary holds a geometry
+func hasZoneBoundary(boundary string) bool {
+	b := strings.TrimSpace(boundary)
+	return b != "" && b != "{}" && b != "null"
+}
+
+// ParseZoneBoundary parses and validates a GeoJSON Polygon or MultiPolygon,
+// bare or wrapped in a Feature. Rings must be closed, hold at least four
+// positions and stay within valid coordinates; every hole must lie inside its
+// outer ring. Problems are returned as a *ZoneError.
+func ParseZoneBoundary(boundary string) (*ZoneShape, error) {
+	var obj geoJSONObject
+	if err := json.Unmarshal([]byte(boundary), &obj); err != nil {
+		return nil, &ZoneError{Message: "Boundary is not valid JSON"}
+	}
+	return parseZoneGeometry(&obj)
+}
+
+func parseZoneGeometry(obj *geoJSONObject) (*ZoneShape, error) {
+	if obj.Type == "Feature" {
+		if obj.Geometry == nil {
+			return nil, &ZoneError{Message: "Boundary feature has no geometry"}
+		}
+		obj = obj.Geometry
+	}
+
+	var polygons []zonePolygon
+	switch obj.Type {
+	case "Polygon":
+		var polygon zonePolygon
+		if err := json.Unmarshal(obj.Coordinates, &polygon); err != nil {
+			return nil, &ZoneError{Message: "Polygon coordinates are malformed"}
+		}
+		polygons = []zonePolygon{polygon}
+	case "MultiPolygon":
+		if err := json.Unmarshal(obj.Coordinates, &polygons); err != nil {
+			return nil, &ZoneError{Message: "MultiPolygon coordinates are malformed"}
+		}
+	default:
+		return nil, &ZoneError{Message: fmt.Sprintf("Boundary must be a Polygon or MultiPolygon, got %q", obj.Type)}
+	}
+
+	if len(polygons) == 0 {
+		return nil, &ZoneError{Message: "Boundary has no polygons"}
+	}
+	for i, polygon := range polygons {
+		if err := validateZonePolygon(polygon); err != nil {
+			return nil, &ZoneError{Message: fmt.Sprintf("Polygon %d: %s", i+1, err.Error())}
+		}
+	}
+	return &ZoneShape{polygons: polygons}, nil
+}
+
+func validateZonePolygon(polygon zonePolygon) error {
+	if len(polygon) == 0 {
+		return errors.New("polygon has no rings")
+	}
+	for i, ring := range polygon {
+		name := "outer ring"
+		if i > 0 {
+			name = fmt.Sprintf("hole %d", i)
+		}
+		if len(ring) < 4 {
+			return fmt.Errorf("%s needs at least 4 positions", name)
+		}
+		if ring[0] != ring[len(ring)-1] {
+			return fmt.Errorf("%s is not closed", name)
+		}
+		for _, p := range ring {
+			if p[0] < -180 || p[0] > 180 || p[1] < -90 || p[1] > 90 {
+				return fmt.Errorf("%s has a position out of range: [%g, %g]", name, p[0], p[1])
+			}
+		}
+		if ringArea(ring) == 0 {
+			return fmt.Errorf("%s has no area", name)
+		}
+		if i > 0 {
+			for _, p := range ring {
+				if !onRingEdge(polygon[0], p) && !ringContains(polygon[0], p) {
+					return fmt.Errorf("%s lies outside the outer ring", name)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// NewZoneShape returns the shape a zone covers: its boundary when it has one,
+// otherwise its bounding box. Returns nil for a zone with neither.
+func NewZoneShape(zone *models.DeliveryZone) (*ZoneShape, error) {
+	if hasZoneBoundary(zone.Boundary) {
+		return ParseZoneBoundary(zone.Boundary)
+	}
+	if zone.MinLatitude >= zone.MaxLatitude || zone.MinLongitude >= zone.MaxLongitude {
+		return nil, nil
+	}
+	ring := zoneRing{
+		{zone.MinLongitude, zone.MinLatitude},
+		{zone.MaxLongitude, zone.MinLatitude},
+		{zone.MaxLongitude, zone.MaxLatitude},
+		{zone.MinLongitude, zone.MaxLatitude},
+		{zone.MinLongitude, zone.MinLatitude},
+	}
+	return &ZoneShape{polygons: []zonePolygon{{ring}}}, nil
+}
+
+// Bounds returns the shape's bounding box
+func (s *ZoneShape) Bounds() (minLat, maxLat, minLng, maxLng float64) {
+	minLat, minLng = math.Inf(1), math.Inf(1)
+	maxLat, maxLng = math.Inf(-1), math.Inf(-1)
+	for _, polygon := range s.polygons {
+		for _, p := range polygon[0] {
+			minLng, maxLng = math.Min(minLng, p[0]), math.Max(maxLng, p[0])
+			minLat, maxLat = math.Min(minLat, p[1]), math.Max(maxLat, p[1])
+		}
+	}
+	return minLat, maxLat, minLng, maxLng
+}
+
+// Contains reports whether a coordinate falls inside the shape and outside
+// any of its holes. Points on an edge count as inside.
+func (s *ZoneShape) Contains(lat, lng float64) bool {
+	p := zonePoint{lng, lat}
+	for _, polygon := range s.polygons {
+		if !onRingEdge(polygon[0], p) && !ringContains(polygon[0], p) {
+			continue
+		}
+		inHole := false
+		for _, hole := range polygon[1:] {
+			if !onRingEdge(hole, p) && ringContains(hole, p) {
+				inHole = true
+				break
+			}
+		}
+		if !inHole {
+			return true
+		}
+	}
+	return false
+}
+
+// Overlaps reports whether two shapes share any area. Zones that only touch
+// along an edge or at a corner do not overlap.
+func (s *ZoneShape) Overlaps(other *ZoneShape) bool {
+	aMinLat, aMaxLat, aMinLng, aMaxLng := s.Bounds()
+	bMinLat, bMaxLat, bMinLng, bMaxLng := other.Bounds()
+	if aMinLat >= bMaxLat || bMinLat >= aMaxLat || aMinLng >= bMaxLng || bMinLng >= aMaxLng {
+		return false
+	}
+
+	for _, a := range s.polygons {
+		for _, b := range other.polygons {
+			if polygonsOverlap(a, b) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func polygonsOverlap(a, b zonePolygon) bool {
+	for _, ra := range a {
+		for _, rb := range b {
+			if ringsCross(ra, rb) {
+				return true
+			}
+		}
+	}
+	// No edges cross, so either one outer ring sits inside the other's area or
+	// they are disjoint. A vertex strictly inside the other polygon settles it;
+	// identical outlines have none, so compare an interior point as well.
+	for _, p := range a[0] {
+		if polygonContainsStrictly(b, p) {
+			return true
+		}
+	}
+	for _, p := range b[0] {
+		if polygonContainsStrictly(a, p) {
+			return true
+		}
+	}
+	if c, ok := ringInteriorPoint(a[0]); ok && polygonContainsStrictly(b, c) {
+		return true
+	}
+	return false
+}
+
+func polygonContainsStrictly(polygon zonePolygon, p zonePoint) bool {
+	if onRingEdge(polygon[0], p) || !ringContains(polygon[0], p) {
+		return false
+	}
+	for _, hole := range polygon[1:] {
+		if onRingEdge(hole, p) || ringContains(hole, p) {
+			return false
+		}
+	}
+	return true
+}
+
+// ringContains is the even-odd ray casting test. Results for points exactly on
+// an edge are unspecified; callers check onRingEdge first.
+func ringContains(ring zoneRing, p zonePoint) bool {
+	inside := false
+	for i, j := 0, len(ring)-2; i < len(ring)-1; j, i = i, i+1 {
+		a, b := ring[i], ring[j]
+		if (a[1] > p[1]) != (b[1] > p[1]) &&
+			p[0] < (b[0]-a[0])*(p[1]-a[1])/(b[1]-a[1])+a[0] {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+func onRingEdge(ring zoneRing, p zonePoint) bool {
+	for i := 0; i < len(ring)-1; i++ {
+		a, b := ring[i], ring[i+1]
+		if orientation(a, b, p) == 0 &&
+			p[0] >= math.Min(a[0], b[0]) && p[0] <= math.Max(a[0], b[0]) &&
+			p[1] >= math.Min(a[1], b[1]) && p[1] <= math.Max(a[1], b[1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// ringsCross reports whether any edge of one ring properly crosses an edge of
+// the other. Shared or touching edges are not crossings.
+func ringsCross(a, b zoneRing) bool {
+	for i := 0; i < len(a)-1; i++ {
+		for j := 0; j < len(b)-1; j++ {
+			o1 := orientation(a[i], a[i+1], b[j])
+			o2 := orientation(a[i], a[i+1], b[j+1])
+			o3 := orientation(b[j], b[j+1], a[i])
+			o4 := orientation(b[j], b[j+1], a[i+1])
+			if o1*o2 < 0 && o3*o4 < 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// orientation is the sign of the cross product (b - a) × (c - a)
+func orientation(a, b, c zonePoint) int {
+	v := (b[0]-a[0])*(c[1]-a[1]) - (b[1]-a[1])*(c[0]-a[0])
+	switch {
+	case v > 1e-12:
+		return 1
+	case v < -1e-12:
+		return -1
+	}
+	return 0
+}
+
+// ringArea is the shoelace area of a ring in square degrees
+func ringArea(ring zoneRing) float64 {
+	sum := 0.0
+	for i := 0; i < len(ring)-1; i++ {
+		sum += ring[i][0]*ring[i+1][1] - ring[i+1][0]*ring[i][1]
+	}
+	return math.Abs(sum) / 2
+}
+
+// ringInteriorPoint finds a point strictly inside a ring by probing the
+// midpoints between its first vertex and each of the others
+func ringInteriorPoint(ring zoneRing) (zonePoint, bool) {
+	for i := 1; i < len(ring)-1; i++ {
+		for j := i + 1; j < len(ring)-1; j++ {
+			c := zonePoint{
+				(ring[0][0] + ring[i][0] + ring[j][0]) / 3,
+				(ring[0][1] + ring[i][1] + ring[j][1]) / 3,
+			}
+			if !onRingEdge(ring, c) && ringContains(ring, c) {
+				return c, true
+			}
+		}
+	}
+	return zonePoint{}, false
+}
+
+// PrepareZone validates a zone's boundary before it is saved. A zone with a
+// boundary has its bounding box derived from it. An active zone may not
+// overlap another active zone of the same priority, since neither would win
+// when resolving a coordinate; overlaps with zones of a different priority
+// are allowed and returned so the caller can report them.
+func PrepareZone(db *gorm.DB, zone *models.DeliveryZone) ([]models.DeliveryZone, error) {
+	if !hasZoneBoundary(zone.Boundary) {
+		zone.Boundary = "{}"
+	}
+
+	shape, err := NewZoneShape(zone)
+	if err != nil {
+		return nil, err
+	}
+	if shape == nil {
+		if zone.MinLatitude != 0 || zone.MaxLatitude != 0 || zone.MinLongitude != 0 || zone.MaxLongitude != 0 {
+			return nil, &ZoneError{Message: "Bounding box minimums must be below its maximums"}
+		}
+		return nil, nil
+	}
+	if hasZoneBoundary(zone.Boundary) {
+		zone.MinLatitude, zone.MaxLatitude, zone.MinLongitude, zone.MaxLongitude = shape.Bounds()
+	}
+	if !zone.IsActive {
+		return nil, nil
+	}
+
+	var candidates []models.DeliveryZone
+	query := db.Where("is_active = ? AND min_latitude < ? AND max_latitude > ? AND min_longitude < ? AND max_longitude > ?",
+		true, zone.MaxLatitude, zone.MinLatitude, zone.MaxLongitude, zone.MinLongitude)
+	if zone.ID != uuid.Nil {
+		query = query.Where("id <> ?", zone.ID)
+	}
+	if err := query.Find(&candidates).Error; err != nil {
+		return nil, fmt.Errorf("failed to load neighbouring zones: %w", err)
+	}
+
+	var overlaps []models.DeliveryZone
+	for _, candidate := range candidates {
+		other, err := NewZoneShape(&candidate)
+		if err != nil {
+			log.Printf("Zone %s has an invalid boundary: %v", candidate.ID, err)
+			continue
+		}
+		if other == nil || !shape.Overlaps(other) {
+			continue
+		}
+		if candidate.Priority == zone.Priority {
+			return nil, &ZoneError{Message: fmt.Sprintf(
+				"Zone overlaps %q (%s) at the same priority %d; change the boundary or give one zone a higher priority",
+				candidate.Name, candidate.City, candidate.Priority)}
+		}
+		overlaps = append(overlaps, candidate)
+	}
+	return overlaps, nil
+}
+
+// zonesAt lists the active zones covering a coordinate, best match first.
+// Zones are prefiltered by bounding box, then tested against their boundary;
+// zones without a boundary are matched on the bounding box alone.
+func zonesAt(db *gorm.DB, lat, lng float64) ([]models.DeliveryZone, error) {
+	var candidates []models.DeliveryZone
+	err := db.Where("is_active = ? AND min_latitude <= ? AND max_latitude >= ? AND min_longitude <= ? AND max_longitude >= ?",
+		true, lat, lat, lng, lng).
+		Order("priority DESC, (max_latitude - min_latitude) * (max_longitude - min_longitude)").
+		Find(&candidates).Error
+	if err != nil {
+		return nil, err
+	}
+
+	zones := candidates[:0]
+	for _, zone := range candidates {
+		if zoneContains(&zone, lat, lng) {
+			zones = append(zones, zone)
+		}
+	}
+	return zones, nil
+}
+
+// zoneCovers reports whether a coordinate falls inside a zone, checking its
+// bounding box before its boundary
+func zoneCovers(zone *models.DeliveryZone, lat, lng float64) bool {
+	if lat < zone.MinLatitude || lat > zone.MaxLatitude || lng < zone.MinLongitude || lng > zone.MaxLongitude {
+		return false
+	}
+	return zoneContains(zone, lat, lng)
+}
+
+// zoneContains tests a coordinate against a zone already known to hold it in
+// its bounding box. A stored boundary that no longer parses falls back to the
+// bounding box.
+func zoneContains(zone *models.DeliveryZone, lat, lng float64) bool {
+	if !hasZoneBoundary(zone.Boundary) {
+		return true
+	}
+	shape, err := ParseZoneBoundary(zone.Boundary)
+	if err != nil {
+		log.Printf("Zone %s has an invalid boundary, using its bounding box: %v", zone.ID, err)
+		return true
+	}
+	return shape.Contains(lat, lng)
+}
+
+// ZoneImportResult summarises a zone boundary import
+type ZoneImportResult struct {
+	Created  []models.DeliveryZoneResponse `json:"created"`
+	Updated  []models.DeliveryZoneResponse `json:"updated"`
+	Warnings []string                      `json:"warnings"`
+}
+
+// ZoneImportError lists every feature an import rejected
+type ZoneImportError struct {
+	Problems []string
+}
+
+func (e *ZoneImportError) Error() string {
+	return fmt.Sprintf("%d features rejected", len(e.Problems))
+}
+
+// ImportZoneBoundaries reads a GeoJSON FeatureCollection and saves each
+// feature's geometry as a zone boundary. A feature updates the zone with the
+// same name and city, or creates a new zone with default pricing. Properties
+// read are name and city (required), and state, country, tier and priority.
+// The import is all or nothing: if any feature is rejected nothing is saved
+// and a *ZoneImportError lists the problems.
+func ImportZoneBoundaries(db *gorm.DB, r io.Reader) (*ZoneImportResult, error) {
+	var collection geoJSONObject
+	if err := json.NewDecoder(r).Decode(&collection); err != nil {
+		return nil, &ZoneError{Message: "File is not valid GeoJSON"}
+	}
+	if collection.Type != "FeatureCollection" {
+		return nil, &ZoneError{Message: "File must be a GeoJSON FeatureCollection"}
+	}
+	if len(collection.Features) == 0 {
+		return nil, &ZoneError{Message: "FeatureCollection has no features"}
+	}
+
+	result := &ZoneImportResult{
+		Created:  []models.DeliveryZoneResponse{},
+		Updated:  []models.DeliveryZoneResponse{},
+		Warnings: []string{},
+	}
+	var problems []string
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for i := range collection.Features {
+			feature := &collection.Features[i]
+			label := fmt.Sprintf("Feature %d", i+1)
+
+			name := featureString(feature.Properties, "name")
+			city := featureString(feature.Properties, "city")
+			if name == "" || city == "" {
+				problems = append(problems, label+": name and city properties are required")
+				continue
+			}
+			label = fmt.Sprintf("%s (%s, %s)", label, name, city)
+
+			if feature.Type != "Feature" {
+				problems = append(problems, label+": not a Feature")
+				continue
+			}
+			if _, err := parseZoneGeometry(feature); err != nil {
+				problems = append(problems, label+": "+err.Error())
+				continue
+			}
+			geometry, err := json.Marshal(feature.Geometry)
+			if err != nil {
+				return err
+			}
+
+			var zone models.DeliveryZone
+			err = tx.Where("LOWER(name) = ? AND LOWER(city) = ?", strings.ToLower(name), strings.ToLower(city)).
+				First(&zone).Error
+			isNew := errors.Is(err, gorm.ErrRecordNotFound)
+			if err != nil && !isNew {
+				return err
+			}
+			if isNew {
+				zone = models.DeliveryZone{
+					Name:                name,
+					City:                city,
+					Country:             "IN",
+					Tier:                "standard",
+					Currency:            "INR",
+					SurgeMultiplier:     1.0,
+					AutoSurge:           true,
+					SurgeFloor:          defaultSurgeFloor,
+					SurgeCeiling:        defaultSurgeCeiling,
+					SurgeStep:           defaultSurgeStep,
+					TipEnabled:          true,
+					DefaultTipPercent:   10,
+					DriverPayoutPercent: 80,
+					IsActive:            true,
+				}
+			}
+			zone.Boundary = string(geometry)
+			if v := featureString(feature.Properties, "state"); v != "" {
+				zone.State = v
+			}
+			if v := featureString(feature.Properties, "country"); v != "" {
+				zone.Country = strings.ToUpper(v)
+			}
+			if v := featureString(feature.Properties, "tier"); v != "" {
+				zone.Tier = v
+			}
+			if v, ok := feature.Properties["priority"].(float64); ok {
+				zone.Priority = int(v)
+			}
+
+			overlaps, err := PrepareZone(tx, &zone)
+			var zoneErr *ZoneError
+			if errors.As(err, &zoneErr) {
+				problems = append(problems, label+": "+zoneErr.Message)
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			for _, o := range overlaps {
+				result.Warnings = append(result.Warnings,
+					fmt.Sprintf("%s overlaps %q (%s) at priority %d", label, o.Name, o.City, o.Priority))
+			}
+
+			if err := tx.Save(&zone).Error; err != nil {
+				return fmt.Errorf("failed to save zone %s: %w", name, err)
+			}
+			if isNew {
+				result.Created = append(result.Created, zone.ToResponse())
+			} else {
+				result.Updated = append(result.Updated, zone.ToResponse())
+			}
+		}
+
+		if len(problems) > 0 {
+			return &ZoneImportError{Problems: problems}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func featureString(properties map[string]interface{}, key string) string {
+	v, _ := properties[key].(string)
+	return strings.TrimSpace(v)
+}
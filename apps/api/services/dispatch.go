@@ -0,0 +1,662 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/homechef/api/database"
+	"github.com/homechef/api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Dispatch tuning
+const (
+	// DeliveryOfferTTL is how long a driver has to answer an offer before it
+	// moves on to the next driver
+	DeliveryOfferTTL = 45 * time.Second
+
+	// DispatchRadiusKm is the farthest a driver may be from the kitchen to be
+	// offered an order
+	DispatchRadiusKm = 10.0
+
+	// DispatchSweepInterval is how often lapsed offers are moved on and ready
+	// orders nobody has taken are offered again
+	DispatchSweepInterval = 15 * time.Second
+)
+
+// Ranking penalties, in km of extra distance. A driver carrying one order
+// ranks like a free driver 2 km further away.
+const (
+	dispatchLoadPenaltyKm       = 2.0
+	dispatchAcceptancePenaltyKm = 3.0 // for a driver who never accepts
+)
+
+// ActiveDeliveryStatuses are the statuses of a delivery a driver is working on
+var ActiveDeliveryStatuses = []models.DeliveryStatus{
+	models.DeliveryAssigned, models.DeliveryAtPickup, models.DeliveryPickedUp,
+	models.DeliveryInTransit, models.DeliveryAtDropoff,
+}
+
+// DispatchError is a driver-facing reason an offer could not be answered
+type DispatchError struct {
+	Message string
+}
+
+func (e *DispatchError) Error() string {
+	return e.Message
+}
+
+// DispatchCandidate is a driver ranked for an order. Lower scores rank first.
+type DispatchCandidate struct {
+	Partner  models.DeliveryPartner
+	Distance float64 // km to the kitchen
+	Load     int     // deliveries already being carried
+	Score    float64
+}
+
+// NewOrderDelivery builds the delivery record for carrying an order, with
+// route distance, duration and payout filled in. The order must be loaded
+// with Chef.
+func NewOrderDelivery(order *models.Order, partnerID uuid.UUID) models.Delivery {
+	distance := haversineDistance(
+		order.Chef.Latitude, order.Chef.Longitude,
+		order.DeliveryLatitude, order.DeliveryLongitude,
+	)
+	estimatedDuration := int(math.Ceil(distance / 0.5)) // ~30km/h average speed, in minutes
+	if estimatedDuration < 10 {
+		estimatedDuration = 10
+	}
+
+	return models.Delivery{
+		OrderID:             order.ID,
+		DeliveryPartnerID:   partnerID,
+		Status:              models.DeliveryAssigned,
+		AssignmentType:      models.AssignmentManual,
+		AttemptNumber:       1,
+		MaxAttempts:         3,
+		PickupStopStatus:    models.StopPending,
+		DropoffStopStatus:   models.StopPending,
+		PickupAddressLine1:  order.Chef.AddressLine1,
+		PickupAddressCity:   order.Chef.City,
+		PickupLatitude:      order.Chef.Latitude,
+		PickupLongitude:     order.Chef.Longitude,
+		DropoffAddressLine1: order.DeliveryAddressLine1,
+		DropoffAddressCity:  order.DeliveryAddressCity,
+		DropoffLatitude:     order.DeliveryLatitude,
+		DropoffLongitude:    order.DeliveryLongitude,
+		Distance:            distance,
+		EstimatedDuration:   estimatedDuration,
+		DeliveryFee:         order.DeliveryFee,
+		Tip:                 order.Tip,
+		TotalPayout:         order.DeliveryFee + order.Tip, // 100% to driver — subscription model
+		AssignedAt:          time.Now(),
+	}
+}
+
+// SaveOrderDelivery stores delivery as its order's delivery record. An order
+// has one delivery row, so an earlier attempt (a cancelled delivery or a lapsed
+// offer) is overwritten in place, keeping its count of failed attempts. Must
+// run inside the transaction holding the order's row lock.
+func SaveOrderDelivery(tx *gorm.DB, delivery *models.Delivery) error {
+	var existing models.Delivery
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("order_id = ?", delivery.OrderID).First(&existing).Error
+	if err == nil {
+		delivery.ID = existing.ID
+		if existing.AttemptNumber > delivery.AttemptNumber {
+			delivery.AttemptNumber = existing.AttemptNumber
+		}
+		return tx.Save(delivery).Error
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return tx.Create(delivery).Error
+}
+
+// RankDispatchCandidates returns the online, verified drivers within
+// DispatchRadiusKm of the kitchen who have room for another order on their
+// current run and no offer waiting on them, best first. Drivers are ranked by distance, with
+// penalties for orders already being carried and for a poor acceptance record.
+func RankDispatchCandidates(db *gorm.DB, order *models.Order, exclude map[uuid.UUID]bool) ([]DispatchCandidate, error) {
+	chefLat, chefLng := order.Chef.Latitude, order.Chef.Longitude
+	if chefLat == 0 && chefLng == 0 {
+		return nil, nil
+	}
+
+	// Bounding box first so the distance check runs on nearby drivers only
+	latSpan := DispatchRadiusKm / 111.0
+	lngSpan := latSpan / math.Max(math.Cos(chefLat*math.Pi/180), 0.01)
+
+	var partners []models.DeliveryPartner
+	if err := db.Where("is_online = ? AND is_verified = ? AND is_active = ?", true, true, true).
+		Where("current_latitude BETWEEN ? AND ? AND current_longitude BETWEEN ? AND ?",
+			chefLat-latSpan, chefLat+latSpan, chefLng-lngSpan, chefLng+lngSpan).
+		Where("id NOT IN (?)", db.Model(&models.Delivery{}).Select("delivery_partner_id").
+			Where("status = ?", models.DeliveryPending)).
+		Find(&partners).Error; err != nil {
+		return nil, fmt.Errorf("failed to load drivers: %w", err)
+	}
+	if len(partners) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]uuid.UUID, len(partners))
+	for i, p := range partners {
+		ids[i] = p.ID
+	}
+	var busy []models.Delivery
+	if err := db.Where("delivery_partner_id IN ? AND status IN ?", ids, ActiveDeliveryStatuses).
+		Find(&busy).Error; err != nil {
+		return nil, fmt.Errorf("failed to load driver deliveries: %w", err)
+	}
+	activeByPartner := make(map[uuid.UUID][]models.Delivery, len(busy))
+	for _, d := range busy {
+		activeByPartner[d.DeliveryPartnerID] = append(activeByPartner[d.DeliveryPartnerID], d)
+	}
+
+	// What the delivery would look like, for checking it fits a driver's batch
+	proposed := NewOrderDelivery(order, uuid.Nil)
+
+	candidates := make([]DispatchCandidate, 0, len(partners))
+	for _, p := range partners {
+		if exclude[p.ID] {
+			continue
+		}
+		active := activeByPartner[p.ID]
+		load := len(active)
+		if CheckBatchFit(&p, active, &proposed) != nil {
+			continue
+		}
+		distance := haversineDistance(p.CurrentLatitude, p.CurrentLongitude, chefLat, chefLng)
+		if distance > DispatchRadiusKm {
+			continue
+		}
+
+		// Rolling 30-day acceptance rate; drivers without metrics yet get the
+		// benefit of the doubt
+		acceptance := 1.0
+		if p.MetricsUpdatedAt != nil {
+			acceptance = p.AcceptanceRate / 100
+		}
+
+		candidates = append(candidates, DispatchCandidate{
+			Partner:  p,
+			Distance: distance,
+			Load:     load,
+			Score:    distance + float64(load)*dispatchLoadPenaltyKm + (1-acceptance)*dispatchAcceptancePenaltyKm,
+		})
+	}
+
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].Score < candidates[j].Score })
+	return candidates, nil
+}
+
+// DispatchOrder offers a ready, unassigned order to the best-ranked driver who
+// has not been offered it yet. Orders that are already offered, assigned or no
+// longer ready are left alone, so it is safe to call more than once.
+func DispatchOrder(orderID uuid.UUID) error {
+	tx := database.DB.Begin()
+
+	var order models.Order
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND status = ? AND delivery_id IS NULL", orderID, models.OrderStatusReady).
+		First(&order).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	offer, err := offerNextPartner(tx, &order, time.Now())
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit().Error; err != nil {
+		return err
+	}
+
+	publishDeliveryOffer(offer)
+	return nil
+}
+
+// AcceptDeliveryOffer assigns an offered delivery to the driver it was offered
+// to, adds it to their run and marks the order driver-assigned. Returns a
+// *DispatchError when the offer has lapsed, was withdrawn or the driver has no
+// room for it.
+func AcceptDeliveryOffer(partner *models.DeliveryPartner, deliveryID uuid.UUID) (*models.Delivery, error) {
+	now := time.Now()
+	tx := database.DB.Begin()
+
+	order, delivery, offer, err := lockOffer(tx, partner.ID, deliveryID, now)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	active, err := LockDriverLoad(tx, partner.ID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := CheckBatchFit(partner, active, delivery); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := IssuePickupCode(delivery); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	delivery.Status = models.DeliveryAssigned
+	delivery.AssignedAt = now
+	delivery.OfferExpiresAt = nil
+	if err := tx.Model(delivery).Updates(map[string]interface{}{
+		"status":               delivery.Status,
+		"assigned_at":          now,
+		"offer_expires_at":     nil,
+		"pickup_code":          delivery.PickupCode,
+		"pickup_code_attempts": 0,
+	}).Error; err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to assign delivery: %w", err)
+	}
+
+	if err := closeOffer(tx, offer, models.OfferAccepted, now); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := AddToBatch(tx, partner, active, delivery); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	by := ByUser(ActorDriver, partner.UserID)
+	if err := RecordOrderEvent(tx, order.ID, models.OrderEventDelivery, string(models.DeliveryPending), string(delivery.Status), by); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	order.EstimatedDeliveryTime = delivery.EstimatedDuration
+	if err := UpdateOrderStatus(tx, order, models.OrderStatusDriverAssigned, by, map[string]interface{}{
+		"estimated_delivery_time": delivery.EstimatedDuration,
+	}); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, err
+	}
+
+	delivery.Order = *order
+	return delivery, nil
+}
+
+// DeclineDeliveryOffer turns an offer down and moves the order on to the next
+// driver straight away
+func DeclineDeliveryOffer(partner *models.DeliveryPartner, deliveryID uuid.UUID) error {
+	now := time.Now()
+	tx := database.DB.Begin()
+
+	order, _, offer, err := lockOffer(tx, partner.ID, deliveryID, now)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := closeOffer(tx, offer, models.OfferDeclined, now); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	next, err := offerNextPartner(tx, order, now)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit().Error; err != nil {
+		return err
+	}
+
+	publishDeliveryOffer(next)
+	return nil
+}
+
+// ExpireDeliveryOffers moves lapsed offers on to the next driver, withdraws
+// offers on orders that are no longer ready, and offers again any ready order
+// that nobody has taken
+func ExpireDeliveryOffers(ctx context.Context) error {
+	db := database.DB.WithContext(ctx)
+	now := time.Now()
+
+	var lapsed []models.Delivery
+	if err := db.Where("status = ? AND offer_expires_at < ?", models.DeliveryPending, now).
+		Find(&lapsed).Error; err != nil {
+		return err
+	}
+	for _, d := range lapsed {
+		if err := expireOffer(d.ID, now); err != nil {
+			log.Printf("Dispatch: failed to expire offer on delivery %s: %v", d.ID, err)
+		}
+	}
+
+	var waiting []uuid.UUID
+	if err := db.Model(&models.Order{}).
+		Where("status = ? AND delivery_id IS NULL AND prepared_at < ?", models.OrderStatusReady, now.Add(-DispatchSweepInterval)).
+		Pluck("id", &waiting).Error; err != nil {
+		return err
+	}
+	for _, id := range waiting {
+		if err := DispatchOrder(id); err != nil {
+			log.Printf("Dispatch: failed to dispatch order %s: %v", id, err)
+		}
+	}
+
+	if len(lapsed) > 0 {
+		log.Printf("Dispatch sweep: %d offers lapsed, %d orders waiting", len(lapsed), len(waiting))
+	}
+	return nil
+}
+
+// expireOffer closes one lapsed offer and moves its order on
+func expireOffer(deliveryID uuid.UUID, now time.Time) error {
+	var delivery models.Delivery
+	if err := database.DB.Where("id = ?", deliveryID).First(&delivery).Error; err != nil {
+		return err
+	}
+
+	tx := database.DB.Begin()
+
+	// Lock the order before the delivery, as every assignment path does
+	var order models.Order
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", delivery.OrderID).First(&order).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND status = ? AND offer_expires_at < ?", deliveryID, models.DeliveryPending, now).
+		First(&delivery).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil // answered in the meantime
+		}
+		return err
+	}
+
+	var offer models.DeliveryOffer
+	if err := tx.Where("delivery_id = ? AND partner_id = ? AND status = ?", delivery.ID, delivery.DeliveryPartnerID, models.OfferPending).
+		First(&offer).Error; err == nil {
+		status := models.OfferExpired
+		if order.Status != models.OrderStatusReady {
+			status = models.OfferWithdrawn
+		}
+		if err := closeOffer(tx, &offer, status, now); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	var next *models.DeliveryOffer
+	if order.Status == models.OrderStatusReady && order.DeliveryID != nil && *order.DeliveryID == delivery.ID {
+		var err error
+		if next, err = offerNextPartner(tx, &order, now); err != nil {
+			tx.Rollback()
+			return err
+		}
+	} else if err := releaseOffer(tx, &order, &delivery, "Order is no longer waiting for a driver", now); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return err
+	}
+	publishDeliveryOffer(next)
+	return nil
+}
+
+// lockOffer locks an order and the delivery offered to a driver on it, and
+// checks the offer can still be answered
+func lockOffer(tx *gorm.DB, partnerID, deliveryID uuid.UUID, now time.Time) (*models.Order, *models.Delivery, *models.DeliveryOffer, error) {
+	var delivery models.Delivery
+	if err := tx.Where("id = ? AND delivery_partner_id = ?", deliveryID, partnerID).First(&delivery).Error; err != nil {
+		return nil, nil, nil, &DispatchError{Message: "Offer not found"}
+	}
+
+	// Lock the order before the delivery, as every assignment path does
+	var order models.Order
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", delivery.OrderID).First(&order).Error; err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to lock order: %w", err)
+	}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND delivery_partner_id = ? AND status = ?", deliveryID, partnerID, models.DeliveryPending).
+		First(&delivery).Error; err != nil {
+		return nil, nil, nil, &DispatchError{Message: "This offer is no longer available"}
+	}
+	if delivery.OfferExpiresAt == nil || now.After(*delivery.OfferExpiresAt) {
+		return nil, nil, nil, &DispatchError{Message: "This offer has expired"}
+	}
+	if order.Status != models.OrderStatusReady || order.DeliveryID == nil || *order.DeliveryID != delivery.ID {
+		return nil, nil, nil, &DispatchError{Message: "This offer is no longer available"}
+	}
+
+	var offer models.DeliveryOffer
+	if err := tx.Where("delivery_id = ? AND partner_id = ? AND status = ?", delivery.ID, partnerID, models.OfferPending).
+		First(&offer).Error; err != nil {
+		return nil, nil, nil, &DispatchError{Message: "This offer is no longer available"}
+	}
+
+	return &order, &delivery, &offer, nil
+}
+
+// offerNextPartner offers a locked, ready order to the best driver not yet
+// offered it, reusing the order's delivery row. With nobody left the order is
+// released to the open list of available deliveries. Returns the new offer, or
+// nil if there was nobody to offer it to.
+func offerNextPartner(tx *gorm.DB, order *models.Order, now time.Time) (*models.DeliveryOffer, error) {
+	if err := tx.Where("id = ?", order.ChefID).First(&order.Chef).Error; err != nil {
+		return nil, fmt.Errorf("failed to load chef: %w", err)
+	}
+
+	var existing *models.Delivery
+	var current models.Delivery
+	if err := tx.Where("order_id = ?", order.ID).First(&current).Error; err == nil {
+		existing = &current
+	}
+
+	exclude := make(map[uuid.UUID]bool)
+	var offered []uuid.UUID
+	if err := tx.Model(&models.DeliveryOffer{}).Where("order_id = ?", order.ID).Pluck("partner_id", &offered).Error; err != nil {
+		return nil, fmt.Errorf("failed to load previous offers: %w", err)
+	}
+	for _, id := range offered {
+		exclude[id] = true
+	}
+	// A driver who handed the order back is not asked again
+	if existing != nil && existing.Status == models.DeliveryCancelled {
+		exclude[existing.DeliveryPartnerID] = true
+	}
+
+	candidates, err := RankDispatchCandidates(tx, order, exclude)
+	if err != nil {
+		return nil, err
+	}
+	if len(candidates) == 0 {
+		log.Printf("Dispatch: no driver available for order %s", order.OrderNumber)
+		if existing != nil && existing.Status == models.DeliveryPending {
+			return nil, releaseOffer(tx, order, existing, "No driver accepted the offer", now)
+		}
+		return nil, nil
+	}
+	best := candidates[0]
+
+	expiresAt := now.Add(DeliveryOfferTTL)
+	delivery := NewOrderDelivery(order, best.Partner.ID)
+	delivery.Status = models.DeliveryPending
+	delivery.AssignmentType = models.AssignmentAuto
+	delivery.OfferExpiresAt = &expiresAt
+	if err := SaveOrderDelivery(tx, &delivery); err != nil {
+		return nil, fmt.Errorf("failed to save delivery offer: %w", err)
+	}
+
+	// Holding the delivery on the order keeps it off the open list while offered
+	if err := tx.Model(&models.Order{}).Where("id = ?", order.ID).Update("delivery_id", delivery.ID).Error; err != nil {
+		return nil, fmt.Errorf("failed to hold order for offer: %w", err)
+	}
+	order.DeliveryID = &delivery.ID
+
+	offer := models.DeliveryOffer{
+		DeliveryID: delivery.ID,
+		OrderID:    order.ID,
+		PartnerID:  best.Partner.ID,
+		Status:     models.OfferPending,
+		Distance:   best.Distance,
+		Score:      best.Score,
+		ExpiresAt:  expiresAt,
+	}
+	if err := tx.Create(&offer).Error; err != nil {
+		return nil, fmt.Errorf("failed to record delivery offer: %w", err)
+	}
+
+	if err := tx.Model(&models.DeliveryPartner{}).Where("id = ?", best.Partner.ID).
+		UpdateColumn("offered_count", gorm.Expr("offered_count + 1")).Error; err != nil {
+		return nil, fmt.Errorf("failed to update driver offer count: %w", err)
+	}
+	if err := RecordDriverMetric(tx, models.DriverMetricEvent{
+		PartnerID:  best.Partner.ID,
+		DeliveryID: &delivery.ID,
+		Kind:       models.MetricOffered,
+		OccurredAt: now,
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := RecordOrderEvent(tx, order.ID, models.OrderEventDelivery, "", string(delivery.Status),
+		BySystem(models.OrderEventSourceJob).WithNotes(fmt.Sprintf("Offered to partner %s (%.1f km away)", best.Partner.ID, best.Distance))); err != nil {
+		return nil, err
+	}
+
+	return &offer, nil
+}
+
+// releaseOffer cancels a pending offer and frees the order for the open list
+func releaseOffer(tx *gorm.DB, order *models.Order, delivery *models.Delivery, reason string, now time.Time) error {
+	if err := tx.Model(delivery).Updates(map[string]interface{}{
+		"status":           models.DeliveryCancelled,
+		"cancelled_at":     now,
+		"cancel_reason":    reason,
+		"offer_expires_at": nil,
+	}).Error; err != nil {
+		return fmt.Errorf("failed to release delivery offer: %w", err)
+	}
+	if err := tx.Model(&models.Order{}).Where("id = ? AND delivery_id = ?", order.ID, delivery.ID).
+		Update("delivery_id", nil).Error; err != nil {
+		return fmt.Errorf("failed to release order: %w", err)
+	}
+	order.DeliveryID = nil
+	return RecordOrderEvent(tx, order.ID, models.OrderEventDelivery, string(models.DeliveryPending), string(models.DeliveryCancelled),
+		BySystem(models.OrderEventSourceJob).WithNotes(reason))
+}
+
+// CancelActiveDelivery closes the delivery a driver is working on for an order
+// that has been taken off them, so it no longer counts towards their load or
+// stays on their run. Pending offers are left to the offer sweep. Call it
+// with the order row locked.
+func CancelActiveDelivery(tx *gorm.DB, deliveryID uuid.UUID, reason string, by OrderChangeBy) error {
+	var delivery models.Delivery
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&delivery, "id = ?", deliveryID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	switch delivery.Status {
+	case models.DeliveryAssigned, models.DeliveryAtPickup, models.DeliveryPickedUp,
+		models.DeliveryInTransit, models.DeliveryAtDropoff:
+	default:
+		return nil
+	}
+
+	updates := map[string]interface{}{
+		"status":              models.DeliveryCancelled,
+		"cancelled_at":        time.Now(),
+		"cancel_reason":       reason,
+		"dropoff_stop_status": models.StopSkipped,
+	}
+	if !delivery.PickupStopStatus.Done() {
+		updates["pickup_stop_status"] = models.StopSkipped
+	}
+	if err := tx.Model(&delivery).Updates(updates).Error; err != nil {
+		return fmt.Errorf("failed to cancel delivery: %w", err)
+	}
+	return RecordOrderEvent(tx, delivery.OrderID, models.OrderEventDelivery, string(delivery.Status), string(models.DeliveryCancelled),
+		by.WithNotes(reason))
+}
+
+// closeOffer records a driver's answer to an offer, or its lapse, and counts
+// it towards their acceptance rate. Withdrawn offers are not held against them.
+func closeOffer(tx *gorm.DB, offer *models.DeliveryOffer, status models.DeliveryOfferStatus, now time.Time) error {
+	offer.Status = status
+	offer.RespondedAt = &now
+	if err := tx.Model(offer).Updates(map[string]interface{}{
+		"status":       status,
+		"responded_at": now,
+	}).Error; err != nil {
+		return fmt.Errorf("failed to close delivery offer: %w", err)
+	}
+
+	var kind models.DriverMetricKind
+	switch status {
+	case models.OfferAccepted:
+		if err := tx.Model(&models.DeliveryPartner{}).Where("id = ?", offer.PartnerID).
+			UpdateColumn("accepted_count", gorm.Expr("accepted_count + 1")).Error; err != nil {
+			return fmt.Errorf("failed to update driver acceptance: %w", err)
+		}
+		kind = models.MetricAccepted
+	case models.OfferDeclined:
+		kind = models.MetricDeclined
+	case models.OfferExpired:
+		kind = models.MetricExpired
+	default:
+		return nil
+	}
+	return RecordDriverMetric(tx, models.DriverMetricEvent{
+		PartnerID:  offer.PartnerID,
+		DeliveryID: &offer.DeliveryID,
+		Kind:       kind,
+		OccurredAt: now,
+	})
+}
+
+// publishDeliveryOffer tells a driver about a new offer
+func publishDeliveryOffer(offer *models.DeliveryOffer) {
+	if offer == nil {
+		return
+	}
+	go func() {
+		var partner models.DeliveryPartner
+		if err := database.DB.Select("id", "user_id").First(&partner, "id = ?", offer.PartnerID).Error; err != nil {
+			log.Printf("Failed to load partner for delivery offer: %v", err)
+			return
+		}
+		if err := PublishEvent(SubjectDeliveryOffered, "delivery.offered", partner.UserID, map[string]interface{}{
+			"delivery_id": offer.DeliveryID.String(),
+			"order_id":    offer.OrderID.String(),
+			"partner_id":  offer.PartnerID.String(),
+			"distance":    offer.Distance,
+			"expires_at":  offer.ExpiresAt,
+		}); err != nil {
+			log.Printf("Failed to publish delivery offered event: %v", err)
+		}
+	}()
+}
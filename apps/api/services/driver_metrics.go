@@ -0,0 +1,293 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/homechef/api/database"
+	"github.com/homechef/api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// DriverMetricsInterval is how often partner metrics are recomputed
+	DriverMetricsInterval = 15 * time.Minute
+
+	// OnTimeGrace is how far past its promised time a delivery may arrive and
+	// still count as on time
+	OnTimeGrace = 5 * time.Minute
+
+	// SatisfiedRating is the lowest delivery rating counted as satisfied in
+	// a partner's CSAT score
+	SatisfiedRating = 4
+)
+
+// Rolling windows partner metrics are kept over, in days. The headline rates
+// on DeliveryPartner use the longer one.
+const (
+	WeeklyMetricsWindow  = 7
+	MonthlyMetricsWindow = 30
+)
+
+// RecordDriverMetric adds a delivery event to a partner's metrics. Use it
+// inside the transaction making the change so both commit together.
+func RecordDriverMetric(tx *gorm.DB, event models.DriverMetricEvent) error {
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+	if err := tx.Create(&event).Error; err != nil {
+		return fmt.Errorf("failed to record driver metric: %w", err)
+	}
+	return nil
+}
+
+// LogDriverMetric records a delivery event outside a transaction; failures
+// are logged, not returned
+func LogDriverMetric(db *gorm.DB, event models.DriverMetricEvent) {
+	if err := RecordDriverMetric(db, event); err != nil {
+		log.Printf("Partner %s: %v", event.PartnerID, err)
+	}
+}
+
+// DeliveryPromisedAt is when a delivery should reach the customer: the
+// estimated trip time from pickup, or else the end of the order's scheduled
+// slot. Returns false when no promise was made.
+func DeliveryPromisedAt(delivery *models.Delivery) (time.Time, bool) {
+	if delivery.PickedUpAt != nil && delivery.EstimatedDuration > 0 {
+		return delivery.PickedUpAt.Add(time.Duration(delivery.EstimatedDuration) * time.Minute), true
+	}
+	if delivery.Order.ScheduledFor != nil {
+		return delivery.Order.ScheduledFor.Add(DefaultSlotMinutes * time.Minute), true
+	}
+	return time.Time{}, false
+}
+
+// RecordDeliveredMetric counts a completed delivery and how late it was
+// against its promise. The event and the on-time counter are written together;
+// errors are logged, not returned.
+func RecordDeliveredMetric(db *gorm.DB, delivery *models.Delivery) {
+	deliveredAt := time.Now()
+	if delivery.DeliveredAt != nil {
+		deliveredAt = *delivery.DeliveredAt
+	}
+	event := models.DriverMetricEvent{
+		PartnerID:  delivery.DeliveryPartnerID,
+		DeliveryID: &delivery.ID,
+		Kind:       models.MetricDelivered,
+		OccurredAt: deliveredAt,
+	}
+	if promised, ok := DeliveryPromisedAt(delivery); ok {
+		onTime := !deliveredAt.After(promised.Add(OnTimeGrace))
+		event.OnTime = &onTime
+		if late := deliveredAt.Sub(promised); late > 0 {
+			event.LateMinutes = int(late.Minutes())
+		}
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if event.OnTime != nil && *event.OnTime {
+			if err := tx.Model(&models.DeliveryPartner{}).Where("id = ?", delivery.DeliveryPartnerID).
+				UpdateColumn("completed_on_time", gorm.Expr("completed_on_time + 1")).Error; err != nil {
+				return fmt.Errorf("failed to count on-time delivery: %w", err)
+			}
+		}
+		return RecordDriverMetric(tx, event)
+	})
+	if err != nil {
+		log.Printf("Partner %s: %v", delivery.DeliveryPartnerID, err)
+	}
+}
+
+// RecordFailedMetric counts a failed attempt. Only failures the driver caused
+// count against their completion rate.
+func RecordFailedMetric(db *gorm.DB, delivery *models.Delivery) {
+	LogDriverMetric(db, models.DriverMetricEvent{
+		PartnerID:  delivery.DeliveryPartnerID,
+		DeliveryID: &delivery.ID,
+		Kind:       models.MetricFailed,
+		AtFault:    delivery.FailureReason == models.FailureOrderDamaged,
+	})
+}
+
+// metricWindow is a partner's event counts over one rolling window
+type metricWindow struct {
+	PartnerID     uuid.UUID
+	Offers        int
+	Accepted      int
+	Declined      int
+	Expired       int
+	Delivered     int
+	OnTime        int
+	Late          int
+	LateMinutes   int
+	Failed        int
+	FailedAtFault int
+	Dropped       int
+	Ratings       int
+	Satisfied     int
+	RatingSum     int
+}
+
+func percent(n, d int) float64 {
+	if d == 0 {
+		return 0
+	}
+	return math.Round(float64(n)*1000/float64(d)) / 10
+}
+
+func (w *metricWindow) acceptanceRate() float64 {
+	return percent(w.Accepted, w.Accepted+w.Declined+w.Expired)
+}
+
+func (w *metricWindow) onTimeRate() float64 {
+	return percent(w.OnTime, w.OnTime+w.Late)
+}
+
+func (w *metricWindow) completionRate() float64 {
+	return percent(w.Delivered, w.Delivered+w.FailedAtFault+w.Dropped)
+}
+
+func (w *metricWindow) csatScore() float64 {
+	return percent(w.Satisfied, w.Ratings)
+}
+
+// loadMetricWindows counts each partner's events since a time
+func loadMetricWindows(db *gorm.DB, partnerIDs []uuid.UUID, since time.Time) (map[uuid.UUID]*metricWindow, error) {
+	var rows []metricWindow
+	err := db.Model(&models.DriverMetricEvent{}).
+		Select(`partner_id,
+			COUNT(*) FILTER (WHERE kind = ?) AS offers,
+			COUNT(*) FILTER (WHERE kind = ?) AS accepted,
+			COUNT(*) FILTER (WHERE kind = ?) AS declined,
+			COUNT(*) FILTER (WHERE kind = ?) AS expired,
+			COUNT(*) FILTER (WHERE kind = ?) AS delivered,
+			COUNT(*) FILTER (WHERE kind = ? AND on_time) AS on_time,
+			COUNT(*) FILTER (WHERE kind = ? AND NOT on_time) AS late,
+			COALESCE(SUM(late_minutes) FILTER (WHERE kind = ? AND NOT on_time), 0) AS late_minutes,
+			COUNT(*) FILTER (WHERE kind = ?) AS failed,
+			COUNT(*) FILTER (WHERE kind = ? AND at_fault) AS failed_at_fault,
+			COUNT(*) FILTER (WHERE kind = ?) AS dropped,
+			COUNT(*) FILTER (WHERE kind = ?) AS ratings,
+			COUNT(*) FILTER (WHERE kind = ? AND rating >= ?) AS satisfied,
+			COALESCE(SUM(rating) FILTER (WHERE kind = ?), 0) AS rating_sum`,
+			models.MetricOffered, models.MetricAccepted, models.MetricDeclined, models.MetricExpired,
+			models.MetricDelivered, models.MetricDelivered, models.MetricDelivered, models.MetricDelivered,
+			models.MetricFailed, models.MetricFailed, models.MetricDropped,
+			models.MetricRated, models.MetricRated, SatisfiedRating, models.MetricRated).
+		Where("partner_id IN ? AND occurred_at >= ?", partnerIDs, since).
+		Group("partner_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	windows := make(map[uuid.UUID]*metricWindow, len(rows))
+	for i := range rows {
+		windows[rows[i].PartnerID] = &rows[i]
+	}
+	return windows, nil
+}
+
+// UpdateDriverMetrics recomputes the rolling metrics of every partner with
+// events in the last 30 days, writes them back to the partner and keeps
+// today's figures as a snapshot for trends. Partners whose last events have
+// just aged out are included once more so their rates fall back to zero.
+func UpdateDriverMetrics(ctx context.Context) error {
+	db := database.DB.WithContext(ctx)
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	monthSince := now.AddDate(0, 0, -MonthlyMetricsWindow)
+	weekSince := now.AddDate(0, 0, -WeeklyMetricsWindow)
+
+	var partnerIDs []uuid.UUID
+	if err := db.Model(&models.DriverMetricEvent{}).
+		Where("occurred_at >= ?", monthSince.AddDate(0, 0, -1)).
+		Distinct("partner_id").Pluck("partner_id", &partnerIDs).Error; err != nil {
+		return err
+	}
+	if len(partnerIDs) == 0 {
+		return nil
+	}
+
+	monthly, err := loadMetricWindows(db, partnerIDs, monthSince)
+	if err != nil {
+		return err
+	}
+	weekly, err := loadMetricWindows(db, partnerIDs, weekSince)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range partnerIDs {
+		month, week := monthly[id], weekly[id]
+		if month == nil {
+			month = &metricWindow{PartnerID: id}
+		}
+		if week == nil {
+			week = &metricWindow{PartnerID: id}
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&models.DeliveryPartner{}).Where("id = ?", id).Updates(map[string]interface{}{
+				"acceptance_rate":        month.acceptanceRate(),
+				"on_time_rate":           month.onTimeRate(),
+				"completion_rate":        month.completionRate(),
+				"csat_score":             month.csatScore(),
+				"weekly_acceptance_rate": week.acceptanceRate(),
+				"weekly_on_time_rate":    week.onTimeRate(),
+				"weekly_completion_rate": week.completionRate(),
+				"weekly_csat_score":      week.csatScore(),
+				"metrics_updated_at":     now,
+			}).Error; err != nil {
+				return err
+			}
+
+			snapshots := []models.DriverMetricSnapshot{
+				metricSnapshot(id, today, WeeklyMetricsWindow, week),
+				metricSnapshot(id, today, MonthlyMetricsWindow, month),
+			}
+			return tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "partner_id"}, {Name: "date"}, {Name: "window_days"}},
+				UpdateAll: true,
+			}).Create(&snapshots).Error
+		})
+		if err != nil {
+			log.Printf("Driver metrics: failed to update partner %s: %v", id, err)
+		}
+	}
+	return nil
+}
+
+func metricSnapshot(partnerID uuid.UUID, date time.Time, days int, w *metricWindow) models.DriverMetricSnapshot {
+	s := models.DriverMetricSnapshot{
+		PartnerID:      partnerID,
+		Date:           date,
+		WindowDays:     days,
+		Offers:         w.Offers,
+		Accepted:       w.Accepted,
+		Declined:       w.Declined,
+		Expired:        w.Expired,
+		Delivered:      w.Delivered,
+		OnTime:         w.OnTime,
+		Late:           w.Late,
+		Failed:         w.Failed,
+		FailedAtFault:  w.FailedAtFault,
+		Dropped:        w.Dropped,
+		Ratings:        w.Ratings,
+		AcceptanceRate: w.acceptanceRate(),
+		OnTimeRate:     w.onTimeRate(),
+		CompletionRate: w.completionRate(),
+		CSATScore:      w.csatScore(),
+	}
+	if w.Late > 0 {
+		s.AvgLateMinutes = math.Round(float64(w.LateMinutes)*10/float64(w.Late)) / 10
+	}
+	if w.Ratings > 0 {
+		s.AvgRating = math.Round(float64(w.RatingSum)*100/float64(w.Ratings)) / 100
+	}
+	return s
+}
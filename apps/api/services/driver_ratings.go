@@ -0,0 +1,202 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/homechef/api/models"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// LowDriverRating is the highest delivery rating that alerts fleet managers
+	LowDriverRating = 2
+
+	// DriverRatingHalfLife is how long it takes a rating to count half as much
+	// towards a partner's average
+	DriverRatingHalfLife = 30 * 24 * time.Hour
+
+	// DriverRatingSample is how many of a partner's latest ratings make up
+	// their average
+	DriverRatingSample = 100
+
+	// MaxDriverRatingTags caps the reason tags on one rating
+	MaxDriverRatingTags = 5
+
+	// MaxDriverFeedbackLength caps the comment left for a driver
+	MaxDriverFeedbackLength = 500
+)
+
+var driverRatingTags = map[string]bool{
+	models.DriverTagLate:                true,
+	models.DriverTagRude:                true,
+	models.DriverTagFoodSpilled:         true,
+	models.DriverTagIgnoredInstructions: true,
+	models.DriverTagFriendly:            true,
+	models.DriverTagFast:                true,
+}
+
+// DriverRatingError is a delivery rating the customer needs to correct
+type DriverRatingError struct {
+	Message string
+}
+
+func (e *DriverRatingError) Error() string {
+	return e.Message
+}
+
+// NormalizeDriverRatingTags lower-cases and de-duplicates reason tags,
+// rejecting any that are not offered. "food spilled" is accepted for
+// food_spilled.
+func NormalizeDriverRatingTags(tags []string) ([]string, error) {
+	seen := make(map[string]bool, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(tag)), " ", "_")
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if !driverRatingTags[tag] {
+			return nil, &DriverRatingError{Message: fmt.Sprintf("Unknown delivery tag %q", tag)}
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	if len(normalized) > MaxDriverRatingTags {
+		return nil, &DriverRatingError{Message: fmt.Sprintf("At most %d delivery tags are allowed", MaxDriverRatingTags)}
+	}
+	return normalized, nil
+}
+
+// RateDeliveryPartner routes the delivery rating on a review to the partner
+// who delivered the order, counts it towards their CSAT score and refreshes
+// their average. Ratings of LowDriverRating or less alert fleet managers.
+// Orders without a delivery partner are ignored.
+func RateDeliveryPartner(db *gorm.DB, review *models.Review, order *models.Order, tags []string, comment string) error {
+	if order.DeliveryID == nil || review.DeliveryRating < 1 || review.DeliveryRating > 5 {
+		return nil
+	}
+
+	var delivery models.Delivery
+	if err := db.Select("id", "delivery_partner_id").Where("id = ?", *order.DeliveryID).First(&delivery).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to load delivery: %w", err)
+	}
+
+	rating := models.DriverRating{
+		ReviewID:   review.ID,
+		OrderID:    order.ID,
+		DeliveryID: delivery.ID,
+		PartnerID:  delivery.DeliveryPartnerID,
+		CustomerID: review.CustomerID,
+		Rating:     review.DeliveryRating,
+		Tags:       pq.StringArray(tags),
+		Comment:    strings.TrimSpace(comment),
+	}
+
+	var partner models.DeliveryPartner
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("User").
+			Where("id = ?", delivery.DeliveryPartnerID).First(&partner).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&rating).Error; err != nil {
+			return err
+		}
+		if err := RecordDriverMetric(tx, models.DriverMetricEvent{
+			PartnerID:  partner.ID,
+			DeliveryID: &delivery.ID,
+			Kind:       models.MetricRated,
+			Rating:     rating.Rating,
+			OccurredAt: rating.CreatedAt,
+		}); err != nil {
+			return err
+		}
+		return refreshPartnerRating(tx, &partner)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to rate delivery partner: %w", err)
+	}
+
+	if rating.Rating <= LowDriverRating {
+		data := map[string]interface{}{
+			"partner_id":     partner.ID.String(),
+			"partner_name":   partner.User.FirstName + " " + partner.User.LastName,
+			"order_id":       order.ID.String(),
+			"delivery_id":    delivery.ID.String(),
+			"rating":         rating.Rating,
+			"tags":           tags,
+			"comment":        rating.Comment,
+			"partner_rating": partner.Rating,
+		}
+		go func() {
+			if err := PublishEvent(SubjectDriverLowRating, "delivery.low_rating", partner.UserID, data); err != nil {
+				log.Printf("Failed to publish driver low rating event: %v", err)
+			}
+		}()
+	}
+	return nil
+}
+
+// refreshPartnerRating recomputes a partner's average over their latest
+// ratings, each weighted down by half for every DriverRatingHalfLife of age
+// so recent deliveries count most, and sets their rating count
+func refreshPartnerRating(tx *gorm.DB, partner *models.DeliveryPartner) error {
+	var recent []models.DriverRating
+	if err := tx.Select("rating", "created_at").Where("partner_id = ?", partner.ID).
+		Order("created_at DESC").Limit(DriverRatingSample).Find(&recent).Error; err != nil {
+		return err
+	}
+	var total int64
+	if err := tx.Model(&models.DriverRating{}).Where("partner_id = ?", partner.ID).Count(&total).Error; err != nil {
+		return err
+	}
+
+	partner.Rating = weightedDriverRating(recent, time.Now())
+	partner.TotalReviews = int(total)
+	return tx.Model(partner).Updates(map[string]interface{}{
+		"rating":        partner.Rating,
+		"total_reviews": partner.TotalReviews,
+	}).Error
+}
+
+func weightedDriverRating(ratings []models.DriverRating, now time.Time) float64 {
+	var sum, weights float64
+	for _, r := range ratings {
+		age := now.Sub(r.CreatedAt)
+		if age < 0 {
+			age = 0
+		}
+		w := math.Pow(0.5, float64(age)/float64(DriverRatingHalfLife))
+		sum += w * float64(r.Rating)
+		weights += w
+	}
+	if weights == 0 {
+		return 0
+	}
+	return math.Round(sum*100/weights) / 100
+}
+
+// PartnerFeedback pages through the ratings left for a partner, newest first
+func PartnerFeedback(db *gorm.DB, partnerID uuid.UUID, page, limit int) ([]models.DriverRating, int64, error) {
+	query := db.Model(&models.DriverRating{}).Where("partner_id = ?", partnerID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var ratings []models.DriverRating
+	if err := query.Order("created_at DESC").Offset((page - 1) * limit).Limit(limit).Find(&ratings).Error; err != nil {
+		return nil, 0, err
+	}
+	return ratings, total, nil
+}
@@ -0,0 +1,512 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/homechef/api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// ShiftEarlyLogin is how long before a shift starts an agent may go online
+	// for it
+	ShiftEarlyLogin = 15 * time.Minute
+
+	// ShiftGracePeriod is how late an agent may log in, or how early they may
+	// log out, before it counts against their attendance
+	ShiftGracePeriod = 5 * time.Minute
+
+	// MinShiftLength and MaxShiftLength bound a published shift
+	MinShiftLength = time.Hour
+	MaxShiftLength = 12 * time.Hour
+)
+
+// ShiftError is a reason a shift could not be published, claimed or assigned
+type ShiftError struct {
+	Message string
+}
+
+func (e *ShiftError) Error() string {
+	return e.Message
+}
+
+// ShiftSpec describes shifts to publish. Slots publishes that many identical
+// open shifts; a shift given a partner is assigned to them straight away.
+type ShiftSpec struct {
+	StartsAt  time.Time  `json:"startsAt" binding:"required"`
+	EndsAt    time.Time  `json:"endsAt" binding:"required"`
+	Slots     int        `json:"slots"`
+	PartnerID *uuid.UUID `json:"partnerId"`
+	Notes     string     `json:"notes"`
+}
+
+// WeekStartOf returns midnight on the Monday of t's week, in t's location
+func WeekStartOf(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, t.Location())
+}
+
+// PublishShifts adds shifts to a zone's roster for the week starting on
+// weekStart, which must be a Monday. Every shift must start within that week
+// and in the future.
+func PublishShifts(db *gorm.DB, zoneID uuid.UUID, weekStart time.Time, specs []ShiftSpec, publishedBy uuid.UUID) ([]models.DriverShift, error) {
+	if weekStart.Weekday() != time.Monday {
+		return nil, &ShiftError{Message: "Roster weeks start on a Monday"}
+	}
+	weekEnd := weekStart.AddDate(0, 0, 7)
+	now := time.Now()
+
+	var zone models.DeliveryZone
+	if err := db.Where("id = ? AND is_active = ?", zoneID, true).First(&zone).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &ShiftError{Message: "Zone not found"}
+		}
+		return nil, err
+	}
+
+	for i, spec := range specs {
+		length := spec.EndsAt.Sub(spec.StartsAt)
+		switch {
+		case length < MinShiftLength || length > MaxShiftLength:
+			return nil, &ShiftError{Message: fmt.Sprintf("Shift %d must last between %s and %s", i+1, MinShiftLength, MaxShiftLength)}
+		case spec.StartsAt.Before(weekStart) || !spec.StartsAt.Before(weekEnd):
+			return nil, &ShiftError{Message: fmt.Sprintf("Shift %d does not start in the week of %s", i+1, weekStart.Format("2006-01-02"))}
+		case !spec.StartsAt.After(now):
+			return nil, &ShiftError{Message: fmt.Sprintf("Shift %d starts in the past", i+1)}
+		case spec.Slots < 0 || spec.Slots > 50:
+			return nil, &ShiftError{Message: fmt.Sprintf("Shift %d must have between 1 and 50 slots", i+1)}
+		case spec.PartnerID != nil && spec.Slots > 1:
+			return nil, &ShiftError{Message: fmt.Sprintf("Shift %d is assigned to a partner, so it can only have one slot", i+1)}
+		}
+	}
+
+	var shifts []models.DriverShift
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, spec := range specs {
+			slots := spec.Slots
+			if slots == 0 {
+				slots = 1
+			}
+			for n := 0; n < slots; n++ {
+				shift := models.DriverShift{
+					ZoneID:        zone.ID,
+					WeekStart:     weekStart,
+					StartsAt:      spec.StartsAt,
+					EndsAt:        spec.EndsAt,
+					Status:        models.ShiftOpen,
+					PublishedByID: publishedBy,
+					Notes:         spec.Notes,
+				}
+				if err := tx.Create(&shift).Error; err != nil {
+					return fmt.Errorf("failed to create shift: %w", err)
+				}
+				if spec.PartnerID != nil {
+					if err := assignShift(tx, &shift, *spec.PartnerID, &publishedBy, now); err != nil {
+						return err
+					}
+				}
+				shift.Zone = zone
+				shifts = append(shifts, shift)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return shifts, nil
+}
+
+// ClaimShift puts an internal agent on an open shift that has not started
+func ClaimShift(db *gorm.DB, shiftID uuid.UUID, partner *models.DeliveryPartner) (*models.DriverShift, error) {
+	var shift models.DriverShift
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", shiftID).First(&shift).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return &ShiftError{Message: "Shift not found"}
+			}
+			return err
+		}
+		now := time.Now()
+		if shift.Status != models.ShiftOpen {
+			return &ShiftError{Message: "This shift has already been taken"}
+		}
+		if !shift.StartsAt.After(now) {
+			return &ShiftError{Message: "This shift has already started"}
+		}
+		return assignShift(tx, &shift, partner.ID, nil, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &shift, nil
+}
+
+// AssignShift puts an internal agent on a shift, replacing whoever held it. A
+// shift can be assigned until it ends.
+func AssignShift(db *gorm.DB, shiftID, partnerID, assignedBy uuid.UUID) (*models.DriverShift, error) {
+	var shift models.DriverShift
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", shiftID).First(&shift).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return &ShiftError{Message: "Shift not found"}
+			}
+			return err
+		}
+		now := time.Now()
+		if shift.Status == models.ShiftCancelled {
+			return &ShiftError{Message: "This shift has been cancelled"}
+		}
+		if !shift.EndsAt.After(now) {
+			return &ShiftError{Message: "This shift has already ended"}
+		}
+		return assignShift(tx, &shift, partnerID, &assignedBy, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &shift, nil
+}
+
+// assignShift checks the partner may work the shift and puts them on it. The
+// partner row is locked so two claims cannot double-book the same agent.
+func assignShift(tx *gorm.DB, shift *models.DriverShift, partnerID uuid.UUID, assignedBy *uuid.UUID, now time.Time) error {
+	var partner models.DeliveryPartner
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", partnerID).First(&partner).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &ShiftError{Message: "Delivery partner not found"}
+		}
+		return err
+	}
+	if partner.AgentType != models.AgentInternal {
+		return &ShiftError{Message: "Only internal agents work rostered shifts"}
+	}
+	if !partner.IsActive || !partner.IsVerified {
+		return &ShiftError{Message: "Delivery partner is not active and verified"}
+	}
+
+	var clashes int64
+	if err := tx.Model(&models.DriverShift{}).
+		Where("partner_id = ? AND status = ? AND id <> ? AND starts_at < ? AND ends_at > ?",
+			partner.ID, models.ShiftAssigned, shift.ID, shift.EndsAt, shift.StartsAt).
+		Count(&clashes).Error; err != nil {
+		return err
+	}
+	if clashes > 0 {
+		return &ShiftError{Message: "Delivery partner already has a shift at this time"}
+	}
+
+	shift.Status = models.ShiftAssigned
+	shift.PartnerID = &partner.ID
+	shift.AssignedAt = &now
+	shift.AssignedByID = assignedBy
+	if err := tx.Model(shift).Updates(map[string]interface{}{
+		"status":         shift.Status,
+		"partner_id":     shift.PartnerID,
+		"assigned_at":    shift.AssignedAt,
+		"assigned_by_id": shift.AssignedByID,
+	}).Error; err != nil {
+		return fmt.Errorf("failed to assign shift: %w", err)
+	}
+	return nil
+}
+
+// CancelShift takes a shift off the roster. Shifts that have ended are kept
+// for attendance.
+func CancelShift(db *gorm.DB, shiftID uuid.UUID) (*models.DriverShift, error) {
+	var shift models.DriverShift
+	if err := db.Where("id = ?", shiftID).First(&shift).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &ShiftError{Message: "Shift not found"}
+		}
+		return nil, err
+	}
+	if shift.Status == models.ShiftCancelled {
+		return &shift, nil
+	}
+	if !shift.EndsAt.After(time.Now()) {
+		return nil, &ShiftError{Message: "This shift has already ended"}
+	}
+	shift.Status = models.ShiftCancelled
+	if err := db.Model(&shift).Update("status", shift.Status).Error; err != nil {
+		return nil, fmt.Errorf("failed to cancel shift: %w", err)
+	}
+	return &shift, nil
+}
+
+// CoveringShift returns the partner's assigned shift running at the given
+// time, counting ShiftEarlyLogin before it starts. Returns nil when none does.
+func CoveringShift(db *gorm.DB, partnerID uuid.UUID, at time.Time) (*models.DriverShift, error) {
+	var shift models.DriverShift
+	err := db.Where("partner_id = ? AND status = ? AND starts_at <= ? AND ends_at > ?",
+		partnerID, models.ShiftAssigned, at.Add(ShiftEarlyLogin), at).
+		Order("starts_at").First(&shift).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &shift, nil
+}
+
+// StartOnlineSession opens an online session for a partner going online. An
+// internal agent with no shift covering the time is flagged. A partner who is
+// already online keeps their open session.
+func StartOnlineSession(db *gorm.DB, partner *models.DeliveryPartner, now time.Time) (*models.PartnerOnlineSession, *models.DriverShift, error) {
+	shift, err := CoveringShift(db, partner.ID, now)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var session models.PartnerOnlineSession
+	err = db.Where("partner_id = ? AND ended_at IS NULL", partner.ID).Order("started_at DESC").First(&session).Error
+	if err == nil {
+		return &session, shift, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, err
+	}
+
+	session = models.PartnerOnlineSession{
+		PartnerID:    partner.ID,
+		StartedAt:    now,
+		OutsideShift: partner.AgentType == models.AgentInternal && shift == nil,
+	}
+	if shift != nil {
+		session.ShiftID = &shift.ID
+		partner.ShiftStart = &shift.StartsAt
+		partner.ShiftEnd = &shift.EndsAt
+	}
+	if err := db.Create(&session).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to open online session: %w", err)
+	}
+	if session.OutsideShift {
+		log.Printf("Internal agent %s went online outside a rostered shift", partner.ID)
+	}
+	return &session, shift, nil
+}
+
+// EndOnlineSession closes a partner's open online session
+func EndOnlineSession(db *gorm.DB, partnerID uuid.UUID, now time.Time) error {
+	return db.Model(&models.PartnerOnlineSession{}).
+		Where("partner_id = ? AND ended_at IS NULL", partnerID).
+		Update("ended_at", now).Error
+}
+
+// ShiftAttendance is how an agent worked one shift, built from their online
+// sessions. Minutes are whole minutes within the shift.
+type ShiftAttendance struct {
+	Shift              models.DriverShiftResponse `json:"shift"`
+	FirstLoginAt       *time.Time                 `json:"firstLoginAt,omitempty"`
+	LastLogoutAt       *time.Time                 `json:"lastLogoutAt,omitempty"`
+	ScheduledMinutes   int                        `json:"scheduledMinutes"`
+	OnlineMinutes      int                        `json:"onlineMinutes"`
+	LateMinutes        int                        `json:"lateMinutes"`
+	EarlyLogoutMinutes int                        `json:"earlyLogoutMinutes"`
+	BreakMinutes       int                        `json:"breakMinutes"`
+	Breaks             int                        `json:"breaks"`
+	NoShow             bool                       `json:"noShow"`
+	InProgress         bool                       `json:"inProgress"`
+}
+
+// ComputeShiftAttendance measures one shift against the agent's online
+// sessions, sorted by start. A login more than ShiftGracePeriod after the
+// start is late, a final logout more than ShiftGracePeriod before the end is
+// early, and gaps between sessions inside the shift are breaks.
+func ComputeShiftAttendance(shift *models.DriverShift, sessions []models.PartnerOnlineSession, now time.Time) ShiftAttendance {
+	a := ShiftAttendance{
+		Shift:            shift.ToResponse(),
+		ScheduledMinutes: int(shift.EndsAt.Sub(shift.StartsAt).Minutes()),
+		InProgress:       now.Before(shift.EndsAt),
+	}
+	windowEnd := shift.EndsAt
+	if now.Before(windowEnd) {
+		windowEnd = now
+	}
+
+	var online, breaks time.Duration
+	var first, last time.Time
+	for i := range sessions {
+		s := &sessions[i]
+		ended := now
+		if s.EndedAt != nil {
+			ended = *s.EndedAt
+		}
+		from, to := s.StartedAt, ended
+		if from.Before(shift.StartsAt) {
+			from = shift.StartsAt
+		}
+		if to.After(windowEnd) {
+			to = windowEnd
+		}
+		if !to.After(from) {
+			continue
+		}
+
+		if a.FirstLoginAt == nil {
+			a.FirstLoginAt = &s.StartedAt
+			first = from
+		} else if from.After(last) {
+			breaks += from.Sub(last)
+			a.Breaks++
+		}
+		if to.After(last) {
+			online += to.Sub(maxTime(from, last))
+			last = to
+		}
+		a.LastLogoutAt = s.EndedAt
+	}
+
+	if a.FirstLoginAt == nil {
+		a.NoShow = now.After(shift.StartsAt.Add(ShiftGracePeriod))
+		return a
+	}
+	if late := first.Sub(shift.StartsAt); late > ShiftGracePeriod {
+		a.LateMinutes = int(late.Minutes())
+	}
+	if !a.InProgress {
+		if early := shift.EndsAt.Sub(last); early > ShiftGracePeriod {
+			a.EarlyLogoutMinutes = int(early.Minutes())
+		}
+	}
+	a.OnlineMinutes = int(online.Minutes())
+	a.BreakMinutes = int(breaks.Minutes())
+	return a
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+// AttendanceFilter narrows an attendance report. Shifts starting in
+// [From, To) are included.
+type AttendanceFilter struct {
+	From      time.Time
+	To        time.Time
+	ZoneID    *uuid.UUID
+	PartnerID *uuid.UUID
+}
+
+// PartnerAttendanceSummary totals one agent's attendance over a report
+type PartnerAttendanceSummary struct {
+	PartnerID          uuid.UUID `json:"partnerId"`
+	PartnerName        string    `json:"partnerName"`
+	Shifts             int       `json:"shifts"`
+	NoShows            int       `json:"noShows"`
+	LateShifts         int       `json:"lateShifts"`
+	LateMinutes        int       `json:"lateMinutes"`
+	EarlyLogouts       int       `json:"earlyLogouts"`
+	EarlyLogoutMinutes int       `json:"earlyLogoutMinutes"`
+	BreakMinutes       int       `json:"breakMinutes"`
+	ScheduledMinutes   int       `json:"scheduledMinutes"`
+	OnlineMinutes      int       `json:"onlineMinutes"`
+	OffShiftSessions   int64     `json:"offShiftSessions"`
+}
+
+// AttendanceReport computes attendance for every assigned shift that has
+// started in the filter's range, with a summary per agent
+func AttendanceReport(db *gorm.DB, filter AttendanceFilter) ([]ShiftAttendance, []PartnerAttendanceSummary, error) {
+	now := time.Now()
+	query := db.Preload("Zone").Preload("Partner.User").
+		Where("status = ? AND starts_at >= ? AND starts_at < ? AND starts_at <= ?",
+			models.ShiftAssigned, filter.From, filter.To, now)
+	if filter.ZoneID != nil {
+		query = query.Where("zone_id = ?", *filter.ZoneID)
+	}
+	if filter.PartnerID != nil {
+		query = query.Where("partner_id = ?", *filter.PartnerID)
+	}
+	var shifts []models.DriverShift
+	if err := query.Order("starts_at").Find(&shifts).Error; err != nil {
+		return nil, nil, err
+	}
+	if len(shifts) == 0 {
+		return []ShiftAttendance{}, []PartnerAttendanceSummary{}, nil
+	}
+
+	partnerIDs := []uuid.UUID{}
+	seen := map[uuid.UUID]bool{}
+	latestEnd := shifts[0].EndsAt
+	for _, s := range shifts {
+		if !seen[*s.PartnerID] {
+			seen[*s.PartnerID] = true
+			partnerIDs = append(partnerIDs, *s.PartnerID)
+		}
+		if s.EndsAt.After(latestEnd) {
+			latestEnd = s.EndsAt
+		}
+	}
+
+	var sessions []models.PartnerOnlineSession
+	if err := db.Where("partner_id IN ? AND started_at < ? AND (ended_at IS NULL OR ended_at > ?)",
+		partnerIDs, latestEnd, shifts[0].StartsAt).
+		Order("started_at").Find(&sessions).Error; err != nil {
+		return nil, nil, err
+	}
+	byPartner := map[uuid.UUID][]models.PartnerOnlineSession{}
+	for _, s := range sessions {
+		byPartner[s.PartnerID] = append(byPartner[s.PartnerID], s)
+	}
+
+	attendance := make([]ShiftAttendance, len(shifts))
+	summaries := map[uuid.UUID]*PartnerAttendanceSummary{}
+	for i := range shifts {
+		shift := &shifts[i]
+		a := ComputeShiftAttendance(shift, byPartner[*shift.PartnerID], now)
+		attendance[i] = a
+
+		sum, ok := summaries[*shift.PartnerID]
+		if !ok {
+			sum = &PartnerAttendanceSummary{PartnerID: *shift.PartnerID, PartnerName: a.Shift.PartnerName}
+			summaries[*shift.PartnerID] = sum
+		}
+		sum.Shifts++
+		if a.NoShow {
+			sum.NoShows++
+		}
+		if a.LateMinutes > 0 {
+			sum.LateShifts++
+			sum.LateMinutes += a.LateMinutes
+		}
+		if a.EarlyLogoutMinutes > 0 {
+			sum.EarlyLogouts++
+			sum.EarlyLogoutMinutes += a.EarlyLogoutMinutes
+		}
+		sum.BreakMinutes += a.BreakMinutes
+		sum.ScheduledMinutes += a.ScheduledMinutes
+		sum.OnlineMinutes += a.OnlineMinutes
+	}
+
+	type offShiftCount struct {
+		PartnerID uuid.UUID
+		Count     int64
+	}
+	var offShift []offShiftCount
+	if err := db.Model(&models.PartnerOnlineSession{}).
+		Select("partner_id, COUNT(*) AS count").
+		Where("partner_id IN ? AND outside_shift = ? AND started_at >= ? AND started_at < ?",
+			partnerIDs, true, filter.From, filter.To).
+		Group("partner_id").Scan(&offShift).Error; err != nil {
+		return nil, nil, err
+	}
+	for _, o := range offShift {
+		summaries[o.PartnerID].OffShiftSessions = o.Count
+	}
+
+	result := make([]PartnerAttendanceSummary, 0, len(summaries))
+	for _, id := range partnerIDs {
+		result = append(result, *summaries[id])
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].PartnerName < result[j].PartnerName })
+	return attendance, result, nil
+}
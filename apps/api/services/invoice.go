@@ -24,6 +24,7 @@ type TaxConfig struct {
 	SubscriptionPercent float64
 	RegistrationIDLabel string // GSTIN, ABN, TIN
 	CompanyTaxID        string
+	PricesIncludeTax    bool // Menu prices already include food tax
 }
 
 // InvoiceCompanyInfo holds Fe3dr company details for invoices
@@ -39,7 +40,7 @@ type InvoiceCompanyInfo struct {
 // Default tax configurations per country
 var defaultTaxConfigs = map[string]TaxConfig{
 	"IN": {CountryCode: "IN", TaxName: "GST", FoodPercent: 5, ServicePercent: 18, DeliveryPercent: 18, SubscriptionPercent: 18, RegistrationIDLabel: "GSTIN", CompanyTaxID: ""},
-	"AU": {CountryCode: "AU", TaxName: "GST", FoodPercent: 10, ServicePercent: 10, DeliveryPercent: 10, SubscriptionPercent: 10, RegistrationIDLabel: "ABN", CompanyTaxID: ""},
+	"AU": {CountryCode: "AU", TaxName: "GST", FoodPercent: 10, ServicePercent: 10, DeliveryPercent: 10, SubscriptionPercent: 10, RegistrationIDLabel: "ABN", CompanyTaxID: "", PricesIncludeTax: true},
 	"PK": {CountryCode: "PK", TaxName: "Sales Tax", FoodPercent: 17, ServicePercent: 17, DeliveryPercent: 17, SubscriptionPercent: 17, RegistrationIDLabel: "NTN", CompanyTaxID: ""},
 	"BD": {CountryCode: "BD", TaxName: "VAT", FoodPercent: 15, ServicePercent: 15, DeliveryPercent: 15, SubscriptionPercent: 15, RegistrationIDLabel: "TIN", CompanyTaxID: ""},
 	"LK": {CountryCode: "LK", TaxName: "VAT", FoodPercent: 8, ServicePercent: 8, DeliveryPercent: 8, SubscriptionPercent: 8, RegistrationIDLabel: "TIN", CompanyTaxID: ""},
@@ -98,6 +99,10 @@ func GetTaxConfig(countryCode string) (*TaxConfig, error) {
 			cfg.RegistrationIDLabel = val
 		case "company_tax_id":
 			cfg.CompanyTaxID = val
+		case "prices_include_tax":
+			if v, err := strconv.ParseBool(val); err == nil {
+				cfg.PricesIncludeTax = v
+			}
 		}
 	}
 
@@ -147,16 +152,11 @@ func GenerateOrderInvoice(order *models.Order) (*models.OrderInvoice, error) {
 		return &existing, nil
 	}
 
-	// Determine country code from chef address or default to "IN"
-	countryCode := "IN"
-	if order.Chef.State != "" {
-		// Try to look up country from address - for now use IN as default
-		// In production, you'd resolve this from the chef's registered country
-		var chefCountry models.PlatformSettings
-		if err := database.DB.Where("key = ?", fmt.Sprintf("chef.%s.country_code", order.Chef.ID.String())).
-			First(&chefCountry).Error; err == nil {
-			countryCode = chefCountry.Value
-		}
+	// Orders priced by the tax engine carry their own rate snapshot; older orders
+	// are taxed with the chef's current country config
+	countryCode := order.TaxCountryCode
+	if !order.HasTaxSnapshot() {
+		countryCode = ResolveChefCountry(&order.Chef)
 	}
 
 	// Load tax config
@@ -182,20 +182,37 @@ func GenerateOrderInvoice(order *models.Order) (*models.OrderInvoice, error) {
 			Quantity:  item.Quantity,
 			UnitPrice: item.Price,
 			Total:     itemTotal,
+			Modifiers: item.SelectedModifiers(),
 		}
 	}
 	subtotal = models.RoundAmount(subtotal)
 
 	// Calculate taxes
-	foodTax := models.RoundAmount(subtotal * taxCfg.FoodPercent / 100)
 	deliveryFee := order.DeliveryFee
-	deliveryTax := models.RoundAmount(deliveryFee * taxCfg.DeliveryPercent / 100)
 	serviceFee := order.ServiceFee
-	serviceTax := models.RoundAmount(serviceFee * taxCfg.ServicePercent / 100)
 	tip := order.Tip
 	discount := order.Discount
 
-	totalAmount := models.RoundAmount(subtotal + foodTax + deliveryFee + deliveryTax + serviceFee + serviceTax + tip - discount)
+	var orderTax OrderTax
+	var totalAmount float64
+	if order.HasTaxSnapshot() {
+		orderTax = OrderTax{
+			CountryCode:      order.TaxCountryCode,
+			TaxName:          order.TaxName,
+			FoodPercent:      order.FoodTaxPercent,
+			ServicePercent:   order.ServiceTaxPercent,
+			DeliveryPercent:  order.DeliveryTaxPercent,
+			PricesIncludeTax: order.PricesIncludeTax,
+			FoodTax:          order.FoodTax,
+			ServiceTax:       order.ServiceTax,
+			DeliveryTax:      order.DeliveryTax,
+		}
+		subtotal = order.Subtotal
+		totalAmount = order.Total
+	} else {
+		orderTax = CalculateOrderTax(taxCfg, subtotal, serviceFee, deliveryFee)
+		totalAmount = models.RoundAmount(subtotal + orderTax.Payable() + deliveryFee + serviceFee + tip - discount)
+	}
 
 	// Serialize line items to JSON
 	lineItemsJSON, err := json.Marshal(lineItems)
@@ -247,21 +264,22 @@ func GenerateOrderInvoice(order *models.Order) (*models.OrderInvoice, error) {
 		ChefID:        order.ChefID,
 
 		Subtotal:    subtotal,
-		FoodTax:     foodTax,
+		FoodTax:     orderTax.FoodTax,
 		DeliveryFee: deliveryFee,
-		DeliveryTax: deliveryTax,
+		DeliveryTax: orderTax.DeliveryTax,
 		ServiceFee:  serviceFee,
-		ServiceTax:  serviceTax,
+		ServiceTax:  orderTax.ServiceTax,
 		Tip:         tip,
 		Discount:    discount,
 		TotalAmount: totalAmount,
 
 		CountryCode:        countryCode,
 		Currency:           currency,
-		TaxName:            taxCfg.TaxName,
-		FoodTaxPercent:     taxCfg.FoodPercent,
-		ServiceTaxPercent:  taxCfg.ServicePercent,
-		DeliveryTaxPercent: taxCfg.DeliveryPercent,
+		TaxName:            orderTax.TaxName,
+		FoodTaxPercent:     orderTax.FoodPercent,
+		ServiceTaxPercent:  orderTax.ServicePercent,
+		DeliveryTaxPercent: orderTax.DeliveryPercent,
+		PricesIncludeTax:   orderTax.PricesIncludeTax,
 
 		CustomerName:  order.Customer.FirstName + " " + order.Customer.LastName,
 		CustomerEmail: order.Customer.Email,
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Job is a periodic background task. Jobs run on a fixed interval in their own
+// goroutine; a slow run delays the next tick rather than overlapping it.
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// JobRunner runs registered background jobs until stopped
+type JobRunner struct {
+	jobs    []Job
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	running bool
+	mu      sync.Mutex
+}
+
+var (
+	jobRunner *JobRunner
+	jobsOnce  sync.Once
+)
+
+// GetJobRunner returns the singleton job runner
+func GetJobRunner() *JobRunner {
+	jobsOnce.Do(func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		jobRunner = &JobRunner{
+			ctx:    ctx,
+			cancel: cancel,
+		}
+	})
+	return jobRunner
+}
+
+// Register adds a job. Jobs registered after Start are started immediately.
+func (r *JobRunner) Register(job Job) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.jobs = append(r.jobs, job)
+	if r.running {
+		r.startJob(job)
+	}
+}
+
+// Start launches every registered job
+func (r *JobRunner) Start() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.running {
+		return
+	}
+
+	log.Printf("Starting %d background jobs...", len(r.jobs))
+	for _, job := range r.jobs {
+		r.startJob(job)
+	}
+	r.running = true
+}
+
+func (r *JobRunner) startJob(job Job) {
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+
+		ticker := time.NewTicker(job.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-r.ctx.Done():
+				return
+			case <-ticker.C:
+				if err := job.Run(r.ctx); err != nil {
+					log.Printf("Background job %s failed: %v", job.Name, err)
+				}
+			}
+		}
+	}()
+}
+
+// Stop cancels all jobs and waits for in-flight runs to finish
+func (r *JobRunner) Stop() {
+	r.mu.Lock()
+	if !r.running {
+		r.mu.Unlock()
+		return
+	}
+	r.running = false
+	r.mu.Unlock()
+
+	log.Println("Stopping background jobs...")
+	r.cancel()
+	r.wg.Wait()
+	log.Println("Background jobs stopped")
+}
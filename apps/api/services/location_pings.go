@@ -0,0 +1,245 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/homechef/api/database"
+	"github.com/homechef/api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Location history limits
+const (
+	// PingMinInterval is the shortest gap between stored pings for a partner.
+	// Faster pings still move the live position but are not stored.
+	PingMinInterval = 5 * time.Second
+
+	// MaxPlausibleSpeedKmh is the fastest a partner may appear to move between
+	// pings before the new ping is treated as a GPS jump and rejected
+	MaxPlausibleSpeedKmh = 150.0
+
+	// LocationPingRetention is how long location history is kept
+	LocationPingRetention = 30 * 24 * time.Hour
+
+	// LocationPingPurgeInterval is how often expired history is deleted
+	LocationPingPurgeInterval = time.Hour
+
+	// JumpConfirmPings is how many pings in a row must agree on a position that
+	// jumped from the last stored ping before it is accepted, so one bad fix
+	// cannot pin the partner in place
+	JumpConfirmPings = 3
+)
+
+// jumpCandidateTTL is how long a jumped position waits for the pings that
+// would confirm it before it is dropped
+const jumpCandidateTTL = 5 * time.Minute
+
+// pingJitterKm is movement allowed between pings whatever the implied speed,
+// so GPS noise on pings close together is not mistaken for a jump
+const pingJitterKm = 0.1
+
+// PingResult says what happened to an accepted location ping
+type PingResult string
+
+const (
+	PingStored    PingResult = "stored"
+	PingThrottled PingResult = "throttled"
+)
+
+// LocationError is a reason a location ping was rejected
+type LocationError struct {
+	Message string
+}
+
+func (e *LocationError) Error() string {
+	return e.Message
+}
+
+// RecordLocationPing appends a partner's position to their location history,
+// once per active delivery or once with no delivery when they have none. The
+// ping is compared to the partner's last stored ping: one implying an
+// impossible speed is rejected with a *LocationError unless JumpConfirmPings
+// pings in a row agree on the new position, and one arriving within
+// PingMinInterval is accepted but not stored.
+func RecordLocationPing(db *gorm.DB, partnerID uuid.UUID, deliveryIDs []uuid.UUID, lat, lng, accuracy float64, now time.Time) (PingResult, error) {
+	var last models.DeliveryLocationPing
+	err := db.Where("partner_id = ?", partnerID).Order("recorded_at DESC").First(&last).Error
+	if err == nil {
+		if !plausibleMove(last.Latitude, last.Longitude, last.RecordedAt, lat, lng, now) {
+			confirmed, err := confirmJump(db, partnerID, lat, lng, now)
+			if err != nil {
+				return "", err
+			}
+			if !confirmed {
+				distance := haversineDistance(last.Latitude, last.Longitude, lat, lng)
+				return "", &LocationError{Message: fmt.Sprintf("Location jumped %.1f km since the last update, ignoring it", distance)}
+			}
+		} else {
+			if err := db.Delete(&models.LocationJumpCandidate{}, "partner_id = ?", partnerID).Error; err != nil {
+				return "", fmt.Errorf("failed to clear location jump: %w", err)
+			}
+			if now.Sub(last.RecordedAt) < PingMinInterval {
+				return PingThrottled, nil
+			}
+		}
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", fmt.Errorf("failed to load last location ping: %w", err)
+	}
+
+	pings := make([]models.DeliveryLocationPing, 0, len(deliveryIDs))
+	for i := range deliveryIDs {
+		pings = append(pings, models.DeliveryLocationPing{
+			PartnerID:  partnerID,
+			DeliveryID: &deliveryIDs[i],
+			Latitude:   lat,
+			Longitude:  lng,
+			Accuracy:   accuracy,
+			RecordedAt: now,
+		})
+	}
+	if len(pings) == 0 {
+		pings = append(pings, models.DeliveryLocationPing{
+			PartnerID:  partnerID,
+			Latitude:   lat,
+			Longitude:  lng,
+			Accuracy:   accuracy,
+			RecordedAt: now,
+		})
+	}
+
+	if err := db.Create(&pings).Error; err != nil {
+		return "", fmt.Errorf("failed to store location ping: %w", err)
+	}
+	return PingStored, nil
+}
+
+// plausibleMove reports whether moving between two fixes is within GPS jitter
+// or MaxPlausibleSpeedKmh
+func plausibleMove(fromLat, fromLng float64, fromAt time.Time, toLat, toLng float64, toAt time.Time) bool {
+	distance := haversineDistance(fromLat, fromLng, toLat, toLng)
+	if distance <= pingJitterKm {
+		return true
+	}
+	elapsed := toAt.Sub(fromAt)
+	return elapsed > 0 && distance/elapsed.Hours() <= MaxPlausibleSpeedKmh
+}
+
+// confirmJump records a ping that jumped from the partner's last stored ping
+// and reports whether enough pings in a row now agree on the new position. A
+// ping that does not follow on from the current candidate, or arrives after it
+// has expired, starts a new one.
+// The candidate is stored so pings handled by different instances count
+// together.
+func confirmJump(db *gorm.DB, partnerID uuid.UUID, lat, lng float64, now time.Time) (bool, error) {
+	confirmed := false
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var candidate models.LocationJumpCandidate
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&candidate, "partner_id = ?", partnerID).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err != nil || now.Sub(candidate.RecordedAt) > jumpCandidateTTL ||
+			!plausibleMove(candidate.Latitude, candidate.Longitude, candidate.RecordedAt, lat, lng, now) {
+			candidate = models.LocationJumpCandidate{PartnerID: partnerID}
+		}
+		candidate.Latitude, candidate.Longitude, candidate.RecordedAt = lat, lng, now
+		candidate.Pings++
+
+		if candidate.Pings >= JumpConfirmPings {
+			confirmed = true
+			return tx.Delete(&models.LocationJumpCandidate{}, "partner_id = ?", partnerID).Error
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "partner_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"latitude", "longitude", "recorded_at", "pings"}),
+		}).Create(&candidate).Error
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to record location jump: %w", err)
+	}
+	return confirmed, nil
+}
+
+// SimplifyRoute downsamples a route with Ramer-Douglas-Peucker, dropping points
+// that lie within toleranceMeters of the line through their neighbours. The
+// first and last points are always kept.
+func SimplifyRoute(pings []models.DeliveryLocationPing, toleranceMeters float64) []models.DeliveryLocationPing {
+	if len(pings) < 3 || toleranceMeters <= 0 {
+		return pings
+	}
+
+	keep := make([]bool, len(pings))
+	keep[0], keep[len(pings)-1] = true, true
+
+	stack := [][2]int{{0, len(pings) - 1}}
+	for len(stack) > 0 {
+		span := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		first, last := span[0], span[1]
+
+		farthest, maxDist := -1, toleranceMeters
+		for i := first + 1; i < last; i++ {
+			if d := segmentDistanceMeters(pings[i], pings[first], pings[last]); d > maxDist {
+				farthest, maxDist = i, d
+			}
+		}
+		if farthest < 0 {
+			continue
+		}
+		keep[farthest] = true
+		stack = append(stack, [2]int{first, farthest}, [2]int{farthest, last})
+	}
+
+	simplified := make([]models.DeliveryLocationPing, 0, len(pings))
+	for i, p := range pings {
+		if keep[i] {
+			simplified = append(simplified, p)
+		}
+	}
+	return simplified
+}
+
+// segmentDistanceMeters is the distance from p to the segment a-b, using a flat
+// projection that is accurate enough over the length of a delivery
+func segmentDistanceMeters(p, a, b models.DeliveryLocationPing) float64 {
+	const metersPerDegree = 111320.0
+	scale := math.Cos(a.Latitude * math.Pi / 180)
+
+	px, py := (p.Longitude-a.Longitude)*scale*metersPerDegree, (p.Latitude-a.Latitude)*metersPerDegree
+	bx, by := (b.Longitude-a.Longitude)*scale*metersPerDegree, (b.Latitude-a.Latitude)*metersPerDegree
+
+	lengthSq := bx*bx + by*by
+	if lengthSq == 0 {
+		return math.Hypot(px, py)
+	}
+	t := math.Max(0, math.Min(1, (px*bx+py*by)/lengthSq))
+	return math.Hypot(px-t*bx, py-t*by)
+}
+
+// PurgeLocationPings deletes location history older than LocationPingRetention
+// and expired location jumps
+func PurgeLocationPings(ctx context.Context) error {
+	result := database.DB.WithContext(ctx).
+		Where("recorded_at < ?", time.Now().Add(-LocationPingRetention)).
+		Delete(&models.DeliveryLocationPing{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected > 0 {
+		log.Printf("Location purge: deleted %d pings", result.RowsAffected)
+	}
+
+	// Unconfirmed jumps are dropped on the next ping anyway; this clears those
+	// of partners who stopped sending
+	return database.DB.WithContext(ctx).
+		Where("recorded_at < ?", time.Now().Add(-jumpCandidateTTL)).
+		Delete(&models.LocationJumpCandidate{}).Error
+}
@@ -0,0 +1,197 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/homechef/api/database"
+	"github.com/homechef/api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// StockResetInterval is how often daily portion stock is checked for a reset
+const StockResetInterval = 10 * time.Minute
+
+// stockDateLayout is the format of MenuItem.StockDate
+const stockDateLayout = "2006-01-02"
+
+// StockError is a customer-facing reason an item cannot be ordered in the
+// requested quantity
+type StockError struct {
+	Message string
+}
+
+func (e *StockError) Error() string {
+	return e.Message
+}
+
+// ChefLocation returns the chef's configured timezone, falling back to the
+// server's local time
+func ChefLocation(db *gorm.DB, chefID uuid.UUID) *time.Location {
+	var settings models.ChefSettings
+	if err := db.Select("timezone").Where("chef_id = ?", chefID).First(&settings).Error; err == nil && settings.Timezone != "" {
+		if loc, err := time.LoadLocation(settings.Timezone); err == nil {
+			return loc
+		}
+	}
+	return time.Local
+}
+
+// StockDate is the chef-local date a day's portions belong to
+func StockDate(t time.Time, loc *time.Location) string {
+	return t.In(loc).Format(stockDateLayout)
+}
+
+// ReserveStock takes portions for an order out of each capped item's stock for
+// the day it will be delivered. Items are locked in ID order so concurrent
+// checkouts cannot oversell or deadlock. Orders for today take from the item's
+// daily counter, resetting stock left over from a previous day first, and an
+// item that reaches zero is switched off until the next reset. Orders for a
+// later day are checked against what is already booked for that day and leave
+// the counter alone. Returns a *StockError when an item does not have enough
+// portions left. Must run inside the order transaction.
+func ReserveStock(tx *gorm.DB, chefID uuid.UUID, quantities map[uuid.UUID]int, deliverAt, now time.Time) error {
+	ids := make([]uuid.UUID, 0, len(quantities))
+	for id := range quantities {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i].String() < ids[j].String() })
+
+	loc := ChefLocation(tx, chefID)
+	today := StockDate(now, loc)
+	day := StockDate(deliverAt, loc)
+	when := "today"
+	if day != today {
+		when = "on " + deliverAt.In(loc).Format("Mon 2 Jan")
+	}
+
+	for _, id := range ids {
+		var item models.MenuItem
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&item).Error; err != nil {
+			return fmt.Errorf("failed to lock menu item: %w", err)
+		}
+		if item.DailyLimit <= 0 {
+			continue
+		}
+
+		remaining := item.RemainingQuantity
+		if day != today || item.StockDate != today {
+			var booked int
+			if err := bookedPortions(tx, loc, day).Where("order_items.menu_item_id = ?", id).
+				Scan(&booked).Error; err != nil {
+				return fmt.Errorf("failed to count booked portions: %w", err)
+			}
+			remaining = max(item.DailyLimit-booked, 0)
+		}
+
+		want := quantities[id]
+		if remaining < want {
+			if remaining == 0 {
+				return &StockError{Message: fmt.Sprintf("%s is sold out %s", item.Name, when)}
+			}
+			return &StockError{Message: fmt.Sprintf("Only %d portions of %s are left %s", remaining, item.Name, when)}
+		}
+		if day != today {
+			continue
+		}
+
+		updates := map[string]interface{}{
+			"remaining_quantity": remaining - want,
+			"stock_date":         today,
+		}
+		if remaining-want == 0 {
+			updates["is_available"] = false
+			updates["sold_out_at"] = now
+		}
+		if err := tx.Model(&models.MenuItem{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+			return fmt.Errorf("failed to update menu item stock: %w", err)
+		}
+	}
+	return nil
+}
+
+// RestoreOrderStock puts a cancelled order's portions back, but only into the
+// same day's stock it was taken from: the day it was placed, or the day it was
+// scheduled for. An item switched off by selling out is switched back on.
+func RestoreOrderStock(db *gorm.DB, order *models.Order) error {
+	var items []models.OrderItem
+	if err := db.Where("order_id = ?", order.ID).Find(&items).Error; err != nil {
+		return fmt.Errorf("failed to load order items: %w", err)
+	}
+
+	stockFor := order.CreatedAt
+	if order.ScheduledFor != nil {
+		stockFor = *order.ScheduledFor
+	}
+	orderDate := StockDate(stockFor, ChefLocation(db, order.ChefID))
+
+	for _, item := range items {
+		result := db.Model(&models.MenuItem{}).
+			Where("id = ? AND daily_limit > 0 AND stock_date = ?", item.MenuItemID, orderDate).
+			Updates(map[string]interface{}{
+				"remaining_quantity": gorm.Expr("LEAST(remaining_quantity + ?, daily_limit)", item.Quantity),
+				"is_available":       gorm.Expr("CASE WHEN sold_out_at IS NOT NULL THEN true ELSE is_available END"),
+				"sold_out_at":        nil,
+			})
+		if result.Error != nil {
+			return fmt.Errorf("failed to restore menu item stock: %w", result.Error)
+		}
+	}
+	return nil
+}
+
+// ResetDailyStock refills capped items whose stock belongs to an earlier day in
+// their chef's timezone, less portions booked in advance for the new day, and
+// switches back on items that were switched off by selling out. Items a chef
+// switched off by hand stay off.
+func ResetDailyStock(ctx context.Context) error {
+	db := database.DB.WithContext(ctx)
+	now := time.Now()
+
+	var chefIDs []uuid.UUID
+	if err := db.Model(&models.MenuItem{}).Where("daily_limit > 0").
+		Distinct().Pluck("chef_id", &chefIDs).Error; err != nil {
+		return err
+	}
+
+	var reset int64
+	for _, chefID := range chefIDs {
+		loc := ChefLocation(db, chefID)
+		today := StockDate(now, loc)
+		booked := bookedPortions(db, loc, today).Where("order_items.menu_item_id = menu_items.id")
+		result := db.Model(&models.MenuItem{}).
+			Where("chef_id = ? AND daily_limit > 0 AND (stock_date IS NULL OR stock_date <> ?)", chefID, today).
+			Updates(map[string]interface{}{
+				"remaining_quantity": gorm.Expr("GREATEST(daily_limit - (?), 0)", booked),
+				"stock_date":         today,
+				"is_available":       gorm.Expr("CASE WHEN sold_out_at IS NOT NULL THEN true ELSE is_available END"),
+				"sold_out_at":        nil,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		reset += result.RowsAffected
+	}
+
+	if reset > 0 {
+		log.Printf("Stock reset: refilled %d menu items", reset)
+	}
+	return nil
+}
+
+// bookedPortions sums the portions of live orders scheduled for a chef-local
+// day that were placed before it began. Orders placed on the day itself come
+// out of the daily counter instead. Narrow it to one item before use.
+func bookedPortions(db *gorm.DB, loc *time.Location, day string) *gorm.DB {
+	start, _ := time.ParseInLocation(stockDateLayout, day, loc)
+	return db.Table("order_items").
+		Select("COALESCE(SUM(order_items.quantity), 0)").
+		Joins("JOIN orders ON orders.id = order_items.order_id").
+		Where("orders.scheduled_for >= ? AND orders.scheduled_for < ? AND orders.created_at < ?", start, start.AddDate(0, 0, 1), start).
+		Where("orders.status NOT IN ?", []models.OrderStatus{models.OrderStatusCancelled, models.OrderStatusRefunded})
+}
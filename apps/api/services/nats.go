@@ -19,9 +19,16 @@ const (
 	SubjectOrderUpdated      = "orders.updated"
 	SubjectOrderCancelled    = "orders.cancelled"
 	SubjectOrderDelivered    = "orders.delivered"
+	SubjectOrderReady        = "orders.ready"
 	SubjectChefNewOrder      = "chef.new_order"
 	SubjectDeliveryAssigned  = "delivery.assigned"
 	SubjectDeliveryPickedUp  = "delivery.picked_up"
+	SubjectDeliveryUpdated   = "delivery.updated"
+	SubjectDeliveryLocation  = "delivery.location"
+	SubjectDeliveryOffered   = "delivery.offered"
+	SubjectDeliveryFailed    = "delivery.failed"
+	SubjectPickupAlert       = "delivery.pickup_alert"
+	SubjectDriverLowRating   = "delivery.low_rating"
 	SubjectPaymentSuccess    = "payments.success"
 	SubjectPaymentFailed     = "payments.failed"
 	SubjectUserRegistered    = "users.registered"
@@ -86,6 +86,11 @@ func (s *NotificationService) Start() error {
 		log.Printf("Warning: Failed to subscribe to approval events: %v", err)
 	}
 
+	// Subscribe to catering events
+	if err := s.subscribeToCateringEvents(); err != nil {
+		log.Printf("Warning: Failed to subscribe to catering events: %v", err)
+	}
+
 	s.running = true
 	log.Println("Notification service started successfully")
 	return nil
@@ -265,6 +270,62 @@ func (s *NotificationService) subscribeToDeliveryEvents() error {
 	}
 	s.subscriptions = append(s.subscriptions, sub)
 
+	// Delivery offered to a driver
+	sub, err = s.nats.QueueSubscribe(SubjectDeliveryOffered, "notification-workers", func(msg *nats.Msg) {
+		var event Event
+		if err := json.Unmarshal(msg.Data, &event); err != nil {
+			log.Printf("Failed to unmarshal delivery offered event: %v", err)
+			return
+		}
+		s.handleDeliveryOffered(event)
+	})
+	if err != nil {
+		return err
+	}
+	s.subscriptions = append(s.subscriptions, sub)
+
+	// Delivery attempt failed
+	sub, err = s.nats.QueueSubscribe(SubjectDeliveryFailed, "notification-workers", func(msg *nats.Msg) {
+		var event Event
+		if err := json.Unmarshal(msg.Data, &event); err != nil {
+			log.Printf("Failed to unmarshal delivery failed event: %v", err)
+			return
+		}
+		s.handleDeliveryFailed(event)
+	})
+	if err != nil {
+		return err
+	}
+	s.subscriptions = append(s.subscriptions, sub)
+
+	// Repeated wrong pickup codes at a kitchen
+	sub, err = s.nats.QueueSubscribe(SubjectPickupAlert, "notification-workers", func(msg *nats.Msg) {
+		var event Event
+		if err := json.Unmarshal(msg.Data, &event); err != nil {
+			log.Printf("Failed to unmarshal pickup alert event: %v", err)
+			return
+		}
+		s.handlePickupAlert(event)
+	})
+	if err != nil {
+		return err
+	}
+	s.subscriptions = append(s.subscriptions, sub)
+
+	// Customer gave a driver a low rating
+	sub, err = s.nats.QueueSubscribe(SubjectDriverLowRating, "notification-workers", func(msg *nats.Msg) {
+		var event Event
+		if err := json.Unmarshal(msg.Data, &event); err != nil {
+			log.Printf("Failed to unmarshal driver low rating event: %v", err)
+			return
+		}
+		s.handleDriverLowRating(event)
+	})
+	if err != nil {
+		return err
+	}
+	s.subscriptions = append(s.subscriptions, sub)
+
 	// Delivery picked up
 	sub, err = s.nats.QueueSubscribe(SubjectDeliveryPickedUp, "notification-workers", func(msg *nats.Msg) {
 		var event Event
@@ -495,6 +556,139 @@ func (s *NotificationService) handleDeliveryAssigned(event Event) {
 	}
 }
 
+func (s *NotificationService) handleDeliveryOffered(event Event) {
+	log.Printf("Processing delivery offered event")
+
+	if event.UserID == uuid.Nil {
+		return
+	}
+	data, _ := json.Marshal(event.Data)
+	notification := &models.Notification{
+		UserID:  event.UserID,
+		Type:    "delivery_offered",
+		Title:   "New Delivery Offer",
+		Message: "A delivery near you is waiting for your answer",
+		Data:    string(data),
+	}
+	if err := s.saveNotification(notification); err != nil {
+		log.Printf("Failed to save notification: %v", err)
+	}
+
+	PublishNotification(NotificationEvent{
+		UserID:  event.UserID,
+		Type:    "push",
+		Title:   "New Delivery Offer",
+		Message: "A delivery near you is waiting for your answer",
+		Data:    event.Data,
+	})
+}
+
+func (s *NotificationService) handleDeliveryFailed(event Event) {
+	log.Printf("Processing delivery failed event")
+
+	customerIDStr, ok := event.Data["customer_id"].(string)
+	if !ok {
+		return
+	}
+	customerID, err := uuid.Parse(customerIDStr)
+	if err != nil {
+		log.Printf("Failed to parse customer_id: %v", err)
+		return
+	}
+
+	reason, _ := event.Data["message"].(string)
+	title := "Delivery Attempt Failed"
+	message := reason + ". Let us know if you'd like us to try again or send your order back."
+	if returning, _ := event.Data["returning"].(bool); returning {
+		title = "Order Not Delivered"
+		message = reason + ". Your order is being returned to the kitchen."
+		if refund, _ := event.Data["refund_amount"].(float64); refund > 0 {
+			message += fmt.Sprintf(" A refund of %.2f is on its way.", refund)
+		}
+	}
+
+	data, _ := json.Marshal(event.Data)
+	notification := &models.Notification{
+		UserID:  customerID,
+		Type:    "delivery_failed",
+		Title:   title,
+		Message: message,
+		Data:    string(data),
+	}
+	if err := s.saveNotification(notification); err != nil {
+		log.Printf("Failed to save notification: %v", err)
+	}
+
+	PublishNotification(NotificationEvent{
+		UserID:  customerID,
+		Type:    "push",
+		Title:   title,
+		Message: message,
+		Data:    event.Data,
+	})
+}
+
+func (s *NotificationService) handlePickupAlert(event Event) {
+	log.Printf("Processing pickup alert event: %s", event.ID)
+
+	attempts, _ := event.Data["attempts"].(float64)
+
+	// Alert every admin; someone may be collecting food in another driver's name
+	var admins []models.User
+	database.DB.Where("role = ?", models.RoleAdmin).Find(&admins)
+
+	data, _ := json.Marshal(event.Data)
+	message := fmt.Sprintf("A driver has entered %d wrong pickup codes for one order. Check that the right driver is at the kitchen.", int(attempts))
+	for _, admin := range admins {
+		notification := &models.Notification{
+			UserID:  admin.ID,
+			Type:    "pickup_code_alert",
+			Title:   "Pickup Code Alert",
+			Message: message,
+			Data:    string(data),
+		}
+		if err := s.saveNotification(notification); err != nil {
+			log.Printf("Failed to save pickup alert notification for admin %s: %v", admin.ID, err)
+		}
+	}
+}
+
+func (s *NotificationService) handleDriverLowRating(event Event) {
+	log.Printf("Processing driver low rating event: %s", event.ID)
+
+	rating, _ := event.Data["rating"].(float64)
+	average, _ := event.Data["partner_rating"].(float64)
+	name, _ := event.Data["partner_name"].(string)
+
+	// Alert active fleet managers and admins; someone may hold both roles
+	var recipients []uuid.UUID
+	database.DB.Model(&models.StaffMember{}).
+		Where("staff_role = ? AND is_active = ?", models.StaffRoleFleetManager, true).
+		Pluck("user_id", &recipients)
+	var adminIDs []uuid.UUID
+	database.DB.Model(&models.User{}).Where("role = ?", models.RoleAdmin).Pluck("id", &adminIDs)
+
+	seen := make(map[uuid.UUID]bool)
+	data, _ := json.Marshal(event.Data)
+	message := fmt.Sprintf("%s was rated %d/5 for a delivery. Their average is now %.2f.", name, int(rating), average)
+	for _, userID := range append(recipients, adminIDs...) {
+		if seen[userID] {
+			continue
+		}
+		seen[userID] = true
+		notification := &models.Notification{
+			UserID:  userID,
+			Type:    "driver_low_rating",
+			Title:   "Low Driver Rating",
+			Message: message,
+			Data:    string(data),
+		}
+		if err := s.saveNotification(notification); err != nil {
+			log.Printf("Failed to save driver low rating notification for %s: %v", userID, err)
+		}
+	}
+}
+
 func (s *NotificationService) handleDeliveryPickedUp(event Event) {
 	log.Printf("Processing delivery picked up event")
 
@@ -527,6 +721,153 @@ func (s *NotificationService) handleDeliveryPickedUp(event Event) {
 	}
 }
 
+// subscribeToCateringEvents subscribes to catering marketplace events
+func (s *NotificationService) subscribeToCateringEvents() error {
+	// New request - notify matching chefs
+	sub, err := s.nats.QueueSubscribe(SubjectCateringRequest, "notification-workers", func(msg *nats.Msg) {
+		var event Event
+		if err := json.Unmarshal(msg.Data, &event); err != nil {
+			log.Printf("Failed to unmarshal catering request event: %v", err)
+			return
+		}
+		s.handleCateringRequestCreated(event)
+	})
+	if err != nil {
+		return err
+	}
+	s.subscriptions = append(s.subscriptions, sub)
+
+	// Quote submitted / accepted
+	sub, err = s.nats.QueueSubscribe(SubjectCateringQuote, "notification-workers", func(msg *nats.Msg) {
+		var event Event
+		if err := json.Unmarshal(msg.Data, &event); err != nil {
+			log.Printf("Failed to unmarshal catering quote event: %v", err)
+			return
+		}
+		switch event.Type {
+		case "catering.quote.submitted":
+			s.handleCateringQuoteSubmitted(event)
+		case "catering.quote.accepted":
+			s.handleCateringQuoteAccepted(event)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	s.subscriptions = append(s.subscriptions, sub)
+
+	return nil
+}
+
+func (s *NotificationService) handleCateringRequestCreated(event Event) {
+	log.Printf("Processing catering request created event: %s", event.ID)
+
+	requestIDStr, _ := event.Data["request_id"].(string)
+	var request models.CateringRequest
+	if err := database.DB.First(&request, "id = ?", requestIDStr).Error; err != nil {
+		log.Printf("Catering request %s not found: %v", requestIDStr, err)
+		return
+	}
+
+	var chefs []models.ChefProfile
+	database.DB.Where("is_active = ? AND is_verified = ? AND user_id <> ?", true, true, request.CustomerID).Find(&chefs)
+
+	data, _ := json.Marshal(map[string]interface{}{"request_id": request.ID.String()})
+	for i := range chefs {
+		if !CateringChefMatches(&request, &chefs[i]) {
+			continue
+		}
+		notification := &models.Notification{
+			UserID:  chefs[i].UserID,
+			Type:    "catering_request",
+			Title:   "New Catering Request",
+			Message: fmt.Sprintf("A %s for %d guests in %s is looking for quotes.", request.EventType, request.GuestCount, request.City),
+			Data:    string(data),
+		}
+		if err := s.saveNotification(notification); err != nil {
+			log.Printf("Failed to save catering request notification for chef %s: %v", chefs[i].ID, err)
+		}
+	}
+}
+
+func (s *NotificationService) handleCateringQuoteSubmitted(event Event) {
+	log.Printf("Processing catering quote submitted event: %s", event.ID)
+
+	customerID, err := uuid.Parse(fmt.Sprint(event.Data["customer_id"]))
+	if err != nil {
+		log.Printf("Failed to parse customer_id: %v", err)
+		return
+	}
+	chefName, _ := event.Data["chef_name"].(string)
+
+	data, _ := json.Marshal(event.Data)
+	notification := &models.Notification{
+		UserID:  customerID,
+		Type:    "catering_quote",
+		Title:   "New Catering Quote",
+		Message: fmt.Sprintf("%s has sent you a quote for your catering request.", chefName),
+		Data:    string(data),
+	}
+	if err := s.saveNotification(notification); err != nil {
+		log.Printf("Failed to save notification: %v", err)
+	}
+}
+
+func (s *NotificationService) handleCateringQuoteAccepted(event Event) {
+	log.Printf("Processing catering quote accepted event: %s", event.ID)
+
+	requestID, _ := event.Data["request_id"].(string)
+
+	// Each chef only learns about their own quote
+	if chefIDStr, ok := event.Data["chef_id"].(string); ok {
+		if userID, err := s.resolveChefUserID(chefIDStr, event.Data); err == nil {
+			data, _ := json.Marshal(map[string]interface{}{
+				"request_id":  requestID,
+				"quote_id":    event.Data["quote_id"],
+				"chef_id":     chefIDStr,
+				"total_price": event.Data["total_price"],
+				"status":      models.QuoteStatusAccepted,
+			})
+			notification := &models.Notification{
+				UserID:  userID,
+				Type:    "catering_quote_accepted",
+				Title:   "Catering Quote Accepted!",
+				Message: "Your catering quote has been accepted. The event details are now available.",
+				Data:    string(data),
+			}
+			if err := s.saveNotification(notification); err != nil {
+				log.Printf("Failed to save notification: %v", err)
+			}
+		} else {
+			log.Printf("Failed to resolve chef for catering quote: %v", err)
+		}
+	}
+
+	rejected, _ := event.Data["rejected_chef_ids"].([]interface{})
+	for _, id := range rejected {
+		chefID := fmt.Sprint(id)
+		userID, err := s.resolveChefUserID(chefID, event.Data)
+		if err != nil {
+			continue
+		}
+		data, _ := json.Marshal(map[string]interface{}{
+			"request_id": requestID,
+			"chef_id":    chefID,
+			"status":     models.QuoteStatusRejected,
+		})
+		notification := &models.Notification{
+			UserID:  userID,
+			Type:    "catering_quote_rejected",
+			Title:   "Catering Quote Not Selected",
+			Message: "The customer has chosen another chef for their event.",
+			Data:    string(data),
+		}
+		if err := s.saveNotification(notification); err != nil {
+			log.Printf("Failed to save notification: %v", err)
+		}
+	}
+}
+
 // subscribeToApprovalEvents subscribes to approval lifecycle events
 func (s *NotificationService) subscribeToApprovalEvents() error {
 	// Approval approved - notify the chef
@@ -808,13 +1149,14 @@ func (s *NotificationService) saveNotification(notification *models.Notification
 
 func getOrderStatusMessage(status string) string {
 	messages := map[string]string{
-		"confirmed":   "Your order has been confirmed by the chef!",
-		"preparing":   "Your order is being prepared",
-		"ready":       "Your order is ready for pickup/delivery",
-		"picked_up":   "Your order has been picked up by the delivery partner",
-		"on_the_way":  "Your order is on its way!",
-		"delivered":   "Your order has been delivered. Enjoy!",
-		"cancelled":   "Your order has been cancelled",
+		"confirmed":       "Your order has been confirmed by the chef!",
+		"preparing":       "Your order is being prepared",
+		"ready":           "Your order is ready for pickup/delivery",
+		"driver_assigned": "A delivery partner is on the way to collect your order",
+		"picked_up":       "Your order has been picked up by the delivery partner",
+		"on_the_way":      "Your order is on its way!",
+		"delivered":       "Your order has been delivered. Enjoy!",
+		"cancelled":       "Your order has been cancelled",
 	}
 	if msg, ok := messages[status]; ok {
 		return msg
@@ -0,0 +1,290 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/homechef/api/models"
+	"gorm.io/gorm"
+)
+
+// OrderActor identifies who is moving an order through its lifecycle
+type OrderActor string
+
+const (
+	ActorCustomer OrderActor = "customer"
+	ActorChef     OrderActor = "chef"
+	ActorDriver   OrderActor = "driver"
+	ActorAdmin    OrderActor = "admin"
+	ActorSystem   OrderActor = "system"
+)
+
+// OrderChangeBy identifies who changed an order and where the change came from.
+// It is written to the order event log with every change.
+type OrderChangeBy struct {
+	Role   OrderActor
+	UserID *uuid.UUID
+	Source string // models.OrderEventSource*
+	Notes  string
+}
+
+// ByUser is a change made by a signed-in user through an API handler
+func ByUser(role OrderActor, userID uuid.UUID) OrderChangeBy {
+	return OrderChangeBy{Role: role, UserID: &userID, Source: models.OrderEventSourceHandler}
+}
+
+// BySystem is a change made by the platform itself, e.g. a webhook or job
+func BySystem(source string) OrderChangeBy {
+	return OrderChangeBy{Role: ActorSystem, Source: source}
+}
+
+// WithNotes returns a copy carrying free-text notes for the event log
+func (b OrderChangeBy) WithNotes(notes string) OrderChangeBy {
+	b.Notes = notes
+	return b
+}
+
+// Error codes returned to clients when a status change is refused
+const (
+	ErrCodeInvalidOrderStatus     = "INVALID_ORDER_STATUS"
+	ErrCodeInvalidOrderTransition = "INVALID_ORDER_TRANSITION"
+	ErrCodeOrderStatusConflict    = "ORDER_STATUS_CONFLICT"
+)
+
+// orderTransitions lists, per actor, the statuses an order may move to from
+// each status. Anything not listed is refused.
+var orderTransitions = map[OrderActor]map[models.OrderStatus][]models.OrderStatus{
+	ActorCustomer: {
+		models.OrderStatusPending:  {models.OrderStatusCancelled},
+		models.OrderStatusAccepted: {models.OrderStatusCancelled},
+	},
+	// Chefs decide refunds up to delivery; refunds after delivery are disputes for admins
+	ActorChef: {
+		models.OrderStatusPending:        {models.OrderStatusAccepted, models.OrderStatusCancelled, models.OrderStatusRefunded},
+		models.OrderStatusAccepted:       {models.OrderStatusPreparing, models.OrderStatusCancelled, models.OrderStatusRefunded},
+		models.OrderStatusPreparing:      {models.OrderStatusReady, models.OrderStatusCancelled, models.OrderStatusRefunded},
+		models.OrderStatusReady:          {models.OrderStatusRefunded},
+		models.OrderStatusDriverAssigned: {models.OrderStatusRefunded},
+		models.OrderStatusPickedUp:       {models.OrderStatusRefunded},
+		models.OrderStatusDelivering:     {models.OrderStatusRefunded},
+		models.OrderStatusCancelled:      {models.OrderStatusRefunded},
+	},
+	// Drivers take an order, collect it with the chef's pickup code, then head out
+	ActorDriver: {
+		models.OrderStatusReady:          {models.OrderStatusDriverAssigned},
+		models.OrderStatusDriverAssigned: {models.OrderStatusPickedUp, models.OrderStatusReady},
+		models.OrderStatusPickedUp:       {models.OrderStatusDelivering, models.OrderStatusReady},
+		models.OrderStatusDelivering:     {models.OrderStatusDelivered, models.OrderStatusReady},
+	},
+	ActorSystem: {
+		models.OrderStatusPending:        {models.OrderStatusAccepted, models.OrderStatusCancelled},
+		models.OrderStatusAccepted:       {models.OrderStatusCancelled},
+		models.OrderStatusReady:          {models.OrderStatusDriverAssigned},
+		models.OrderStatusDriverAssigned: {models.OrderStatusPickedUp, models.OrderStatusReady},
+		models.OrderStatusPickedUp:       {models.OrderStatusDelivering, models.OrderStatusReady},
+		// A failed delivery is closed by the platform once the food heads back
+		models.OrderStatusDelivering: {models.OrderStatusDelivered, models.OrderStatusReady, models.OrderStatusCancelled, models.OrderStatusRefunded},
+		models.OrderStatusCancelled:  {models.OrderStatusRefunded},
+	},
+	ActorAdmin: {
+		models.OrderStatusPending:        {models.OrderStatusAccepted, models.OrderStatusCancelled, models.OrderStatusRefunded},
+		models.OrderStatusAccepted:       {models.OrderStatusPreparing, models.OrderStatusCancelled, models.OrderStatusRefunded},
+		models.OrderStatusPreparing:      {models.OrderStatusReady, models.OrderStatusCancelled, models.OrderStatusRefunded},
+		models.OrderStatusReady:          {models.OrderStatusDriverAssigned, models.OrderStatusCancelled, models.OrderStatusRefunded},
+		models.OrderStatusDriverAssigned: {models.OrderStatusPickedUp, models.OrderStatusReady, models.OrderStatusCancelled, models.OrderStatusRefunded},
+		models.OrderStatusPickedUp:       {models.OrderStatusDelivering, models.OrderStatusReady, models.OrderStatusCancelled, models.OrderStatusRefunded},
+		models.OrderStatusDelivering:     {models.OrderStatusDelivered, models.OrderStatusReady, models.OrderStatusCancelled, models.OrderStatusRefunded},
+		models.OrderStatusDelivered:      {models.OrderStatusRefunded},
+		models.OrderStatusCancelled:      {models.OrderStatusRefunded},
+	},
+}
+
+// OrderTransitionError explains why a status change was refused
+type OrderTransitionError struct {
+	Code    string
+	From    models.OrderStatus
+	To      models.OrderStatus
+	Actor   OrderActor
+	Allowed []models.OrderStatus
+}
+
+func (e *OrderTransitionError) Error() string {
+	switch e.Code {
+	case ErrCodeInvalidOrderStatus:
+		return fmt.Sprintf("Unknown order status %q", e.To)
+	case ErrCodeOrderStatusConflict:
+		return "Order status changed while updating, please refresh and try again"
+	}
+	return fmt.Sprintf("Order cannot move from %s to %s", e.From, e.To)
+}
+
+// IsValidOrderStatus reports whether s is a known order status
+func IsValidOrderStatus(s models.OrderStatus) bool {
+	switch s {
+	case models.OrderStatusPending, models.OrderStatusAccepted, models.OrderStatusPreparing,
+		models.OrderStatusReady, models.OrderStatusDriverAssigned, models.OrderStatusPickedUp, models.OrderStatusDelivering,
+		models.OrderStatusDelivered, models.OrderStatusCancelled, models.OrderStatusRefunded:
+		return true
+	}
+	return false
+}
+
+// AllowedOrderTransitions returns the statuses an actor may move an order to
+func AllowedOrderTransitions(from models.OrderStatus, actor OrderActor) []models.OrderStatus {
+	allowed := orderTransitions[actor][from]
+	if allowed == nil {
+		return []models.OrderStatus{}
+	}
+	return allowed
+}
+
+// CheckOrderTransition returns an *OrderTransitionError if the actor may not
+// move an order from one status to another
+func CheckOrderTransition(from, to models.OrderStatus, actor OrderActor) error {
+	if !IsValidOrderStatus(to) {
+		return &OrderTransitionError{Code: ErrCodeInvalidOrderStatus, From: from, To: to, Actor: actor,
+			Allowed: AllowedOrderTransitions(from, actor)}
+	}
+	for _, s := range orderTransitions[actor][from] {
+		if s == to {
+			return nil
+		}
+	}
+	return &OrderTransitionError{Code: ErrCodeInvalidOrderTransition, From: from, To: to, Actor: actor,
+		Allowed: AllowedOrderTransitions(from, actor)}
+}
+
+// UpdateOrderStatus moves an order to a new status on behalf of an actor. It
+// validates the transition, stamps the matching lifecycle timestamp and persists
+// the change together with any extra column updates and an event log entry, in
+// one transaction (nested in the caller's if db is one). The write only
+// succeeds if the order is still in the status it was loaded with, so
+// concurrent updates cannot both win. On success the in-memory order reflects
+// the new status.
+func UpdateOrderStatus(db *gorm.DB, order *models.Order, to models.OrderStatus, by OrderChangeBy, extra map[string]interface{}) error {
+	from := order.Status
+	actor := by.Role
+	if err := CheckOrderTransition(from, to, actor); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{"status": to}
+	switch to {
+	case models.OrderStatusAccepted:
+		order.AcceptedAt = &now
+		updates["accepted_at"] = now
+	case models.OrderStatusReady:
+		// A driver handing an order back leaves the original prep time intact
+		if order.PreparedAt == nil {
+			order.PreparedAt = &now
+			updates["prepared_at"] = now
+		}
+	case models.OrderStatusDriverAssigned:
+		order.DriverAssignedAt = &now
+		updates["driver_assigned_at"] = now
+	case models.OrderStatusPickedUp:
+		order.PickedUpAt = &now
+		updates["picked_up_at"] = now
+	case models.OrderStatusDelivering:
+		if order.PickedUpAt == nil {
+			order.PickedUpAt = &now
+			updates["picked_up_at"] = now
+		}
+	case models.OrderStatusDelivered:
+		order.DeliveredAt = &now
+		updates["delivered_at"] = now
+	case models.OrderStatusCancelled:
+		order.CancelledAt = &now
+		updates["cancelled_at"] = now
+	case models.OrderStatusRefunded:
+		if order.RefundedAt == nil {
+			order.RefundedAt = &now
+			updates["refunded_at"] = now
+		}
+	}
+	for k, v := range extra {
+		updates[k] = v
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Order{}).Where("id = ? AND status = ?", order.ID, from).Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return &OrderTransitionError{Code: ErrCodeOrderStatusConflict, From: from, To: to, Actor: actor}
+		}
+
+		if err := RecordOrderEvent(tx, order.ID, models.OrderEventStatus, string(from), string(to), by); err != nil {
+			return err
+		}
+
+		// Portions of an order called off before it was ready go back on sale.
+		// A failed restore is rolled back on its own and does not block the change.
+		if (to == models.OrderStatusCancelled || to == models.OrderStatusRefunded) && !foodReady(from) {
+			if err := tx.Transaction(func(stx *gorm.DB) error {
+				return RestoreOrderStock(stx, order)
+			}); err != nil {
+				log.Printf("Order %s: %v", order.ID, err)
+			}
+		}
+
+		// A called-off order gives its promo code use back
+		if (to == models.OrderStatusCancelled || to == models.OrderStatusRefunded) && from != models.OrderStatusCancelled {
+			if err := ReleasePromoRedemption(tx, order.ID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	order.Status = to
+	return nil
+}
+
+// foodReady reports whether an order in this status has finished cooking. A
+// cancelled order counts as done so a later refund does not restore it twice.
+func foodReady(status models.OrderStatus) bool {
+	switch status {
+	case models.OrderStatusPending, models.OrderStatusAccepted, models.OrderStatusPreparing:
+		return false
+	}
+	return true
+}
+
+// RecordOrderEvent appends an entry to an order's event log. Pass the
+// transaction when the change itself is transactional.
+func RecordOrderEvent(db *gorm.DB, orderID uuid.UUID, eventType models.OrderEventType, oldValue, newValue string, by OrderChangeBy) error {
+	role := by.Role
+	if role == "" {
+		role = ActorSystem
+	}
+	entry := models.OrderEventLog{
+		OrderID:   orderID,
+		EventType: eventType,
+		OldValue:  oldValue,
+		NewValue:  newValue,
+		ActorID:   by.UserID,
+		ActorRole: string(role),
+		Source:    by.Source,
+		Notes:     by.Notes,
+	}
+	if err := db.Create(&entry).Error; err != nil {
+		return fmt.Errorf("failed to record order event: %w", err)
+	}
+	return nil
+}
+
+// LogOrderEvent records an event outside of a transaction, logging rather than
+// returning failures so the caller's change is never blocked by the audit trail
+func LogOrderEvent(db *gorm.DB, orderID uuid.UUID, eventType models.OrderEventType, oldValue, newValue string, by OrderChangeBy) {
+	if err := RecordOrderEvent(db, orderID, eventType, oldValue, newValue, by); err != nil {
+		log.Printf("Order %s: %v", orderID, err)
+	}
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/homechef/api/database"
+	"github.com/homechef/api/models"
+)
+
+// OrderTax is the tax breakdown for a food order, computed once at checkout and
+// snapshotted on the order
+type OrderTax struct {
+	CountryCode      string
+	TaxName          string
+	FoodPercent      float64
+	ServicePercent   float64
+	DeliveryPercent  float64
+	PricesIncludeTax bool
+	FoodTax          float64
+	ServiceTax       float64
+	DeliveryTax      float64
+}
+
+// Total is the full tax on the order, including food tax already inside
+// tax-inclusive menu prices
+func (t *OrderTax) Total() float64 {
+	return models.RoundAmount(t.FoodTax + t.ServiceTax + t.DeliveryTax)
+}
+
+// Payable is the tax added on top of listed prices
+func (t *OrderTax) Payable() float64 {
+	if t.PricesIncludeTax {
+		return models.RoundAmount(t.ServiceTax + t.DeliveryTax)
+	}
+	return t.Total()
+}
+
+// CalculateOrderTax applies a country's tax config: food tax on the subtotal,
+// service tax on the service fee and delivery tax on the delivery fee. When menu
+// prices include tax the food tax is extracted from the subtotal instead of
+// added to it.
+func CalculateOrderTax(cfg *TaxConfig, subtotal, serviceFee, deliveryFee float64) OrderTax {
+	tax := OrderTax{
+		CountryCode:      cfg.CountryCode,
+		TaxName:          cfg.TaxName,
+		FoodPercent:      cfg.FoodPercent,
+		ServicePercent:   cfg.ServicePercent,
+		DeliveryPercent:  cfg.DeliveryPercent,
+		PricesIncludeTax: cfg.PricesIncludeTax,
+		ServiceTax:       models.RoundAmount(serviceFee * cfg.ServicePercent / 100),
+		DeliveryTax:      models.RoundAmount(deliveryFee * cfg.DeliveryPercent / 100),
+	}
+
+	if cfg.PricesIncludeTax {
+		tax.FoodTax = models.RoundAmount(subtotal - subtotal/(1+cfg.FoodPercent/100))
+	} else {
+		tax.FoodTax = models.RoundAmount(subtotal * cfg.FoodPercent / 100)
+	}
+
+	return tax
+}
+
+// ResolveChefCountry returns the country a chef sells in: an explicit
+// chef.<id>.country_code setting, else the country of the delivery zone for the
+// chef's city, else India.
+func ResolveChefCountry(chef *models.ChefProfile) string {
+	var setting models.PlatformSettings
+	if err := database.DB.Where("key = ?", fmt.Sprintf("chef.%s.country_code", chef.ID.String())).
+		First(&setting).Error; err == nil && setting.Value != "" {
+		return strings.ToUpper(setting.Value)
+	}
+
+	if chef.City != "" {
+		var zone models.DeliveryZone
+		if err := database.DB.Where("LOWER(city) = ?", strings.ToLower(chef.City)).
+			First(&zone).Error; err == nil && zone.Country != "" {
+			return strings.ToUpper(zone.Country)
+		}
+	}
+
+	return "IN"
+}
@@ -0,0 +1,201 @@
+package services
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+)
+
+// TrackingLocationInterval is the fastest a single tracking stream is sent
+// driver coordinates
+const TrackingLocationInterval = 5 * time.Second
+
+// TrackingHeartbeatInterval is how often an idle tracking stream is pinged,
+// kept well under common proxy idle timeouts (30-60s)
+const TrackingHeartbeatInterval = 15 * time.Second
+
+// Kinds of tracking update
+const (
+	TrackingUpdateStatus   = "status"
+	TrackingUpdateLocation = "location"
+)
+
+// TrackingUpdate is pushed to clients watching an order. Status updates only
+// say something changed; the stream reloads the order before sending.
+type TrackingUpdate struct {
+	Kind      string    `json:"kind"`
+	OrderID   uuid.UUID `json:"orderId"`
+	Subject   string    `json:"subject,omitempty"`
+	Latitude  float64   `json:"latitude,omitempty"`
+	Longitude float64   `json:"longitude,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// trackingMessage covers both message shapes seen on orders.* and delivery.*:
+// OrderEvent with a top-level order_id, and Event with order_id inside data
+type trackingMessage struct {
+	OrderID uuid.UUID              `json:"order_id"`
+	Data    map[string]interface{} `json:"data"`
+}
+
+// OrderTracker fans order and delivery events out to in-process watchers. Each
+// API instance subscribes without a queue group so every instance sees every
+// event for the streams it is serving.
+type OrderTracker struct {
+	nats          *NATSClient
+	subscriptions []*nats.Subscription
+	watchers      map[uuid.UUID]map[chan TrackingUpdate]struct{}
+	running       bool
+	mu            sync.Mutex
+}
+
+var (
+	orderTracker     *OrderTracker
+	orderTrackerOnce sync.Once
+)
+
+// GetOrderTracker returns the singleton order tracker
+func GetOrderTracker() *OrderTracker {
+	orderTrackerOnce.Do(func() {
+		orderTracker = &OrderTracker{
+			nats:     GetNATSClient(),
+			watchers: make(map[uuid.UUID]map[chan TrackingUpdate]struct{}),
+		}
+	})
+	return orderTracker
+}
+
+// Start subscribes to order and delivery events
+func (t *OrderTracker) Start() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.running {
+		return nil
+	}
+
+	for _, subject := range []string{"orders.*", "delivery.*"} {
+		sub, err := t.nats.Subscribe(subject, t.handleMessage)
+		if err != nil {
+			for _, s := range t.subscriptions {
+				s.Unsubscribe()
+			}
+			t.subscriptions = nil
+			return err
+		}
+		t.subscriptions = append(t.subscriptions, sub)
+	}
+
+	t.running = true
+	log.Println("Order tracker started")
+	return nil
+}
+
+// Stop unsubscribes and closes every watcher
+func (t *OrderTracker) Stop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.running {
+		return
+	}
+
+	for _, sub := range t.subscriptions {
+		sub.Unsubscribe()
+	}
+	t.subscriptions = nil
+
+	for orderID, chans := range t.watchers {
+		for ch := range chans {
+			close(ch)
+		}
+		delete(t.watchers, orderID)
+	}
+
+	t.running = false
+	log.Println("Order tracker stopped")
+}
+
+// IsRunning reports whether live events are being received
+func (t *OrderTracker) IsRunning() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.running
+}
+
+// Watch registers for updates to one order. The returned cancel func must be
+// called when the watcher goes away. The channel is closed if the tracker stops.
+func (t *OrderTracker) Watch(orderID uuid.UUID) (<-chan TrackingUpdate, func()) {
+	ch := make(chan TrackingUpdate, 16)
+
+	t.mu.Lock()
+	if t.watchers[orderID] == nil {
+		t.watchers[orderID] = make(map[chan TrackingUpdate]struct{})
+	}
+	t.watchers[orderID][ch] = struct{}{}
+	t.mu.Unlock()
+
+	cancel := func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if chans, ok := t.watchers[orderID]; ok {
+			if _, ok := chans[ch]; ok {
+				delete(chans, ch)
+				close(ch)
+			}
+			if len(chans) == 0 {
+				delete(t.watchers, orderID)
+			}
+		}
+	}
+	return ch, cancel
+}
+
+// handleMessage routes an event to the watchers of its order
+func (t *OrderTracker) handleMessage(msg *nats.Msg) {
+	var m trackingMessage
+	if err := json.Unmarshal(msg.Data, &m); err != nil {
+		return
+	}
+
+	orderID := m.OrderID
+	if orderID == uuid.Nil {
+		if s, ok := m.Data["order_id"].(string); ok {
+			orderID, _ = uuid.Parse(s)
+		}
+	}
+	if orderID == uuid.Nil {
+		return
+	}
+
+	update := TrackingUpdate{
+		Kind:      TrackingUpdateStatus,
+		OrderID:   orderID,
+		Subject:   msg.Subject,
+		Timestamp: time.Now().UTC(),
+	}
+	if msg.Subject == SubjectDeliveryLocation {
+		lat, latOK := m.Data["latitude"].(float64)
+		lng, lngOK := m.Data["longitude"].(float64)
+		if !latOK || !lngOK {
+			return
+		}
+		update.Kind = TrackingUpdateLocation
+		update.Latitude = lat
+		update.Longitude = lng
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for ch := range t.watchers[orderID] {
+		// A slow client misses intermediate updates rather than blocking NATS
+		select {
+		case ch <- update:
+		default:
+		}
+	}
+}
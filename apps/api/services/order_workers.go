@@ -0,0 +1,305 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/homechef/api/database"
+	"github.com/homechef/api/models"
+	"github.com/nats-io/nats.go"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// OrderWorkerService runs background consumers that act on orders, as opposed
+// to the notification service which only tells people about them
+type OrderWorkerService struct {
+	nats          *NATSClient
+	subscriptions []*nats.Subscription
+	ctx           context.Context
+	cancel        context.CancelFunc
+	wg            sync.WaitGroup
+	running       bool
+	mu            sync.Mutex
+}
+
+var (
+	orderWorkerService *OrderWorkerService
+	orderWorkerOnce    sync.Once
+)
+
+// GetOrderWorkerService returns the singleton order worker service
+func GetOrderWorkerService() *OrderWorkerService {
+	orderWorkerOnce.Do(func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		orderWorkerService = &OrderWorkerService{
+			nats:   GetNATSClient(),
+			ctx:    ctx,
+			cancel: cancel,
+		}
+	})
+	return orderWorkerService
+}
+
+// Start subscribes the order workers
+func (s *OrderWorkerService) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.running {
+		return nil
+	}
+
+	log.Println("Starting order workers...")
+
+	// Auto-accept new orders for chefs who opted in
+	if err := s.subscribeToAutoAccept(); err != nil {
+		log.Printf("Warning: Failed to subscribe auto-accept worker: %v", err)
+	}
+
+	// Offer ready orders to drivers
+	if err := s.subscribeToDispatch(); err != nil {
+		log.Printf("Warning: Failed to subscribe dispatch worker: %v", err)
+	}
+
+	s.running = true
+	log.Println("Order workers started successfully")
+	return nil
+}
+
+// Stop unsubscribes the order workers and waits for in-flight work
+func (s *OrderWorkerService) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.running {
+		return
+	}
+
+	log.Println("Stopping order workers...")
+	s.cancel()
+
+	for _, sub := range s.subscriptions {
+		sub.Unsubscribe()
+	}
+	s.subscriptions = nil
+
+	s.wg.Wait()
+	s.running = false
+	log.Println("Order workers stopped")
+}
+
+// subscribeToAutoAccept listens for new chef orders
+func (s *OrderWorkerService) subscribeToAutoAccept() error {
+	sub, err := s.nats.QueueSubscribe(SubjectChefNewOrder, "order-workers", func(msg *nats.Msg) {
+		var event OrderEvent
+		if err := json.Unmarshal(msg.Data, &event); err != nil {
+			log.Printf("Failed to unmarshal chef new order event: %v", err)
+			return
+		}
+		s.wg.Add(1)
+		defer s.wg.Done()
+		s.handleAutoAccept(event)
+	})
+	if err != nil {
+		return err
+	}
+	s.subscriptions = append(s.subscriptions, sub)
+	return nil
+}
+
+// subscribeToDispatch listens for orders that are ready for a driver
+func (s *OrderWorkerService) subscribeToDispatch() error {
+	sub, err := s.nats.QueueSubscribe(SubjectOrderReady, "order-workers", func(msg *nats.Msg) {
+		var event OrderEvent
+		if err := json.Unmarshal(msg.Data, &event); err != nil {
+			log.Printf("Failed to unmarshal order ready event: %v", err)
+			return
+		}
+		s.wg.Add(1)
+		defer s.wg.Done()
+		if err := DispatchOrder(event.OrderID); err != nil {
+			log.Printf("Dispatch failed for order %s: %v", event.OrderNumber, err)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	s.subscriptions = append(s.subscriptions, sub)
+	return nil
+}
+
+// handleAutoAccept accepts a new order on the chef's behalf when their settings
+// allow it, logging why when they don't
+func (s *OrderWorkerService) handleAutoAccept(event OrderEvent) {
+	var order models.Order
+	if err := database.DB.Where("id = ?", event.OrderID).First(&order).Error; err != nil {
+		log.Printf("Auto-accept: order %s not found: %v", event.OrderID, err)
+		return
+	}
+
+	// Hold the chef row while counting their active orders, so concurrent
+	// auto-accepts cannot all fit under the cap
+	var reason string
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&models.ChefProfile{}, "id = ?", order.ChefID).Error; err != nil {
+			return err
+		}
+		if reason = CheckAutoAccept(tx, &order, time.Now()); reason != "" {
+			return nil
+		}
+		by := BySystem(models.OrderEventSourceJob).WithNotes("Auto-accepted from chef settings")
+		return UpdateOrderStatus(tx, &order, models.OrderStatusAccepted, by, nil)
+	})
+	if err != nil {
+		log.Printf("Auto-accept skipped for order %s: %v", order.OrderNumber, err)
+		return
+	}
+	if reason != "" {
+		log.Printf("Auto-accept skipped for order %s: %s", order.OrderNumber, reason)
+		return
+	}
+
+	log.Printf("Auto-accepted order %s for chef %s", order.OrderNumber, order.ChefID)
+
+	if err := PublishOrderEvent(SubjectOrderUpdated, OrderEvent{
+		OrderID:     order.ID,
+		OrderNumber: order.OrderNumber,
+		CustomerID:  order.CustomerID,
+		ChefID:      order.ChefID,
+		Status:      string(order.Status),
+		Total:       order.Total,
+	}); err != nil {
+		log.Printf("Failed to publish order updated event: %v", err)
+	}
+}
+
+// CheckAutoAccept returns why an order may not be auto-accepted at the given
+// time, or "" if it may. A zero threshold accepts orders of any size and a zero
+// active-order cap means no cap. Run it with the chef row locked so the cap
+// holds.
+func CheckAutoAccept(db *gorm.DB, order *models.Order, now time.Time) string {
+	if order.Status != models.OrderStatusPending {
+		return fmt.Sprintf("order is %s", order.Status)
+	}
+
+	var settings models.ChefSettings
+	if err := db.Where("chef_id = ?", order.ChefID).First(&settings).Error; err != nil {
+		return "chef has no settings"
+	}
+	if !settings.AutoAcceptOrders {
+		return "auto-accept is off"
+	}
+	if settings.AutoAcceptThreshold > 0 && order.Total > settings.AutoAcceptThreshold {
+		return fmt.Sprintf("total %.2f is over the %.2f threshold", order.Total, settings.AutoAcceptThreshold)
+	}
+
+	var chef models.ChefProfile
+	if err := db.Where("id = ?", order.ChefID).First(&chef).Error; err != nil {
+		return "chef not found"
+	}
+	if !chef.AcceptingOrders {
+		return "chef is not accepting orders"
+	}
+
+	// A scheduled order is checked against the hours it will be cooked in
+	at := now
+	if order.ScheduledFor != nil {
+		at = *order.ScheduledFor
+	}
+	at = at.In(ChefLocation(db, chef.ID))
+	open, err := kitchenOpenAt(db, chef.ID, at)
+	if err != nil {
+		return "chef schedule could not be loaded"
+	}
+	if !open {
+		if order.ScheduledFor != nil {
+			return fmt.Sprintf("kitchen is closed at the scheduled time %s", at.Format("Mon 15:04"))
+		}
+		return "outside schedule hours"
+	}
+
+	if settings.AutoAcceptMaxActive > 0 {
+		var active int64
+		db.Model(&models.Order{}).
+			Where("chef_id = ? AND status IN ?", chef.ID, []models.OrderStatus{
+				models.OrderStatusAccepted, models.OrderStatusPreparing, models.OrderStatusReady, models.OrderStatusDriverAssigned,
+			}).Count(&active)
+		if active >= int64(settings.AutoAcceptMaxActive) {
+			return fmt.Sprintf("%d active orders, cap is %d", active, settings.AutoAcceptMaxActive)
+		}
+	}
+
+	return ""
+}
+
+// kitchenOpenAt reports whether a chef's weekly schedule has them open at a
+// time in their timezone, including the early hours of a window that opened
+// the day before and runs past midnight
+func kitchenOpenAt(db *gorm.DB, chefID uuid.UUID, at time.Time) (bool, error) {
+	today, yesterday := int(at.Weekday()), int(at.AddDate(0, 0, -1).Weekday())
+
+	var schedules []models.ChefSchedule
+	if err := db.Where("chef_id = ? AND day_of_week IN ?", chefID, []int{today, yesterday}).
+		Find(&schedules).Error; err != nil {
+		return false, err
+	}
+	for i := range schedules {
+		s := &schedules[i]
+		if s.DayOfWeek == today && withinSchedule(s, at) {
+			return true, nil
+		}
+		if s.DayOfWeek == yesterday && withinOvernightTail(s, at) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// withinSchedule reports whether now falls in the part of a schedule's window
+// on its own day. A close time before the open time runs past midnight; the
+// hours after midnight are checked with withinOvernightTail.
+func withinSchedule(schedule *models.ChefSchedule, now time.Time) bool {
+	openMin, closeMin, ok := scheduleMinutes(schedule)
+	if !ok {
+		return false
+	}
+	minute := now.Hour()*60 + now.Minute()
+	if closeMin <= openMin {
+		return minute >= openMin
+	}
+	return minute >= openMin && minute < closeMin
+}
+
+// withinOvernightTail reports whether now falls in the hours after midnight of
+// a window that opened the previous day
+func withinOvernightTail(schedule *models.ChefSchedule, now time.Time) bool {
+	openMin, closeMin, ok := scheduleMinutes(schedule)
+	if !ok || closeMin > openMin {
+		return false
+	}
+	return now.Hour()*60+now.Minute() < closeMin
+}
+
+// scheduleMinutes returns a schedule's open and close times as minutes past
+// midnight. Reports false for a closed day or unparseable times.
+func scheduleMinutes(schedule *models.ChefSchedule) (int, int, bool) {
+	if schedule.IsClosed {
+		return 0, 0, false
+	}
+	open, err := time.Parse("15:04", schedule.OpenTime)
+	if err != nil {
+		return 0, 0, false
+	}
+	closeAt, err := time.Parse("15:04", schedule.CloseTime)
+	if err != nil {
+		return 0, 0, false
+	}
+	return open.Hour()*60 + open.Minute(), closeAt.Hour()*60 + closeAt.Minute(), true
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/homechef/api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PromoError is a promo code rejection that is safe to show to the customer
+type PromoError struct {
+	Message string
+}
+
+func (e *PromoError) Error() string {
+	return e.Message
+}
+
+// PromoCheckout describes the order a promo code is being applied to
+type PromoCheckout struct {
+	UserID   uuid.UUID
+	ChefID   uuid.UUID
+	City     string
+	Country  string
+	Subtotal float64
+}
+
+// NormalizePromoCode trims and uppercases a customer-entered code
+func NormalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// ValidatePromoCode checks a code against an order without reserving it. Use it
+// for previews; order creation must go through ApplyPromoCode.
+func ValidatePromoCode(db *gorm.DB, code string, checkout PromoCheckout) (*models.PromoCode, float64, error) {
+	return checkPromoCode(db, code, checkout, false)
+}
+
+// ApplyPromoCode validates a code inside the order transaction and holds a row
+// lock on it, so concurrent checkouts serialise on the same code until the
+// transaction commits. Call RecordPromoRedemption in the same transaction once
+// the order exists.
+func ApplyPromoCode(tx *gorm.DB, code string, checkout PromoCheckout) (*models.PromoCode, float64, error) {
+	return checkPromoCode(tx, code, checkout, true)
+}
+
+func checkPromoCode(db *gorm.DB, code string, checkout PromoCheckout, lock bool) (*models.PromoCode, float64, error) {
+	code = NormalizePromoCode(code)
+	if code == "" {
+		return nil, 0, &PromoError{Message: "Promo code is required"}
+	}
+
+	query := db
+	if lock {
+		query = query.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+
+	var promo models.PromoCode
+	if err := query.Where("code = ?", code).First(&promo).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, 0, &PromoError{Message: "Invalid promo code"}
+		}
+		return nil, 0, err
+	}
+
+	now := time.Now()
+	if !promo.IsLive(now) {
+		return nil, 0, &PromoError{Message: "This promo code has expired or is not active yet"}
+	}
+
+	// Scope
+	if promo.ChefID != nil && *promo.ChefID != checkout.ChefID {
+		return nil, 0, &PromoError{Message: "This promo code is not valid for this chef"}
+	}
+	if promo.City != "" && !strings.EqualFold(promo.City, checkout.City) {
+		return nil, 0, &PromoError{Message: "This promo code is not valid in your city"}
+	}
+	if promo.Country != "" && !strings.EqualFold(promo.Country, checkout.Country) {
+		return nil, 0, &PromoError{Message: "This promo code is not valid in your country"}
+	}
+
+	if checkout.Subtotal < promo.MinSubtotal {
+		return nil, 0, &PromoError{Message: fmt.Sprintf("A minimum subtotal of %.2f is required for this promo code", promo.MinSubtotal)}
+	}
+
+	// Usage limits
+	if promo.MaxRedemptions > 0 && promo.RedemptionCount >= promo.MaxRedemptions {
+		return nil, 0, &PromoError{Message: "This promo code has reached its usage limit"}
+	}
+
+	if promo.PerUserLimit > 0 {
+		var used int64
+		if err := db.Model(&models.PromoRedemption{}).
+			Where("promo_code_id = ? AND user_id = ? AND released_at IS NULL", promo.ID, checkout.UserID).
+			Count(&used).Error; err != nil {
+			return nil, 0, err
+		}
+		if int(used) >= promo.PerUserLimit {
+			return nil, 0, &PromoError{Message: "You have already used this promo code"}
+		}
+	}
+
+	if promo.FirstOrderOnly {
+		var previous int64
+		if err := db.Model(&models.Order{}).
+			Where("customer_id = ? AND status != ?", checkout.UserID, models.OrderStatusCancelled).
+			Count(&previous).Error; err != nil {
+			return nil, 0, err
+		}
+		if previous > 0 {
+			return nil, 0, &PromoError{Message: "This promo code is only valid on your first order"}
+		}
+	}
+
+	discount := promo.CalculateDiscount(checkout.Subtotal)
+	if discount <= 0 {
+		return nil, 0, &PromoError{Message: "This promo code does not apply to this order"}
+	}
+
+	return &promo, discount, nil
+}
+
+// RecordPromoRedemption stores the redemption and bumps the global counter. It
+// must run in the transaction that called ApplyPromoCode.
+func RecordPromoRedemption(tx *gorm.DB, promo *models.PromoCode, userID, orderID uuid.UUID, discount float64) error {
+	redemption := models.PromoRedemption{
+		PromoCodeID: promo.ID,
+		UserID:      userID,
+		OrderID:     orderID,
+		Discount:    discount,
+		FundedBy:    promo.FundedBy,
+	}
+	if err := tx.Create(&redemption).Error; err != nil {
+		return err
+	}
+
+	return tx.Model(&models.PromoCode{}).Where("id = ?", promo.ID).
+		UpdateColumn("redemption_count", gorm.Expr("redemption_count + 1")).Error
+}
+
+// ReleasePromoRedemption gives back the promo code use on an order that was
+// cancelled or refunded, so it no longer counts towards the code's limits.
+// The redemption is kept, marked released, for the code's history.
+func ReleasePromoRedemption(tx *gorm.DB, orderID uuid.UUID) error {
+	var redemption models.PromoRedemption
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("order_id = ? AND released_at IS NULL", orderID).First(&redemption).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Model(&redemption).Update("released_at", time.Now()).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.PromoCode{}).Where("id = ? AND redemption_count > 0", redemption.PromoCodeID).
+		UpdateColumn("redemption_count", gorm.Expr("redemption_count - 1")).Error
+}
@@ -222,17 +222,22 @@ func (s *ProviderService) HandleProviderWebhook(providerCode string, payload []b
 		updates["external_tracking_url"] = trackingURL
 	}
 
+	// Updates writes the new status onto delivery, so keep the old one for the log
+	oldStatus := string(delivery.Status)
 	if err := database.DB.Model(&delivery).Updates(updates).Error; err != nil {
 		return fmt.Errorf("failed to update delivery: %w", err)
 	}
 
+	LogOrderEvent(database.DB, delivery.OrderID, models.OrderEventDelivery, oldStatus, fe3drStatus,
+		BySystem(models.OrderEventSourceWebhook).WithNotes(provider.Code+": "+providerStatus))
+
 	// Publish NATS event
 	_ = PublishEvent(SubjectProviderDeliveryUpdated, "provider.delivery.updated", uuid.Nil, map[string]interface{}{
 		"provider_id":          provider.ID.String(),
 		"provider_code":        provider.Code,
 		"delivery_id":          delivery.ID.String(),
 		"external_delivery_id": externalID,
-		"old_status":           string(delivery.Status),
+		"old_status":           oldStatus,
 		"new_status":           fe3drStatus,
 		"provider_status":      providerStatus,
 	})
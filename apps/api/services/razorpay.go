@@ -231,6 +231,82 @@ func (c *RazorpayClient) FetchPayment(paymentID string) (*PaymentResponse, error
 	return &result, nil
 }
 
+// --- Customers & Saved Tokens ---
+
+// CustomerRequest creates (or, with FailExisting "0", returns) a Razorpay customer
+type CustomerRequest struct {
+	Name         string `json:"name,omitempty"`
+	Email        string `json:"email,omitempty"`
+	Contact      string `json:"contact,omitempty"`
+	FailExisting string `json:"fail_existing"`
+}
+
+// CustomerResponse from Razorpay
+type CustomerResponse struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Email   string `json:"email"`
+	Contact string `json:"contact"`
+}
+
+// TokenResponse is a saved payment method token on a Razorpay customer
+type TokenResponse struct {
+	ID     string `json:"id"`
+	Method string `json:"method"` // card, upi
+	Card   *struct {
+		Last4       string `json:"last4"`
+		Network     string `json:"network"`
+		ExpiryMonth int    `json:"expiry_month"`
+		ExpiryYear  int    `json:"expiry_year"`
+	} `json:"card,omitempty"`
+	VPA *struct {
+		Username string `json:"username"`
+		Handle   string `json:"handle"`
+	} `json:"vpa,omitempty"`
+	ExpiredAt int64 `json:"expired_at"`
+}
+
+// CreateCustomer creates a Razorpay customer, returning the existing one for the
+// same email/contact instead of failing
+func (c *RazorpayClient) CreateCustomer(req *CustomerRequest) (*CustomerResponse, error) {
+	req.FailExisting = "0"
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	resp, err := c.doRequest("POST", "/customers", body)
+	if err != nil {
+		return nil, err
+	}
+
+	var result CustomerResponse
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return &result, nil
+}
+
+// FetchToken retrieves a saved token for a customer
+func (c *RazorpayClient) FetchToken(customerID, tokenID string) (*TokenResponse, error) {
+	resp, err := c.doRequest("GET", fmt.Sprintf("/customers/%s/tokens/%s", customerID, tokenID), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result TokenResponse
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return &result, nil
+}
+
+// DeleteToken removes a saved token from a customer
+func (c *RazorpayClient) DeleteToken(customerID, tokenID string) error {
+	_, err := c.doRequest("DELETE", fmt.Sprintf("/customers/%s/tokens/%s", customerID, tokenID), nil)
+	return err
+}
+
 // --- Webhook Verification ---
 
 // VerifyWebhookSignature validates that a webhook payload came from Razorpay